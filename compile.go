@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/cr0sh/minfuck/mf"
+)
+
+// policyFlags 함수는 코드 생성 명령어에 공통으로 쓰이는 셀 크기, EOF, 테이프 플래그를 등록합니다.
+func policyFlags(fs *flag.FlagSet) *mf.Policy {
+	p := new(mf.Policy)
+	fs.IntVar(&p.CellWidth, "cell-width", 0, "셀 크기 (8, 16, 32)")
+	fs.StringVar(&p.EOF, "eof", "", "EOF 처리 방식 (0, -1, unchanged)")
+	fs.StringVar(&p.Tape, "tape", "", "테이프 처리 방식 (fixed, wrap, grow)")
+	return p
+}
+
+// openMF 함수는 MinFuck 파일을 읽어들입니다. 실패하면 프로그램을 종료합니다.
+func openMF(name string) mf.FileData {
+	f, err := os.Open(name)
+	if err != nil {
+		fmt.Println("파일 여는 중 오류:", err)
+		os.Exit(3)
+	}
+	fd, err := mf.ReadFile(f)
+	f.Close()
+	if err != nil {
+		fmt.Println("MinFuck 코드를 읽는 중 오류:", err)
+		os.Exit(4)
+	}
+	return fd
+}
+
+// openBF 함수는 Brainfuck 파일을 최적화된 MinFuck 코드로 변환합니다. 실패하면 프로그램을 종료합니다.
+func openBF(args []string) mf.FileData {
+	b, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		fmt.Println("파일 여는 중 오류:", err)
+		os.Exit(3)
+	}
+	fd, _ := mf.FromBfCodeOpts(string(b), mf.BfOptions{Mem: memArg(args), Optimize: true})
+	return fd
+}
+
+// writeOutput 함수는 생성한 코드를 입력 파일과 같은 이름, 다른 확장자의 파일에 기록합니다.
+func writeOutput(name, ext string, code string, err error) {
+	writeFile(name[0:len(name)-len(path.Ext(name))]+ext, code, err)
+}
+
+// writeFile 함수는 생성한 코드를 파일에 기록합니다. 코드 생성이나 기록에 실패하면 프로그램을 종료합니다.
+func writeFile(name string, code string, err error) {
+	if err != nil {
+		fmt.Println("코드를 생성하는 중 오류:", err)
+		os.Exit(4)
+	}
+	if err := ioutil.WriteFile(name, []byte(code), 0644); err != nil {
+		fmt.Println("파일 쓰는 중 오류:", err)
+		os.Exit(3)
+	}
+}
+
+func m2c() {
+	fs := flag.NewFlagSet("m2c", flag.ExitOnError)
+	p := policyFlags(fs)
+	args := parseFlags(fs, os.Args[2:])
+	if len(args) < 1 {
+		fmt.Println("변환할 MinFuck 소스 파일이 필요합니다.")
+		help()
+	}
+	c, err := mf.ToCCode(openMF(args[0]), *p)
+	writeOutput(args[0], ".c", c, err)
+}
+
+func b2c() {
+	fs := flag.NewFlagSet("b2c", flag.ExitOnError)
+	p := policyFlags(fs)
+	args := parseFlags(fs, os.Args[2:])
+	if len(args) < 1 {
+		fmt.Println("변환할 Brainfuck 소스 파일이 필요합니다.")
+		help()
+	}
+	c, err := mf.ToCCode(openBF(args), *p)
+	writeOutput(args[0], ".c", c, err)
+}
+
+func m2go() {
+	fs := flag.NewFlagSet("m2go", flag.ExitOnError)
+	pkg := fs.String("pkg", "main", "생성할 Go 패키지 이름")
+	out := fs.String("o", "", "출력 파일 이름 (기본값: 입력 파일 이름.go)")
+	args := parseFlags(fs, os.Args[2:])
+	if len(args) < 1 {
+		fmt.Println("변환할 MinFuck 소스 파일이 필요합니다.")
+		help()
+	}
+	code, err := mf.ToGoCode(openMF(args[0]), *pkg)
+	if *out != "" {
+		writeFile(*out, code, err)
+	} else {
+		writeOutput(args[0], ".go", code, err)
+	}
+}
+
+func m2wasm() {
+	fs := flag.NewFlagSet("m2wasm", flag.ExitOnError)
+	out := fs.String("o", "", "출력 파일 이름 (기본값: 입력 파일 이름.wasm)")
+	args := parseFlags(fs, os.Args[2:])
+	if len(args) < 1 {
+		fmt.Println("변환할 MinFuck 소스 파일이 필요합니다.")
+		help()
+	}
+	mod, err := mf.ToWasm(openMF(args[0]))
+	if *out != "" {
+		writeFile(*out, string(mod), err)
+	} else {
+		writeOutput(args[0], ".wasm", string(mod), err)
+	}
+}
+
+func m2ll() {
+	if len(os.Args) < 3 {
+		fmt.Println("변환할 MinFuck 소스 파일이 필요합니다.")
+		help()
+	}
+	ir, err := mf.ToLLVM(openMF(os.Args[2]))
+	writeOutput(os.Args[2], ".ll", ir, err)
+}
+
+func build() {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	out := fs.String("o", "", "출력 파일 이름 (기본값: 입력 파일 이름에서 확장자를 뺀 이름)")
+	args := parseFlags(fs, os.Args[2:])
+	if len(args) < 1 {
+		fmt.Println("빌드할 MinFuck 소스 파일이 필요합니다.")
+		help()
+	}
+	name := *out
+	if name == "" {
+		name = args[0][0 : len(args[0])-len(path.Ext(args[0]))]
+		if name == args[0] {
+			name += ".out"
+		}
+	}
+	b, err := mf.ToELF(openMF(args[0]))
+	writeFile(name, string(b), err)
+	if err := os.Chmod(name, 0755); err != nil {
+		fmt.Println("파일 쓰는 중 오류:", err)
+		os.Exit(3)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cr0sh/minfuck/mf"
+)
+
+func format() {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := fs.Bool("w", false, "결과를 원래 파일에 기록합니다")
+	diff := fs.Bool("d", false, "원래 코드와의 차이를 출력합니다")
+	minify := fs.Bool("minify", false, "주석과 공백, 상쇄되는 명령어를 지웁니다")
+	width := fs.Int("width", mf.DefaultFormatWidth, "한 줄의 최대 길이")
+	args := parseFlags(fs, os.Args[2:])
+
+	formatSource := func(src string) (string, error) {
+		if *minify {
+			return mf.MinifyBf(src)
+		}
+		return mf.FormatBf(src, *width)
+	}
+	if len(args) == 0 {
+		if *write {
+			fmt.Println("-w를 지정하려면 파일 이름이 필요합니다.")
+			os.Exit(-1)
+		}
+		b, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Println("표준 입력을 읽는 중 오류:", err)
+			os.Exit(3)
+		}
+		out, err := formatSource(string(b))
+		if err != nil {
+			fmt.Println("소스 코드를 정리하는 중 오류:", err)
+			os.Exit(4)
+		}
+		if *diff {
+			fmt.Print(unifiedDiff("<standard input>", string(b), out))
+		} else {
+			fmt.Print(out)
+		}
+		return
+	}
+	for _, name := range args {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			fmt.Println("파일 여는 중 오류:", err)
+			os.Exit(3)
+		}
+		out, err := formatSource(string(b))
+		if err != nil {
+			fmt.Printf("소스 코드를 정리하는 중 오류: %s: %v\n", name, err)
+			os.Exit(4)
+		}
+		if *diff {
+			fmt.Print(unifiedDiff(name, string(b), out))
+		}
+		if *write {
+			if out != string(b) {
+				writeFile(name, out, nil)
+			}
+		} else if !*diff {
+			fmt.Print(out)
+		}
+	}
+}
+
+// diffContext는 unifiedDiff가 바뀐 줄 앞뒤로 출력하는 줄의 수입니다.
+const diffContext = 3
+
+// diffLine 구조체는 편집 스크립트의 한 줄입니다. kind는 ' '(같음), '-'(삭제), '+'(추가) 중 하나입니다.
+type diffLine struct {
+	kind     byte
+	text     string
+	old, new int // 이 줄 앞까지의 원래 파일과 새 파일의 줄 수
+}
+
+// splitLines 함수는 텍스트를 줄 단위로 나눕니다. 마지막 줄바꿈 뒤의 빈 줄은 포함하지 않습니다.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// unifiedDiff 함수는 a를 b로 바꾸는 unified diff를 반환합니다. 두 텍스트가 같으면 빈 문자열을 반환합니다.
+func unifiedDiff(name, a, b string) string {
+	if a == b {
+		return ""
+	}
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s.orig\n+++ %s\n", name, name)
+	for k := 0; k < len(lines); {
+		if lines[k].kind == ' ' {
+			k++
+			continue
+		}
+		// 바뀐 줄 사이의 같은 줄이 diffContext*2개 이하이면 하나의 덩어리로 합칩니다.
+		start, end := k-diffContext, k
+		if start < 0 {
+			start = 0
+		}
+		for same := 0; end < len(lines) && same <= diffContext*2; end++ {
+			if lines[end].kind == ' ' {
+				same++
+			} else {
+				same = 0
+			}
+		}
+		for end > k && lines[end-1].kind == ' ' {
+			end--
+		}
+		if end += diffContext; end > len(lines) {
+			end = len(lines)
+		}
+
+		var oldN, newN int
+		for _, l := range lines[start:end] {
+			if l.kind != '+' {
+				oldN++
+			}
+			if l.kind != '-' {
+				newN++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(lines[start].old, oldN), hunkRange(lines[start].new, newN))
+		for _, l := range lines[start:end] {
+			fmt.Fprintf(&sb, "%c%s\n", l.kind, l.text)
+		}
+		k = end
+	}
+	return sb.String()
+}
+
+// diffLines 함수는 x를 y로 바꾸는 최소 편집 스크립트를 반환합니다.
+// 공통 접두사와 접미사를 떼어 낸 나머지를 Myers 알고리즘의 선형 공간 변형으로 나누어 비교하며,
+// 연속된 삭제와 추가는 삭제가 먼저 오도록 정렬합니다.
+func diffLines(x, y []string) []diffLine {
+	var lines []diffLine
+	same := func(s []string) {
+		for _, t := range s {
+			lines = append(lines, diffLine{kind: ' ', text: t})
+		}
+	}
+	var walk func(x, y []string)
+	walk = func(x, y []string) {
+		n := 0
+		for n < len(x) && n < len(y) && x[n] == y[n] {
+			n++
+		}
+		same(x[:n])
+		x, y = x[n:], y[n:]
+		n = 0
+		for n < len(x) && n < len(y) && x[len(x)-1-n] == y[len(y)-1-n] {
+			n++
+		}
+		suffix := x[len(x)-n:]
+		x, y = x[:len(x)-n], y[:len(y)-n]
+
+		switch {
+		case len(x) == 0:
+			for _, t := range y {
+				lines = append(lines, diffLine{kind: '+', text: t})
+			}
+		case len(y) == 0:
+			for _, t := range x {
+				lines = append(lines, diffLine{kind: '-', text: t})
+			}
+		default:
+			// 접두사와 접미사를 떼어 냈으므로 편집 거리는 2 이상이고, 양쪽 부분 문제의 편집 거리는 그보다 작습니다.
+			xs, ys, xe, ye := middleSnake(x, y)
+			walk(x[:xs], y[:ys])
+			same(x[xs:xe])
+			walk(x[xe:], y[ye:])
+		}
+		same(suffix)
+	}
+	walk(x, y)
+
+	for k := 0; k < len(lines); {
+		if lines[k].kind == ' ' {
+			k++
+			continue
+		}
+		e := k
+		for e < len(lines) && lines[e].kind != ' ' {
+			e++
+		}
+		run := lines[k:e]
+		sort.SliceStable(run, func(i, j int) bool { return run[i].kind == '-' && run[j].kind == '+' })
+		k = e
+	}
+	var old, new int
+	for k := range lines {
+		lines[k].old, lines[k].new = old, new
+		if lines[k].kind != '+' {
+			old++
+		}
+		if lines[k].kind != '-' {
+			new++
+		}
+	}
+	return lines
+}
+
+// middleSnake 함수는 x를 y로 바꾸는 최단 편집 경로의 가운데에 있는 스네이크(대각선으로 이어진 같은 줄들)를 찾아
+// 시작점 (xs, ys)와 끝점 (xe, ye)를 반환합니다. 앞쪽과 뒤쪽에서 동시에 탐색하므로 O(len(x)+len(y)) 공간을 사용합니다.
+func middleSnake(x, y []string) (xs, ys, xe, ye int) {
+	n, m := len(x), len(y)
+	delta := n - m
+	max := (n + m + 1) / 2
+	off := max + 1
+	// vf[off+k]는 앞쪽에서 대각선 k(=i-j) 위로 도달한 가장 먼 i, vb[off+k]는 뒤집은 x, y에서 같은 값입니다.
+	vf, vb := make([]int, 2*max+3), make([]int, 2*max+3)
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			i := vf[off+k-1] + 1
+			if k == -d || (k != d && vf[off+k-1] < vf[off+k+1]) {
+				i = vf[off+k+1]
+			}
+			i0, j := i, i-k
+			for i < n && j < m && x[i] == y[j] {
+				i, j = i+1, j+1
+			}
+			vf[off+k] = i
+			if c := delta - k; delta%2 != 0 && c >= -(d-1) && c <= d-1 && i+vb[off+c] >= n {
+				return i0, i0 - k, i, j
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			i := vb[off+k-1] + 1
+			if k == -d || (k != d && vb[off+k-1] < vb[off+k+1]) {
+				i = vb[off+k+1]
+			}
+			i0, j := i, i-k
+			for i < n && j < m && x[n-1-i] == y[m-1-j] {
+				i, j = i+1, j+1
+			}
+			vb[off+k] = i
+			if c := delta - k; delta%2 == 0 && c >= -d && c <= d && vf[off+c]+i >= n {
+				return n - i, m - j, n - i0, m - (i0 - k)
+			}
+		}
+	}
+	panic("middleSnake: 편집 경로를 찾지 못했습니다")
+}
+
+// hunkRange 함수는 unified diff 덩어리 머리말의 줄 범위를 작성합니다. start는 0부터 셉니다.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/cr0sh/minfuck/mf"
+)
+
+func genText() {
+	fs := flag.NewFlagSet("gen-text", flag.ExitOnError)
+	toMF := fs.Bool("mf", false, "Brainfuck 대신 MinFuck 코드를 생성합니다")
+	out := fs.String("o", "", "출력 파일 이름 (기본값: 표준 출력, -mf이면 필수)")
+	args := parseFlags(fs, os.Args[2:])
+	if len(args) < 1 {
+		fmt.Println("출력할 문자열이 필요합니다.")
+		help()
+	}
+	text, err := unescape(args[0])
+	if err != nil {
+		fmt.Println("문자열을 읽는 중 오류:", err)
+		os.Exit(-1)
+	}
+	code := mf.GenText(text)
+	if !*toMF {
+		if *out != "" {
+			writeFile(*out, code+"\n", nil)
+		} else {
+			fmt.Println(code)
+		}
+		return
+	}
+	if *out == "" {
+		fmt.Println("MinFuck 코드를 기록할 파일 이름(-o)이 필요합니다.")
+		os.Exit(-1)
+	}
+	fd, _ := mf.FromBfCodeOpts(code, mf.BfOptions{Mem: 4096, Optimize: true})
+	writeFile(*out, fd.String(), nil)
+}
+
+// unescape 함수는 \n, \x41, é 같은 Go 문자열 이스케이프를 해석합니다.
+func unescape(s string) (string, error) {
+	var b []byte
+	for len(s) > 0 {
+		r, multibyte, tail, err := strconv.UnquoteChar(s, 0)
+		if err != nil {
+			return "", fmt.Errorf("잘못된 이스케이프: %q", s)
+		}
+		if multibyte {
+			b = utf8.AppendRune(b, r)
+		} else {
+			b = append(b, byte(r))
+		}
+		s = tail
+	}
+	return string(b), nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/cr0sh/minfuck/mf"
+)
+
+func info() {
+	if len(os.Args) < 3 {
+		fmt.Println("정보를 출력할 MinFuck 코드가 필요합니다.")
+		help()
+	}
+	f, err := os.Open(os.Args[2])
+	if err != nil {
+		fmt.Println("파일 여는 중 오류:", err)
+		os.Exit(3)
+	}
+	fd, err := mf.ReadFile(f)
+	f.Close()
+	if err != nil {
+		fmt.Println("MinFuck 코드를 읽는 중 오류:", err)
+		os.Exit(4)
+	}
+
+	ids := fd.Sections()
+	format := "기존 포맷"
+	if len(ids) > 0 || fd.Compressed() {
+		format = "섹션 포맷"
+	}
+	fmt.Printf("파일: %s\n", os.Args[2])
+	fmt.Printf("포맷: %s\n", format)
+	fmt.Printf("메모리 번지 제한: %d\n", fd.MemSize())
+	if fd.Compressed() {
+		fmt.Printf("코드 크기: %d바이트 (압축 후 %d바이트)\n", fd.CodeSize(), fd.CompressedSize())
+	} else {
+		fmt.Printf("코드 크기: %d바이트\n", fd.CodeSize())
+	}
+	fmt.Printf("인코딩: %s\n", fd.Encoding())
+
+	if format == "섹션 포맷" {
+		fmt.Println("\n섹션:")
+		if fd.Compressed() {
+			fmt.Printf("    %s  %d바이트\n", mf.SectionCompressedCode, fd.CompressedSize())
+		} else {
+			fmt.Printf("    %s  %d바이트\n", mf.SectionCode, fd.CodeSize())
+		}
+		for _, id := range ids {
+			b, _ := fd.Section(id)
+			fmt.Printf("    %s  %d바이트\n", id, len(b))
+		}
+	}
+	if pub, err := fd.Signer(); err == nil {
+		fmt.Printf("\n서명자: %s\n", hex.EncodeToString(pub))
+	}
+
+	meta, err := fd.Metadata()
+	if err != nil {
+		fmt.Println("\n메타데이터를 읽는 중 오류:", err)
+	} else if len(meta) > 0 {
+		fmt.Println("\n메타데이터:")
+		for _, e := range meta {
+			fmt.Printf("    %s = %s\n", e.Key, e.Value)
+		}
+	}
+
+	code, err := fd.Code()
+	if err != nil {
+		fmt.Println("\nMinFuck 코드를 읽는 중 오류:", err)
+		os.Exit(4)
+	}
+	st, err := mf.Stats(code, fd.Encoding())
+	fmt.Println("\n코드 통계:")
+	fmt.Printf("    니블: %d\n", st.Nibbles)
+	fmt.Printf("    명령어: %d (압축 %d, NOP %d, 확장 %d)\n", st.Instrs, st.Compressed, st.Nops, st.Ext)
+	for op, n := range st.Ops {
+		fmt.Printf("    %s: %d\n", mf.ToBf(byte(op)), n)
+	}
+	fmt.Printf("    최대 중첩 깊이: %d\n", st.MaxDepth)
+	if err != nil {
+		fmt.Println("코드를 해석하는 중 오류:", err)
+		os.Exit(4)
+	}
+}
@@ -0,0 +1,285 @@
+// Package build는 메모리 포인터의 위치를 추적하면서 MinFuck 코드를 작성하는 빌더입니다.
+//
+// 셀을 할당하고 셀 단위의 연산을 호출하면 포인터 이동은 자동으로 계산되며, 모든 루프는 시작한 셀에서 끝나므로
+// 작성하는 동안 포인터의 위치는 항상 정해져 있습니다. 셀의 값을 비우거나 옮길 때는 셋과 곱셈 확장 명령어를 사용하므로,
+// 작성된 코드는 EncodingV2를 사용합니다.
+//
+// 비교, 곱셈, 나눗셈, 십진수 입출력 같은 루틴은 셀이 32비트라고 가정하며, 입력 셀을 바꾸지 않고 결과를 기록할 셀을 받습니다.
+// 결과는 계산을 모두 마친 뒤에 기록하므로 결과 셀은 입력 셀과 같아도 됩니다. 루틴이 계산에 사용하는 임시 셀은
+// 자동으로 할당되고 해제되며, 각 루틴의 문서에 동시에 사용하는 임시 셀의 수가 적혀 있습니다.
+//
+//	b := build.New()
+//	n := b.Alloc()
+//	b.Set(n, 3)
+//	b.Loop(n, func() {
+//		b.PrintString("hi\n")
+//		b.Add(n, -1)
+//	})
+//	b.Free(n)
+//	fd := b.FileData()
+package build
+
+import (
+	"fmt"
+
+	"github.com/cr0sh/minfuck/mf"
+)
+
+// 셀 i는 메모리 번지 cellBase + cellStride*i에 놓입니다.
+// MinFuckVM은 짝수 번지를 0이 아닌 값으로 초기화하므로, 0으로 초기화되는 홀수 번지만 사용합니다.
+const (
+	cellBase   = 9
+	cellStride = 2
+)
+
+// 확장 명령어의 인자로 기록할 수 있는 셋의 최댓값과 곱셈의 범위입니다. 더 큰 상수는 나누어 계산합니다.
+const (
+	maxSet = 1<<30 - 1
+	maxMul = 1<<29 - 1
+	minMul = -1 << 29
+)
+
+// maxAdd는 Add가 + 또는 -를 반복하여 더하는 상수의 최댓값입니다. 더 큰 상수는 임시 셀을 통해 더합니다.
+const maxAdd = 16
+
+// Cell은 Builder가 할당한 셀의 번호입니다.
+type Cell int
+
+// Builder 구조체는 MinFuck 코드를 작성합니다. 빈 값은 사용할 수 없으며 New로 생성해야 합니다.
+type Builder struct {
+	nw   *mf.NibbleWriterOptimized
+	pos  mf.SourcePos // 작성하는 코드의 원본 위치
+	addr int          // 메모리 포인터가 가리키는 번지
+	used []bool       // 각 셀이 할당되어 있는지 여부
+	done bool
+}
+
+// New 함수는 새 Builder를 생성합니다.
+func New() *Builder {
+	nw := &mf.NibbleWriterOptimized{NibbleWriter: new(mf.NibbleWriter), Encoding: mf.EncodingV2}
+	return &Builder{nw: nw}
+}
+
+// SetSourceMap 메서드는 이후에 작성하는 코드의 원본 위치를 sm에 기록합니다. 위치는 SetPos로 설정합니다.
+func (b *Builder) SetSourceMap(sm *mf.SourceMap) {
+	b.nw.Flush()
+	b.nw.Map = sm
+}
+
+// SetPos 메서드는 이후에 작성하는 코드의 원본 위치를 설정합니다. 컴파일러가 소스맵을 만들 때 사용합니다.
+func (b *Builder) SetPos(pos mf.SourcePos) {
+	b.pos = pos
+}
+
+// Alloc 메서드는 사용하지 않는 셀 중 번호가 가장 작은 셀을 할당합니다. 할당한 셀의 값은 항상 0입니다.
+func (b *Builder) Alloc() Cell {
+	for i, u := range b.used {
+		if !u {
+			b.used[i] = true
+			return Cell(i)
+		}
+	}
+	b.used = append(b.used, true)
+	return Cell(len(b.used) - 1)
+}
+
+// AllocN 메서드는 셀 n개를 할당합니다.
+func (b *Builder) AllocN(n int) []Cell {
+	c := make([]Cell, n)
+	for i := range c {
+		c[i] = b.Alloc()
+	}
+	return c
+}
+
+// Free 메서드는 셀을 비우고 해제합니다. 해제한 셀은 다시 할당될 수 있습니다.
+func (b *Builder) Free(cells ...Cell) {
+	for _, c := range cells {
+		b.Set(c, 0)
+		b.used[c] = false
+	}
+}
+
+// MemSize 메서드는 지금까지 사용한 셀의 수를 반환합니다.
+func (b *Builder) MemSize() int {
+	return len(b.used)
+}
+
+// check 메서드는 c번 셀이 할당되어 있는지 확인합니다. 아니면 panic이 발생합니다.
+func (b *Builder) check(c Cell) {
+	if b.done {
+		panic("FileData를 호출한 뒤에는 코드를 작성할 수 없습니다")
+	}
+	if c < 0 || int(c) >= len(b.used) || !b.used[c] {
+		panic(fmt.Sprintf("할당되지 않은 셀 %d", c))
+	}
+}
+
+// move 메서드는 메모리 포인터를 c번 셀로 옮깁니다.
+func (b *Builder) move(c Cell) {
+	b.check(c)
+	addr := cellBase + cellStride*int(c)
+	if d := addr - b.addr; d > 0 {
+		b.put(2, d)
+	} else {
+		b.put(3, -d)
+	}
+	b.addr = addr
+}
+
+func (b *Builder) put(nb byte, n int) {
+	for ; n > 0; n-- {
+		b.nw.PutAt(nb, b.pos)
+	}
+}
+
+// Set 메서드는 c번 셀의 값을 v로 설정합니다.
+func (b *Builder) Set(c Cell, v uint32) {
+	b.move(c)
+	b.nw.PutExt(mf.ExtSet, b.pos, int64(v&maxSet))
+	if v > maxSet {
+		// v의 상위 2비트는 (v>>30)<<15 에 2^15를 곱해 더합니다.
+		t := b.Alloc()
+		b.Set(t, v>>30<<15)
+		b.MulAdd(t, c, 1<<15)
+		b.Free(t)
+	}
+}
+
+// Add 메서드는 c번 셀에 상수 n을 더합니다. 값은 2^32로 나눈 나머지를 계산하므로 음수를 더하면 뺄셈이 됩니다.
+func (b *Builder) Add(c Cell, n int) {
+	switch v := uint32(n); {
+	case v == 0:
+	case v <= maxAdd:
+		b.move(c)
+		b.put(0, int(v))
+	case -v <= maxAdd:
+		b.move(c)
+		b.put(1, int(-v))
+	default:
+		t := b.Alloc()
+		b.Set(t, v)
+		b.Move(t, c)
+		b.Free(t)
+	}
+}
+
+// MulAdd 메서드는 dst번 셀에 src번 셀의 값 * k를 더합니다. src번 셀은 바뀌지 않습니다.
+// src와 dst가 같으면 panic이 발생합니다.
+func (b *Builder) MulAdd(src, dst Cell, k uint32) {
+	if src == dst {
+		panic(fmt.Sprintf("셀 %d를 자기 자신에게 더할 수 없습니다", src))
+	}
+	if int32(k) < minMul || int32(k) > maxMul {
+		// k = hi*2^15 + lo 로 나누어 src*hi에 다시 2^15를 곱합니다.
+		if lo := k & (1<<15 - 1); lo != 0 {
+			b.MulAdd(src, dst, lo)
+		}
+		t := b.Alloc()
+		b.MulAdd(src, t, k>>15)
+		b.MulAdd(t, dst, 1<<15)
+		b.Free(t)
+		return
+	}
+	b.check(dst)
+	b.move(src)
+	b.nw.PutExt(mf.ExtMul, b.pos, int64((dst-src)*cellStride), int64(int32(k)))
+}
+
+// Move 메서드는 dst의 각 셀에 src번 셀의 값을 더하고 src번 셀을 비웁니다.
+func (b *Builder) Move(src Cell, dst ...Cell) {
+	b.Copy(src, dst...)
+	b.Set(src, 0)
+}
+
+// Copy 메서드는 dst의 각 셀에 src번 셀의 값을 더합니다. src번 셀은 바뀌지 않습니다.
+func (b *Builder) Copy(src Cell, dst ...Cell) {
+	for _, d := range dst {
+		b.MulAdd(src, d, 1)
+	}
+}
+
+// loop 메서드는 c번 셀이 0이 아닌 동안 body를 반복하는 루프를 압축된 [ ]로 작성합니다.
+func (b *Builder) loop(c Cell, body func()) {
+	b.move(c)
+	b.nw.PutJump(4, b.pos)
+	body()
+	b.move(c)
+	b.nw.PutJump(5, b.pos)
+}
+
+// Loop 메서드는 c번 셀이 0이 아닌 동안 body를 반복합니다. 루프를 끝내려면 body 안에서 c번 셀을 0으로 만들어야 합니다.
+// 한 번 반복할 때마다 할당한 셀이 다시 0이어야 하므로, body 안에서 할당한 셀은 body 안에서 해제해야 합니다.
+// 그렇지 않으면 panic이 발생합니다.
+func (b *Builder) Loop(c Cell, body func()) {
+	before := append([]bool(nil), b.used...)
+	b.loop(c, body)
+	for i, u := range b.used {
+		if u && (i >= len(before) || !before[i]) {
+			panic(fmt.Sprintf("루프 안에서 할당한 셀 %d를 해제하지 않았습니다", i))
+		}
+	}
+}
+
+// If 메서드는 c번 셀이 0이 아니면 body를 한 번 실행합니다. c번 셀은 바뀌지 않습니다.
+func (b *Builder) If(c Cell, body func()) {
+	t := b.Alloc()
+	b.Copy(c, t)
+	b.loop(t, func() {
+		b.Set(t, 0)
+		body()
+	})
+	b.Free(t)
+}
+
+// IfElse 메서드는 c번 셀이 0이 아니면 then을, 0이면 els를 실행합니다. c번 셀은 바뀌지 않습니다.
+func (b *Builder) IfElse(c Cell, then, els func()) {
+	e := b.Alloc()
+	b.Set(e, 1)
+	b.If(c, func() {
+		b.Set(e, 0)
+		then()
+	})
+	b.loop(e, func() {
+		b.Set(e, 0)
+		els()
+	})
+	b.Free(e)
+}
+
+// Print 메서드는 c번 셀의 값을 문자로 출력합니다.
+func (b *Builder) Print(c Cell) {
+	b.move(c)
+	b.put(6, 1)
+}
+
+// Read 메서드는 입력에서 한 문자를 읽어 c번 셀에 기록합니다. 입력이 끝났으면 0입니다.
+func (b *Builder) Read(c Cell) {
+	b.move(c)
+	b.put(7, 1)
+}
+
+// PrintString 메서드는 임시 셀 하나의 값을 문자열의 각 바이트로 바꾸며 출력합니다.
+func (b *Builder) PrintString(s string) {
+	t := b.Alloc()
+	var v byte
+	for i := 0; i < len(s); i++ {
+		if d := int(s[i]) - int(v); d >= -maxAdd && d <= maxAdd {
+			b.Add(t, d)
+		} else {
+			b.Set(t, uint32(s[i]))
+		}
+		v = s[i]
+		b.Print(t)
+	}
+	b.Free(t)
+}
+
+// FileData 메서드는 작성한 코드와 사용한 셀의 수로 MinFuck 파일을 만듭니다. 그 뒤에는 코드를 작성할 수 없습니다.
+func (b *Builder) FileData() mf.FileData {
+	b.nw.Align()
+	b.done = true
+	fd := mf.NewFileData(uint32(len(b.used)), b.nw.Nibbles)
+	fd.SetEncoding(mf.EncodingV2)
+	return fd
+}
@@ -0,0 +1,183 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/cr0sh/minfuck/mf"
+	"github.com/cr0sh/minfuck/mf/internal/vmtest"
+)
+
+// printDigit 함수는 c번 셀의 값을 0 ~ 9의 한 자리 수로 보고 출력합니다.
+func printDigit(b *Builder, c Cell) {
+	b.Add(c, '0')
+	b.Print(c)
+	b.Add(c, -'0')
+}
+
+var builderTestEntries = []struct {
+	build func(b *Builder)
+	in    string
+	out   string
+}{
+	{func(b *Builder) { b.PrintString("Hello, World!\n") }, "", "Hello, World!\n"},
+	{func(b *Builder) {
+		// 셋과 덧셈은 2^32로 나눈 나머지를 계산합니다.
+		c := b.Alloc()
+		b.Set(c, 4294967295)
+		b.Add(c, 'A'+1)
+		b.Print(c)
+		b.Set(c, 3000000000)
+		b.Add(c, 1294967296+'B')
+		b.Print(c)
+		b.Add(c, 1000)
+		b.Add(c, -1000+1)
+		b.Print(c)
+	}, "", "ABC"},
+	{func(b *Builder) {
+		x, y, z := b.Alloc(), b.Alloc(), b.Alloc()
+		b.Set(x, 3)
+		b.Copy(x, y, z)
+		b.Copy(x, y)
+		b.Move(x, z)
+		for _, c := range []Cell{x, y, z} {
+			printDigit(b, c)
+		}
+	}, "", "066"},
+	{func(b *Builder) {
+		x, y := b.Alloc(), b.Alloc()
+		// 2 * (2^31 + 3) = 2^32 + 6
+		b.Set(x, 2)
+		b.MulAdd(x, y, 1<<31+3)
+		b.Free(x)
+		printDigit(b, y)
+	}, "", "6"},
+	{func(b *Builder) {
+		n, s := b.Alloc(), b.Alloc()
+		b.Set(n, 4)
+		b.Loop(n, func() {
+			t := b.Alloc()
+			b.Copy(n, t)
+			b.Move(t, s)
+			b.Free(t)
+			b.Add(n, -1)
+		})
+		printDigit(b, n)
+		b.Add(s, 'A'-10)
+		b.Print(s)
+	}, "", "0A"},
+	{func(b *Builder) {
+		c := b.Alloc()
+		for _, v := range []uint32{0, 1, 4294967295} {
+			b.Set(c, v)
+			b.IfElse(c, func() { b.PrintString("T") }, func() { b.PrintString("F") })
+			b.If(c, func() { b.PrintString("!") })
+		}
+		// If와 IfElse는 c번 셀을 바꾸지 않으므로 c는 2^32-1입니다.
+		b.Add(c, 1)
+		printDigit(b, c)
+	}, "", "FT!T!0"},
+	{func(b *Builder) {
+		c := b.Alloc()
+		b.Read(c)
+		b.Loop(c, func() {
+			b.Add(c, 1)
+			b.Print(c)
+			b.Read(c)
+		})
+	}, "HAL", "IBM"},
+}
+
+func TestBuilder(t *testing.T) {
+	for n, test := range builderTestEntries {
+		b := New()
+		test.build(b)
+		fd := b.FileData()
+		if fd.MemSize() != uint32(b.MemSize()) {
+			t.Errorf("Test #%d failed: got memsize %d, expected %d", n+1, fd.MemSize(), b.MemSize())
+		}
+		for _, backend := range []mf.Backend{mf.BackendInterp, mf.BackendClosure} {
+			if out, err := vmtest.Run(fd, backend, test.in); err != nil || out != test.out {
+				t.Errorf("Test #%d failed (backend %v): got %q (%v), expected %q", n+1, backend, out, err, test.out)
+			}
+		}
+	}
+}
+
+func TestBuilderAlloc(t *testing.T) {
+	b := New()
+	x, y, z := b.Alloc(), b.Alloc(), b.Alloc()
+	b.Free(y)
+	if c := b.Alloc(); c != y {
+		t.Errorf("got cell %d, expected %d", c, y)
+	}
+	b.Free(x, y, z)
+	if c := b.AllocN(4); c[0] != x || c[3] != 3 || b.MemSize() != 4 {
+		t.Errorf("got cells %v, memsize %d", c, b.MemSize())
+	}
+}
+
+func TestBuilderSourceMap(t *testing.T) {
+	b := New()
+	sm := new(mf.SourceMap)
+	b.SetSourceMap(sm)
+	c := b.Alloc()
+	b.Set(c, 3)
+	for line := 1; line <= 3; line++ {
+		b.SetPos(mf.SourcePos{Line: line, Col: 1})
+		b.Loop(c, func() {
+			b.Add(c, -1)
+			b.PrintString("x")
+		})
+		b.Add(c, line)
+	}
+	fd := b.FileData()
+	code, err := fd.Code()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make(map[int]bool)
+	for pc := uint64(0); pc < uint64(len(code)*2); pc++ {
+		if r, ok := sm.Lookup(pc); ok {
+			lines[r.Start.Line] = true
+		}
+	}
+	// 위치를 설정하기 전에 작성한 셋은 소스맵에 기록되지 않습니다.
+	if _, ok := sm.Lookup(0); ok || len(lines) != 3 {
+		t.Errorf("got lines %v, expected 1, 2 and 3", lines)
+	}
+}
+
+var builderPanicTestEntries = []func(b *Builder){
+	func(b *Builder) { b.Set(0, 1) },
+	func(b *Builder) {
+		c := b.Alloc()
+		b.Free(c)
+		b.Print(c)
+	},
+	func(b *Builder) {
+		c := b.Alloc()
+		b.MulAdd(c, c, 2)
+	},
+	func(b *Builder) {
+		c := b.Alloc()
+		b.Loop(c, func() { b.Alloc() })
+	},
+	func(b *Builder) {
+		c := b.Alloc()
+		b.FileData()
+		b.Print(c)
+	},
+}
+
+func TestBuilderPanic(t *testing.T) {
+	for n, f := range builderPanicTestEntries {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Test #%d failed: expected panic", n+1)
+				}
+			}()
+			f(New())
+		}()
+	}
+}
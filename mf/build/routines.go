@@ -0,0 +1,375 @@
+package build
+
+// 셀의 비트 수와, 셀의 값을 십진수로 출력할 때의 최대 자릿수입니다.
+const (
+	cellBits  = 32
+	numDigits = 10
+)
+
+// pow2 함수는 2^k를 반환합니다.
+func pow2(k int) uint32 {
+	return uint32(1) << uint(k)
+}
+
+// release 메서드는 값이 0인 것이 확실한 셀을 코드를 작성하지 않고 해제합니다.
+func (b *Builder) release(cells ...Cell) {
+	for _, c := range cells {
+		b.check(c)
+		b.used[c] = false
+	}
+}
+
+// copyOf 메서드는 c번 셀의 값을 새로 할당한 셀에 복사하여 반환합니다.
+func (b *Builder) copyOf(c Cell) Cell {
+	t := b.Alloc()
+	b.Copy(c, t)
+	return t
+}
+
+// when 메서드는 c번 셀이 0이 아니면 body를 한 번 실행합니다. c번 셀은 비워집니다.
+func (b *Builder) when(c Cell, body func()) {
+	b.loop(c, func() {
+		b.Set(c, 0)
+		body()
+	})
+}
+
+// result 메서드는 dst번 셀을 r번 셀의 값으로 설정하고 r번 셀을 해제합니다.
+// 계산을 모두 마친 뒤에 결과를 기록하므로 dst는 입력 셀과 같아도 됩니다.
+func (b *Builder) result(r, dst Cell) {
+	b.Set(dst, 0)
+	b.Move(r, dst)
+	b.release(r)
+}
+
+// flag 메서드는 c번 셀이 0이 아니면 1, 0이면 0인 새로 할당한 셀을 반환합니다. not이면 반대입니다. c번 셀은 비워집니다.
+func (b *Builder) flag(c Cell, not bool) Cell {
+	f := b.Alloc()
+	if not {
+		b.Set(f, 1)
+		b.when(c, func() { b.Set(f, 0) })
+	} else {
+		b.when(c, func() { b.Set(f, 1) })
+	}
+	b.release(c)
+	return f
+}
+
+// satSub 메서드는 c번 셀이 0이 아닌 동안 n번까지 1을 뺍니다. n번 반복하므로 n이 작을 때 사용합니다.
+func (b *Builder) satSub(c Cell, n int) {
+	for i := 0; i < n; i++ {
+		t := b.copyOf(c)
+		b.when(t, func() { b.Add(c, -1) })
+		b.release(t)
+	}
+}
+
+// double 메서드는 c번 셀의 값을 두 배로 만듭니다.
+func (b *Builder) double(c Cell) {
+	t := b.copyOf(c)
+	b.Move(t, c)
+	b.release(t)
+}
+
+// bit 메서드는 x번 셀의 k번 비트를 새로 할당한 셀에 기록하고 x번 셀에서 뺍니다. x의 k번보다 낮은 비트는 0이어야 합니다.
+// x * 2^(31-k)는 x의 k번 비트만 남기므로, 그 값이 0인지 확인하여 비트를 구합니다.
+func (b *Builder) bit(x Cell, k int) Cell {
+	t := b.Alloc()
+	b.MulAdd(x, t, pow2(cellBits-1-k))
+	d := b.flag(t, false)
+	b.MulAdd(d, x, -pow2(k))
+	return d
+}
+
+// bits 메서드는 x번 셀의 값을 비트로 나누어 새로 할당한 cellBits개의 셀에 낮은 비트부터 기록합니다. x번 셀은 비워집니다.
+func (b *Builder) bits(x Cell) []Cell {
+	bs := make([]Cell, cellBits)
+	for k := range bs {
+		bs[k] = b.bit(x, k)
+	}
+	return bs
+}
+
+// shift 메서드는 비트 셀들의 값을 한 칸씩 높은 쪽으로 옮기고, 가장 높은 비트를 dst번 셀에 더합니다.
+// cellBits번 반복하면 높은 비트부터 차례로 꺼낼 수 있습니다.
+func (b *Builder) shift(bs []Cell, dst Cell) {
+	b.Move(bs[len(bs)-1], dst)
+	for k := len(bs) - 1; k > 0; k-- {
+		b.Move(bs[k-1], bs[k])
+	}
+}
+
+// Not 메서드는 x번 셀이 0이면 dst번 셀을 1로, 아니면 0으로 설정합니다. 임시 셀 2개를 사용합니다.
+func (b *Builder) Not(x, dst Cell) {
+	b.result(b.flag(b.copyOf(x), true), dst)
+}
+
+// And 메서드는 x번과 y번 셀이 모두 0이 아니면 dst번 셀을 1로, 아니면 0으로 설정합니다. 임시 셀 3개를 사용합니다.
+func (b *Builder) And(x, y, dst Cell) {
+	r := b.Alloc()
+	t := b.copyOf(x)
+	b.when(t, func() {
+		u := b.copyOf(y)
+		b.when(u, func() { b.Set(r, 1) })
+		b.release(u)
+	})
+	b.release(t)
+	b.result(r, dst)
+}
+
+// Or 메서드는 x번과 y번 셀 중 하나라도 0이 아니면 dst번 셀을 1로, 아니면 0으로 설정합니다. 임시 셀 2개를 사용합니다.
+func (b *Builder) Or(x, y, dst Cell) {
+	r := b.Alloc()
+	for _, c := range []Cell{x, y} {
+		t := b.copyOf(c)
+		b.when(t, func() { b.Set(r, 1) })
+		b.release(t)
+	}
+	b.result(r, dst)
+}
+
+// Equal 메서드는 x번과 y번 셀의 값이 같으면 dst번 셀을 1로, 아니면 0으로 설정합니다. 임시 셀 2개를 사용합니다.
+func (b *Builder) Equal(x, y, dst Cell) {
+	t := b.copyOf(x)
+	b.MulAdd(y, t, ^uint32(0))
+	b.result(b.flag(t, true), dst)
+}
+
+// Less 메서드는 x번 셀의 값이 y번 셀의 값보다 작으면 dst번 셀을 1로, 아니면 0으로 설정합니다. 임시 셀 6개를 사용합니다.
+// 낮은 비트부터 두 값의 비트가 다르면 결과를 y의 비트로 바꾸므로, 마지막으로 다른 가장 높은 비트가 결과를 정합니다.
+// 값과 관계없이 cellBits번의 비트 비교를 실행합니다.
+func (b *Builder) Less(x, y, dst Cell) {
+	tx, ty, r := b.copyOf(x), b.copyOf(y), b.Alloc()
+	for k := 0; k < cellBits; k++ {
+		bx := b.bit(tx, k)
+		by := b.bit(ty, k)
+		d := b.copyOf(bx)
+		b.MulAdd(by, d, ^uint32(0))
+		b.when(d, func() {
+			b.Set(r, 0)
+			b.Copy(by, r)
+		})
+		b.release(d)
+		b.Free(bx, by)
+	}
+	b.release(tx, ty)
+	b.result(r, dst)
+}
+
+// Greater 메서드는 x번 셀의 값이 y번 셀의 값보다 크면 dst번 셀을 1로, 아니면 0으로 설정합니다. 임시 셀 6개를 사용합니다.
+func (b *Builder) Greater(x, y, dst Cell) {
+	b.Less(y, x, dst)
+}
+
+// Compare 메서드는 x번과 y번 셀의 값을 비교하여 작으면 -1 (2^32-1), 같으면 0, 크면 1을 dst번 셀에 설정합니다.
+// 임시 셀 8개를 사용합니다.
+func (b *Builder) Compare(x, y, dst Cell) {
+	l, g := b.Alloc(), b.Alloc()
+	b.Less(x, y, l)
+	b.Less(y, x, g)
+	b.MulAdd(l, g, ^uint32(0))
+	b.Free(l)
+	b.result(g, dst)
+}
+
+// Mul 메서드는 x번과 y번 셀의 값을 곱하여 2^32로 나눈 나머지를 dst번 셀에 설정합니다. 임시 셀 4개를 사용합니다.
+// y의 각 비트가 1이면 x * 2^k를 더하므로, 값과 관계없이 cellBits번 반복합니다.
+func (b *Builder) Mul(x, y, dst Cell) {
+	t, r := b.copyOf(y), b.Alloc()
+	for k := 0; k < cellBits; k++ {
+		bk := b.bit(t, k)
+		b.when(bk, func() { b.MulAdd(x, r, pow2(k)) })
+		b.release(bk)
+	}
+	b.release(t)
+	b.result(r, dst)
+}
+
+// DivMod 메서드는 n번 셀의 값을 d번 셀의 값으로 나눈 몫과 나머지를 q번과 r번 셀에 설정합니다. q와 r은 서로 달라야 합니다.
+// d가 0이면 몫은 2^32-1, 나머지는 n입니다. 임시 셀 45개를 사용합니다.
+// 높은 비트부터 나머지에 더하면서 나머지가 d 이상이면 d를 빼고 몫의 비트를 1로 설정하는 방식으로, 값과 관계없이 cellBits번 반복합니다.
+func (b *Builder) DivMod(n, d, q, r Cell) {
+	if q == r {
+		panic("몫과 나머지를 같은 셀에 기록할 수 없습니다")
+	}
+	tq, tr := b.Alloc(), b.Alloc()
+	// ge 함수는 나머지가 d 이상이면 1, 아니면 0인 새로 할당한 셀을 반환합니다.
+	ge := func() Cell {
+		l := b.Alloc()
+		b.Less(tr, d, l)
+		return b.flag(l, true)
+	}
+	// 나머지를 두 배로 만들면 넘칠 수 있으므로, d가 2^31 이상이면 몫은 0 또는 1입니다.
+	t := b.copyOf(d)
+	for k := 0; k < cellBits-1; k++ {
+		b.Free(b.bit(t, k))
+	}
+	big := b.flag(t, false)
+	small := b.flag(b.copyOf(big), true)
+	b.when(big, func() {
+		b.Copy(n, tr)
+		f := ge()
+		b.when(f, func() {
+			b.Set(tq, 1)
+			b.MulAdd(d, tr, ^uint32(0))
+		})
+		b.release(f)
+	})
+	b.when(small, func() {
+		t := b.copyOf(n)
+		bs := b.bits(t)
+		cnt := b.Alloc()
+		b.Set(cnt, cellBits)
+		b.loop(cnt, func() {
+			b.double(tr)
+			b.shift(bs, tr)
+			b.double(tq)
+			f := ge()
+			b.when(f, func() {
+				b.Add(tq, 1)
+				b.MulAdd(d, tr, ^uint32(0))
+			})
+			b.release(f)
+			b.Add(cnt, -1)
+		})
+		b.release(cnt, t)
+		b.release(bs...)
+	})
+	b.release(big, small)
+	b.result(tq, q)
+	b.result(tr, r)
+}
+
+// PrintNum 메서드는 c번 셀의 값을 십진수로 출력합니다. 임시 셀 47개를 사용합니다.
+// 높은 비트부터 각 자릿수를 두 배로 만들고 비트를 더하는 double dabble 방식으로 자릿수를 구한 뒤, 앞의 0을 빼고 출력합니다.
+func (b *Builder) PrintNum(c Cell) {
+	t := b.copyOf(c)
+	bs := b.bits(t)
+	digits := b.AllocN(numDigits)
+	cnt := b.Alloc()
+	b.Set(cnt, cellBits)
+	b.loop(cnt, func() {
+		carry := b.Alloc()
+		b.shift(bs, carry)
+		for _, d := range digits {
+			// 5 이상인 자릿수는 두 배로 만들면 다음 자릿수로 올라갑니다.
+			ge5 := b.copyOf(d)
+			b.satSub(ge5, 4)
+			next := b.flag(ge5, false)
+			b.double(d)
+			b.Move(carry, d)
+			b.MulAdd(next, d, ^uint32(9))
+			b.Move(next, carry)
+			b.release(next)
+		}
+		// 2^32 - 1도 numDigits 자리이므로 마지막 자릿수에서 올라가는 값은 없습니다.
+		b.release(carry)
+		b.Add(cnt, -1)
+	})
+	b.release(cnt, t)
+	b.release(bs...)
+
+	started := b.Alloc()
+	for i := numDigits - 1; i > 0; i-- {
+		d := digits[i]
+		f := b.copyOf(d)
+		b.Copy(started, f)
+		b.when(f, func() {
+			b.Set(started, 1)
+			b.Add(d, '0')
+			b.Print(d)
+		})
+		b.release(f)
+		b.Free(d)
+	}
+	b.Add(digits[0], '0')
+	b.Print(digits[0])
+	b.Free(digits[0], started)
+}
+
+// isDigit 메서드는 c번 셀의 값이 숫자 문자이면 dst번 셀을 1로, 아니면 0으로 설정합니다.
+// c-'0'에서 9번까지 1을 빼서 0이 되면 c-'0' < 10입니다.
+func (b *Builder) isDigit(c, dst Cell) {
+	t := b.copyOf(c)
+	b.Add(t, -'0')
+	b.satSub(t, 9)
+	b.result(b.flag(t, true), dst)
+}
+
+// ReadNum 메서드는 입력에서 십진수를 읽어 dst번 셀에 설정합니다. 임시 셀 7개를 사용합니다.
+// 숫자가 아닌 문자는 건너뛰고, 숫자 다음의 첫 문자까지 읽습니다. 숫자를 읽기 전에 입력이 끝나면 0이며,
+// 2^32 이상인 수는 2^32로 나눈 나머지가 됩니다.
+func (b *Builder) ReadNum(dst Cell) {
+	v, c, digit, skip := b.Alloc(), b.Alloc(), b.Alloc(), b.Alloc()
+	b.Read(c)
+
+	// 입력이 끝나지 않았고(0이 아님) 숫자가 아닌 동안 다음 문자를 읽습니다.
+	cond := func() {
+		b.isDigit(c, digit)
+		t := b.copyOf(c)
+		b.when(t, func() {
+			b.Set(skip, 1)
+			u := b.copyOf(digit)
+			b.when(u, func() { b.Set(skip, 0) })
+			b.release(u)
+		})
+		b.release(t)
+	}
+	cond()
+	b.loop(skip, func() {
+		b.Set(skip, 0)
+		b.Read(c)
+		cond()
+	})
+
+	b.isDigit(c, digit)
+	b.loop(digit, func() {
+		b.double(v)
+		t := b.copyOf(v)
+		b.MulAdd(t, v, 4)
+		b.Free(t)
+		b.Copy(c, v)
+		b.Add(v, -'0')
+		b.Read(c)
+		b.isDigit(c, digit)
+	})
+	b.Free(c)
+	b.release(digit, skip)
+	b.result(v, dst)
+}
+
+// StrCompare 메서드는 x와 y의 셀들에 한 문자씩 기록된 두 문자열을 사전순으로 비교하여
+// x가 앞서면 -1 (2^32-1), 같으면 0, 뒤에 오면 1을 dst번 셀에 설정합니다. 임시 셀 13개를 사용합니다.
+// 문자열은 값이 0인 첫 셀이나 슬라이스의 끝에서 끝나며, 문자는 32비트 값으로 비교합니다.
+func (b *Builder) StrCompare(x, y []Cell, dst Cell) {
+	r, done, zero := b.Alloc(), b.Alloc(), b.Alloc()
+	at := func(s []Cell, i int) Cell {
+		if i < len(s) {
+			return s[i]
+		}
+		return zero
+	}
+	for i := 0; i < len(x) || i < len(y); i++ {
+		xi, yi := at(x, i), at(y, i)
+		active := b.flag(b.copyOf(done), true)
+		b.when(active, func() {
+			// 두 문자가 다르면 그 비교가 결과이고, 같은 문자가 0이면 두 문자열이 함께 끝납니다.
+			c := b.Alloc()
+			b.Compare(xi, yi, c)
+			t := b.copyOf(c)
+			b.when(t, func() {
+				b.Copy(c, r)
+				b.Set(done, 1)
+			})
+			b.release(t)
+			b.Free(c)
+			end := b.flag(b.copyOf(xi), true)
+			b.when(end, func() { b.Set(done, 1) })
+			b.release(end)
+		})
+		b.release(active)
+	}
+	b.Free(done)
+	b.release(zero)
+	b.result(r, dst)
+}
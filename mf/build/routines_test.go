@@ -0,0 +1,267 @@
+package build
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cr0sh/minfuck/mf"
+	"github.com/cr0sh/minfuck/mf/internal/vmtest"
+)
+
+// routineTestValues는 루틴을 검사할 값입니다. 작은 값은 모두, 큰 값은 비트의 경계 근처를 검사합니다.
+var routineTestValues = func() []uint32 {
+	v := make([]uint32, 0, 64)
+	for i := uint32(0); i <= 17; i++ {
+		v = append(v, i)
+	}
+	return append(v, 99, 100, 255, 256, 1000, 65535, 65536, 123456789, 1<<30, 1<<31-1, 1<<31, 1<<31+1,
+		3000000000, 4294967294, 4294967295)
+}()
+
+// readU32 함수는 입력의 4바이트를 리틀 엔디언 32비트 값으로 읽어 c번 셀에 더합니다.
+func readU32(b *Builder, c Cell) {
+	t := b.Alloc()
+	for i := 0; i < 4; i++ {
+		b.Read(t)
+		b.MulAdd(t, c, 1<<uint(8*i))
+	}
+	b.Free(t)
+}
+
+func appendU32(in []byte, v uint32) []byte {
+	return append(in, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+// expect 함수는 e번 셀에서 c번 셀의 값을 빼서, 0이면 .을, 아니면 X를 출력합니다. e번 셀은 해제됩니다.
+func expect(b *Builder, c, e Cell) {
+	b.MulAdd(c, e, ^uint32(0))
+	b.IfElse(e, func() { b.PrintString("X") }, func() { b.PrintString(".") })
+	b.Free(e)
+}
+
+func b2u(v bool) uint32 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func divmod(x, y uint32) []uint32 {
+	if y == 0 {
+		return []uint32{^uint32(0), x}
+	}
+	return []uint32{x / y, x % y}
+}
+
+var routineTestEntries = []struct {
+	name    string
+	outs    int
+	scratch int
+	emit    func(b *Builder, in, out []Cell)
+	want    func(x, y uint32) []uint32
+}{
+	{"Not", 1, 2, func(b *Builder, in, out []Cell) { b.Not(in[0], out[0]) },
+		func(x, y uint32) []uint32 { return []uint32{b2u(x == 0)} }},
+	{"And", 1, 3, func(b *Builder, in, out []Cell) { b.And(in[0], in[1], out[0]) },
+		func(x, y uint32) []uint32 { return []uint32{b2u(x != 0 && y != 0)} }},
+	{"Or", 1, 2, func(b *Builder, in, out []Cell) { b.Or(in[0], in[1], out[0]) },
+		func(x, y uint32) []uint32 { return []uint32{b2u(x != 0 || y != 0)} }},
+	{"Equal", 1, 2, func(b *Builder, in, out []Cell) { b.Equal(in[0], in[1], out[0]) },
+		func(x, y uint32) []uint32 { return []uint32{b2u(x == y)} }},
+	{"Less", 1, 6, func(b *Builder, in, out []Cell) { b.Less(in[0], in[1], out[0]) },
+		func(x, y uint32) []uint32 { return []uint32{b2u(x < y)} }},
+	{"Greater", 1, 6, func(b *Builder, in, out []Cell) { b.Greater(in[0], in[1], out[0]) },
+		func(x, y uint32) []uint32 { return []uint32{b2u(x > y)} }},
+	{"Compare", 1, 8, func(b *Builder, in, out []Cell) { b.Compare(in[0], in[1], out[0]) },
+		func(x, y uint32) []uint32 { return []uint32{b2u(x > y) - b2u(x < y)} }},
+	{"Mul", 1, 4, func(b *Builder, in, out []Cell) { b.Mul(in[0], in[1], out[0]) },
+		func(x, y uint32) []uint32 { return []uint32{x * y} }},
+	{"DivMod", 2, 45, func(b *Builder, in, out []Cell) { b.DivMod(in[0], in[1], out[0], out[1]) }, divmod},
+	{"Mul (결과 셀이 입력 셀)", 1, 4, func(b *Builder, in, out []Cell) {
+		b.Set(out[0], 0)
+		b.Copy(in[0], out[0])
+		b.Mul(out[0], in[1], out[0])
+	}, func(x, y uint32) []uint32 { return []uint32{x * y} }},
+	{"DivMod (결과 셀이 입력 셀)", 2, 45, func(b *Builder, in, out []Cell) {
+		b.Set(out[0], 0)
+		b.Set(out[1], 0)
+		b.Copy(in[0], out[0])
+		b.Copy(in[1], out[1])
+		b.DivMod(out[0], out[1], out[0], out[1])
+	}, divmod},
+}
+
+// TestRoutines는 각 루틴을 routineTestValues의 모든 값의 쌍에 대해 검사합니다.
+// 루틴마다 입력에서 두 값과 기대하는 결과를 읽어 비교하는 프로그램을 하나 만들고, 경우마다 . 또는 X를 출력합니다.
+func TestRoutines(t *testing.T) {
+	for _, test := range routineTestEntries {
+		b := New()
+		in, out, more := b.AllocN(2), b.AllocN(test.outs), b.Alloc()
+		b.Read(more)
+		b.Loop(more, func() {
+			for _, c := range in {
+				b.Set(c, 0)
+				readU32(b, c)
+			}
+			test.emit(b, in, out)
+			for _, c := range out {
+				e := b.Alloc()
+				readU32(b, e)
+				expect(b, c, e)
+			}
+			b.Read(more)
+		})
+		fd := b.FileData()
+
+		var input []byte
+		var cases [][2]uint32
+		for _, x := range routineTestValues {
+			for _, y := range routineTestValues {
+				input = appendU32(appendU32(append(input, 1), x), y)
+				for _, v := range test.want(x, y) {
+					input = appendU32(input, v)
+				}
+				cases = append(cases, [2]uint32{x, y})
+			}
+		}
+		got, err := vmtest.Run(fd, mf.BackendInterp, string(input))
+		if err != nil || len(got) != len(cases)*test.outs {
+			t.Errorf("%s failed: got %d results (%v), expected %d", test.name, len(got), err, len(cases)*test.outs)
+			continue
+		}
+		failed := 0
+		for i, c := range cases {
+			if r := got[i*test.outs : (i+1)*test.outs]; r != strings.Repeat(".", test.outs) && failed < 5 {
+				t.Errorf("%s failed: x=%d, y=%d, got %s, expected %v", test.name, c[0], c[1], r, test.want(c[0], c[1]))
+				failed++
+			}
+		}
+	}
+}
+
+func TestRoutineScratch(t *testing.T) {
+	for _, test := range routineTestEntries {
+		b := New()
+		in, out := b.AllocN(2), b.AllocN(test.outs)
+		test.emit(b, in, out)
+		if s := b.MemSize() - len(in) - len(out); s != test.scratch {
+			t.Errorf("%s failed: got %d scratch cells, expected %d", test.name, s, test.scratch)
+		}
+	}
+	for _, test := range []struct {
+		name    string
+		scratch int
+		emit    func(b *Builder, c Cell)
+	}{
+		{"PrintNum", 47, func(b *Builder, c Cell) { b.PrintNum(c) }},
+		{"ReadNum", 7, func(b *Builder, c Cell) { b.ReadNum(c) }},
+	} {
+		b := New()
+		test.emit(b, b.Alloc())
+		if s := b.MemSize() - 1; s != test.scratch {
+			t.Errorf("%s failed: got %d scratch cells, expected %d", test.name, s, test.scratch)
+		}
+	}
+}
+
+func TestPrintNum(t *testing.T) {
+	b := New()
+	c, more := b.Alloc(), b.Alloc()
+	b.Read(more)
+	b.Loop(more, func() {
+		b.Set(c, 0)
+		readU32(b, c)
+		b.PrintNum(c)
+		b.PrintString(" ")
+		b.Read(more)
+	})
+	fd := b.FileData()
+
+	var input []byte
+	var expected string
+	for _, v := range append(routineTestValues, 9, 10, 19, 99999, 100000, 999999999, 1000000000, 1999999999, 4000000000) {
+		input = appendU32(append(input, 1), v)
+		expected += strconv.FormatUint(uint64(v), 10) + " "
+	}
+	if got, err := vmtest.Run(fd, mf.BackendInterp, string(input)); err != nil || got != expected {
+		t.Errorf("got %q (%v), expected %q", got, err, expected)
+	}
+}
+
+var readNumTestEntries = []struct {
+	in   string
+	want []uint32
+}{
+	{"0", []uint32{0}},
+	{"42\n", []uint32{42}},
+	{"4294967295", []uint32{4294967295}},
+	{"4294967296 4294967297", []uint32{0, 1}},
+	{"99999999999", []uint32{1215752191}},
+	{"  x-4096!", []uint32{4096}},
+	{"12 30\n", []uint32{12, 30}},
+	{"007/1a2", []uint32{7, 1, 2}},
+	{"5", []uint32{5, 0}},
+	{"", []uint32{0}},
+}
+
+func TestReadNum(t *testing.T) {
+	for n, test := range readNumTestEntries {
+		b := New()
+		c := b.Alloc()
+		for _, v := range test.want {
+			b.ReadNum(c)
+			e := b.Alloc()
+			b.Set(e, v)
+			expect(b, c, e)
+		}
+		expected := strings.Repeat(".", len(test.want))
+		if got, err := vmtest.Run(b.FileData(), mf.BackendInterp, test.in); err != nil || got != expected {
+			t.Errorf("Test #%d failed: got %q (%v), expected %q", n+1, got, err, expected)
+		}
+	}
+}
+
+var strCompareTestEntries = []struct {
+	x, y []uint32
+	want int32
+}{
+	{[]uint32{'a', 'b', 'c'}, []uint32{'a', 'b', 'c'}, 0},
+	{[]uint32{'a', 'b', 'c'}, []uint32{'a', 'b', 'd'}, -1},
+	{[]uint32{'a', 'b', 'd'}, []uint32{'a', 'b', 'c'}, 1},
+	{[]uint32{'a', 'b'}, []uint32{'a', 'b', 'c'}, -1},
+	{[]uint32{'a', 'b', 'c'}, []uint32{'a', 'b'}, 1},
+	{[]uint32{'b'}, []uint32{'a', 'z', 'z'}, 1},
+	{nil, nil, 0},
+	{nil, []uint32{'a'}, -1},
+	{[]uint32{0}, nil, 0},
+	{[]uint32{'a', 'b', 0, 'z'}, []uint32{'a', 'b'}, 0},
+	{[]uint32{'a', 0, 'c'}, []uint32{'a', 0, 'd'}, 0},
+	{[]uint32{'a', 0, 'c'}, []uint32{'a', 'b', 0}, -1},
+	{[]uint32{4294967295}, []uint32{1}, 1},
+	{[]uint32{1 << 31, 1}, []uint32{1 << 31, 2}, -1},
+}
+
+func TestStrCompare(t *testing.T) {
+	for n, test := range strCompareTestEntries {
+		b := New()
+		x, y, r := b.AllocN(len(test.x)), b.AllocN(len(test.y)), b.Alloc()
+		for i, v := range test.x {
+			b.Set(x[i], v)
+		}
+		for i, v := range test.y {
+			b.Set(y[i], v)
+		}
+		b.StrCompare(x, y, r)
+		// 비교하는 문자열이 짧으면 임시 셀을 13개보다 적게 사용할 수 있습니다.
+		if s := b.MemSize() - len(x) - len(y) - 1; s > 13 {
+			t.Errorf("Test #%d failed: got %d scratch cells", n+1, s)
+		}
+		e := b.Alloc()
+		b.Set(e, uint32(test.want))
+		expect(b, r, e)
+		if got, err := vmtest.Run(b.FileData(), mf.BackendInterp, ""); err != nil || got != "." {
+			t.Errorf("Test #%d failed: got %q (%v), expected %d", n+1, got, err, test.want)
+		}
+	}
+}
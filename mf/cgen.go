@@ -0,0 +1,209 @@
+package mf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+/*
+ToCCode 함수는 MinFuck 프로그램을 단독으로 컴파일할 수 있는 C99 코드로 변환합니다.
+
+ 메모리는 VMFile과 같은 크기로 할당하고 같은 값으로 초기화하므로,
+ 기본 Policy에서는 MinFuckVM과 같은 결과를 출력합니다.
+ 압축된 명령어와 루프 관용구는 한 번의 덧셈, 대입, 곱셈으로 변환됩니다.
+
+ 메모리 범위는 at 함수로 포인터를 옮기거나 곱셈의 대상 셀을 계산할 때 검사합니다.
+ TapeFixed에서 범위를 벗어나면 표준 오류에 메시지를 출력하고 종료 코드 2로 종료하며,
+ TapeGrow에서는 왼쪽 끝을 벗어날 때만 그렇게 종료합니다. 프로그램 끝의 이동은 검사하지 않고,
+ 곱셈은 현재 셀이 0일 때 대상 셀을 계산하지 않습니다.
+*/
+func ToCCode(fd FileData, p Policy) (string, error) {
+	p, err := p.resolve(&fd)
+	if err != nil {
+		return "", err
+	}
+	ops, err := buildIR(&fd)
+	if err != nil {
+		return "", err
+	}
+
+	// 마지막 이동 뒤에는 메모리에 접근하지 않으므로 검사하지 않도록 생략합니다.
+	if len(ops) > 0 && ops[len(ops)-1].kind == irMove {
+		ops = ops[:len(ops)-1]
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, cPrologue, p.CellWidth, p.EOF, p.Tape, p.CellWidth, tapeSize(fd.memsize))
+	uses := map[irKind]bool{}
+	for _, op := range ops {
+		uses[op.kind] = uses[op.kind] || op.kind != irMove || op.n != 0
+	}
+	if uses[irMove] || uses[irMul] || uses[irScan] {
+		buf.WriteString(cTapeFuncs[p.Tape])
+	}
+	if uses[irIn] {
+		buf.WriteString(cInputFuncs[p.EOF])
+	}
+	fmt.Fprintf(buf, cMainStart, fd.memsize)
+
+	mask := uint64(1)<<uint(p.CellWidth) - 1
+	depth := 1
+	for _, op := range ops {
+		if op.kind == irClose {
+			depth--
+		}
+		var line string
+		switch op.kind {
+		case irAdd:
+			line = cAdd("m[p]", "", uint64(op.n)&mask, mask)
+		case irMove:
+			if op.n != 0 {
+				line = fmt.Sprintf("p = at(%d);", op.n)
+			}
+		case irOpen:
+			line = "while (m[p]) {"
+		case irClose:
+			line = "}"
+		case irOut:
+			line = "putchar((unsigned char)m[p]);"
+		case irIn:
+			line = "input();"
+		case irSet:
+			line = fmt.Sprintf("m[p] = %d;", uint64(op.n)&mask)
+		case irMul:
+			if line = cAdd(fmt.Sprintf("m[at(%d)]", op.off), "m[p]", uint64(op.n)&mask, mask); line != "" {
+				line = "if (m[p]) " + line
+			}
+		case irScan:
+			line = fmt.Sprintf("while (m[p]) p = at(%d);", op.n)
+		}
+		if line != "" {
+			buf.WriteString(strings.Repeat("    ", depth) + line + "\n")
+		}
+		if op.kind == irOpen {
+			depth++
+		}
+	}
+	buf.WriteString(cMainEnd)
+	return buf.String(), nil
+}
+
+// cAdd 함수는 dst에 factor * v를 더하는 C 문장을 만듭니다. factor가 비어 있으면 v만 더합니다.
+// v가 셀 크기의 절반을 넘으면 뺄셈으로 나타냅니다.
+func cAdd(dst, factor string, v, mask uint64) string {
+	if v == 0 {
+		return ""
+	}
+	op := "+="
+	if v > mask>>1 {
+		op, v = "-=", (mask-v+1)&mask
+	}
+	switch {
+	case factor == "" && v == 1:
+		return dst + op[:1] + op[:1] + ";"
+	case factor == "":
+		return fmt.Sprintf("%s %s %d;", dst, op, v)
+	case v == 1:
+		return fmt.Sprintf("%s %s %s;", dst, op, factor)
+	}
+	return fmt.Sprintf("%s %s %s * %d;", dst, op, factor, v)
+}
+
+const cPrologue = `/*
+ * minfuck으로 생성한 C99 코드입니다.
+ * 셀 크기: %d비트, EOF: %s, 테이프: %s
+ */
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+typedef uint%d_t cell;
+
+static cell *m;
+static size_t size = %d, p;
+
+static void fail(const char *msg)
+{
+    fflush(stdout);
+    fprintf(stderr, "\n%%s\n", msg);
+    exit(2);
+}
+
+`
+
+// cTapeFuncs는 테이프 처리 방식별로, 현재 위치에서 d만큼 떨어진 셀의 위치를 계산하는 at 함수입니다.
+var cTapeFuncs = map[string]string{
+	TapeFixed: `static size_t at(long long d)
+{
+    long long t = (long long)p + d;
+    if (t < 0 || t >= (long long)size)
+        fail("메모리 범위를 벗어났습니다");
+    return (size_t)t;
+}
+
+`,
+	TapeWrap: `static size_t at(long long d)
+{
+    long long t = ((long long)p + d) % (long long)size;
+    return (size_t)(t < 0 ? t + (long long)size : t);
+}
+
+`,
+	TapeGrow: `static size_t at(long long d)
+{
+    long long t = (long long)p + d;
+    if (t < 0)
+        fail("메모리 범위를 벗어났습니다");
+    if (t >= (long long)size) {
+        size_t n = size * 2 > (size_t)t ? size * 2 : (size_t)t + 1;
+        if (!(m = realloc(m, n * sizeof(cell))))
+            fail("메모리를 할당할 수 없습니다");
+        memset(m + size, 0, (n - size) * sizeof(cell));
+        size = n;
+    }
+    return (size_t)t;
+}
+
+`,
+}
+
+// cInputFuncs는 EOF 처리 방식별로, 현재 셀에 입력을 읽어들이는 input 함수입니다.
+var cInputFuncs = map[string]string{
+	EOFZero: `static void input(void)
+{
+    int c = getchar();
+    m[p] = c == EOF ? 0 : (cell)c;
+}
+
+`,
+	EOFMinusOne: `static void input(void)
+{
+    int c = getchar();
+    m[p] = c == EOF ? (cell)-1 : (cell)c;
+}
+
+`,
+	EOFUnchanged: `static void input(void)
+{
+    int c = getchar();
+    if (c != EOF)
+        m[p] = (cell)c;
+}
+
+`,
+}
+
+const cMainStart = `int main(void)
+{
+    if (!(m = calloc(size, sizeof(cell))))
+        fail("메모리를 할당할 수 없습니다");
+    for (size_t i = 0; i < %du; i++)
+        m[8 + 2 * i] = (cell)(i + 1);
+
+`
+
+const cMainEnd = `    return 0;
+}
+`
@@ -0,0 +1,139 @@
+package mf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var cCodeTestEntries = []struct {
+	bf     string
+	expect []string
+}{
+	{bf: "+++++.", expect: []string{"m[p] += 5;", "putchar((unsigned char)m[p]);"}},
+	{bf: "-<+", expect: []string{"m[p]--;", "p = at(-8);"}},
+	{bf: "[-]", expect: []string{"m[p] = 0;"}},
+	{bf: "[->++<]", expect: []string{"m[at(8)] += m[p] * 2;", "m[p] = 0;"}},
+	{bf: "[-<->]", expect: []string{"m[at(-8)] -= m[p];"}},
+	{bf: "[<]", expect: []string{"while (m[p]) p = at(-8);"}},
+	{bf: ",[.,]", expect: []string{"input();", "while (m[p]) {\n        putchar"}},
+}
+
+func TestToCCode(t *testing.T) {
+	for n, test := range cCodeTestEntries {
+		for _, opt := range []bool{false, true} {
+			fd, _ := FromBfCodeOpts(test.bf, BfOptions{Mem: 16, Optimize: opt})
+			c, err := ToCCode(fd, Policy{})
+			if err != nil {
+				t.Fatalf("Test #%d failed: %v", n+1, err)
+			}
+			for _, e := range test.expect {
+				if !strings.Contains(c, e) {
+					t.Errorf("Test #%d failed (optimize=%v): %q not found in\n%s", n+1, opt, e, c)
+				}
+			}
+		}
+	}
+}
+
+func TestToCCodeErrors(t *testing.T) {
+	for _, code := range [][]byte{
+		{0x40},                            // [
+		{0x50},                            // ]
+		{0xc0, 0, 0, 0, 0, 0, 0, 0, 0x05}, // 잘못된 점프 대상
+	} {
+		if _, err := ToCCode(NewFileData(16, code), Policy{}); err == nil {
+			t.Errorf("%x: expected error", code)
+		}
+	}
+	fd := NewFileData(16, nil)
+	fd.SetMetadata(Metadata{{MetaCellWidth, "12"}})
+	if _, err := ToCCode(fd, Policy{}); err == nil {
+		t.Errorf("invalid cell width should be rejected")
+	}
+}
+
+// runC 함수는 C 코드를 컴파일하여 실행하고 출력과 종료 코드를 반환합니다.
+func runC(t *testing.T, dir, c, in string) (string, int) {
+	src, bin := filepath.Join(dir, "prog.c"), filepath.Join(dir, "prog")
+	if err := ioutil.WriteFile(src, []byte(c), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("cc", "-std=c99", "-O1", "-Wall", "-Werror", "-o", bin, src).CombinedOutput(); err != nil {
+		t.Fatalf("cc failed: %v\n%s\n%s", err, out, c)
+	}
+	cmd := exec.Command(bin)
+	cmd.Stdin = strings.NewReader(in)
+	out, err := cmd.Output()
+	if ee, ok := err.(*exec.ExitError); ok {
+		return string(out), ee.ExitCode()
+	}
+	return string(out), 0
+}
+
+func TestToCCodeCompile(t *testing.T) {
+	if _, err := exec.LookPath("cc"); err != nil || testing.Short() {
+		t.Skip("C 컴파일러가 없습니다")
+	}
+	dir, err := ioutil.TempDir("", "mfcgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for n, test := range rtTestEntries {
+		for _, opts := range rtOptions[:3] {
+			fd, _ := FromBfCodeOpts(test.bf, opts)
+			expect, _ := runBackend(NewVM(fd), BackendInterp, test.in)
+
+			c, err := ToCCode(fd, Policy{})
+			if err != nil {
+				t.Fatalf("Test #%d failed: %v", n+1, err)
+			}
+			if out, code := runC(t, dir, c, test.in); out != expect || code != 0 {
+				t.Errorf("Test #%d failed (%+v): got %q (exit %d), VM %q", n+1, opts, out, code, expect)
+			}
+		}
+	}
+
+	policies := []struct {
+		bf, in string
+		p      Policy
+		out    string
+		code   int
+	}{
+		{bf: ",.", p: Policy{EOF: EOFMinusOne}, out: "\xff"},
+		{bf: "+++,.", p: Policy{EOF: EOFUnchanged}, out: "\x03"},
+		{bf: "+++,.", in: "x", p: Policy{EOF: EOFZero}, out: "x"},
+		{bf: "<<" + strings.Repeat("+", 65) + ".", p: Policy{Tape: TapeFixed}, code: 2},
+		{bf: "<<" + strings.Repeat("+", 65) + ".", p: Policy{Tape: TapeWrap}, out: "A"},
+		{bf: "+.<<", p: Policy{Tape: TapeFixed}, out: "\x01"},
+		{bf: "[->>>>>>+<<<<<<]+.", p: Policy{Tape: TapeFixed}, out: "\x01"},
+		{bf: strings.Repeat(">", 100) + strings.Repeat("+", 65) + ".", p: Policy{Tape: TapeGrow}, out: "A"},
+		{bf: strings.Repeat("+", 300) + ".[-]", p: Policy{CellWidth: 8}, out: "\x2c"},
+		{bf: "-[->+<]>.", p: Policy{CellWidth: 16}, out: "\xff"},
+	}
+	for n, test := range policies {
+		fd, _ := FromBfCodeOpts(test.bf, BfOptions{Mem: 16, Optimize: true})
+		c, err := ToCCode(fd, test.p)
+		if err != nil {
+			t.Fatalf("Policy #%d failed: %v", n+1, err)
+		}
+		if out, code := runC(t, dir, c, test.in); out != test.out || code != test.code {
+			t.Errorf("Policy #%d failed: got %q (exit %d), expected %q (exit %d)", n+1, out, code, test.out, test.code)
+		}
+	}
+
+	fd, _ := FromBfCodeOpts(",.", BfOptions{Mem: 16, Meta: Metadata{{MetaEOF, EOFMinusOne}}})
+	c, _ := ToCCode(fd, Policy{})
+	if out, _ := runC(t, dir, c, ""); out != "\xff" {
+		t.Errorf("EOF policy from metadata is not honored: got %q", out)
+	}
+	if !bytes.Contains([]byte(c), []byte("EOF: -1")) {
+		t.Errorf("policy comment missing")
+	}
+}
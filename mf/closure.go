@@ -0,0 +1,336 @@
+package mf
+
+import (
+	"fmt"
+	"io"
+)
+
+// Backend 타입은 VM이 MinFuck 코드를 실행하는 방식입니다.
+type Backend int
+
+const (
+	// BackendInterp는 니블코드를 하나씩 해석하여 실행합니다. (기본값)
+	BackendInterp Backend = iota
+	// BackendClosure는 코드를 Go 클로저 트리로 컴파일한 뒤 실행합니다.
+	BackendClosure
+)
+
+// String 메서드는 백엔드의 이름을 반환합니다.
+func (b Backend) String() string {
+	switch b {
+	case BackendInterp:
+		return "interp"
+	case BackendClosure:
+		return "closure"
+	}
+	return fmt.Sprintf("Backend(%d)", int(b))
+}
+
+// ParseBackend 함수는 이름으로 백엔드를 찾습니다.
+func ParseBackend(name string) (Backend, error) {
+	for _, b := range []Backend{BackendInterp, BackendClosure} {
+		if b.String() == name {
+			return b, nil
+		}
+	}
+	return 0, fmt.Errorf("알 수 없는 백엔드: %s", name)
+}
+
+const (
+	closureChain = 1024    // 한 번에 이어서 호출하는 클로저의 최대 수
+	closurePoll  = 1 << 12 // stop 채널을 확인하는 루프 반복 간격
+)
+
+// closure 타입은 클로저로 컴파일된 명령어입니다. 실행을 마치면 다음 명령어를 직접 호출합니다.
+type closure func(vm *MinFuckVM) error
+
+// done 함수는 명령어 사슬의 끝입니다.
+func done(vm *MinFuckVM) error {
+	return nil
+}
+
+// cnode 구조체는 클로저로 컴파일하기 전의 명령어 트리입니다.
+// 연속된 +- 와 >< 는 Op가 0 또는 2인 하나의 노드로 합쳐지며, n은 그 결과입니다.
+type cnode struct {
+	in   Instr
+	n    uint32
+	body []cnode // 루프 본문
+}
+
+// closureCompiler 구조체는 명령어 트리를 클로저로 변환합니다.
+type closureCompiler struct {
+	stop  <-chan struct{}
+	poll  int                // stop 채널을 확인하기까지 남은 루프 반복 횟수
+	buf   []byte             // 입출력 버퍼
+	procs map[uint32]closure // 프로시저 번호별 본문
+	depth int                // 호출 스택의 깊이
+}
+
+/*
+runClosure 메서드는 코드를 클로저로 컴파일하여 실행합니다.
+
+ 실행 결과는 인터프리터와 같지만, 다음이 다릅니다:
+ 짝이 맞지 않는 대괄호나 구조화되지 않은 점프, 프로시저 정의가 있으면 실행하기 전에 오류를 보고합니다.
+ fork는 지원하지 않으며, 마찬가지로 실행하기 전에 오류를 보고합니다.
+ 메모리 범위 오류는 범위를 벗어난 셀에 접근하는 명령어 대신 포인터를 옮긴 명령어에서 보고합니다.
+ stop 채널은 루프를 반복할 때 가끔씩만 확인합니다.
+*/
+func (vm *MinFuckVM) runClosure(stop <-chan struct{}, report chan<- error) {
+	tree, err := vm.parseTree()
+	if err != nil {
+		report <- err
+		return
+	}
+	c := &closureCompiler{stop: stop, poll: closurePoll, buf: make([]byte, 1)}
+	if err := c.block(tree, true)(vm); err != errEnd {
+		report <- err
+		return
+	}
+	report <- nil
+}
+
+// parseTree 메서드는 코드 전체를 디코딩하여 명령어 트리를 만듭니다.
+func (vm *MinFuckVM) parseTree() ([]cnode, error) {
+	var (
+		cur   []cnode
+		outer [][]cnode
+		open  []Instr
+	)
+	for pc := uint64(0); ; {
+		in, err := vm.Encoding.decode(vm.fetch, pc)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%v: 니블 오프셋 %d", err, pc)
+		}
+		pc += in.Len
+
+		switch {
+		case in.Ext == ExtProc:
+			outer, open, cur = append(outer, cur), append(open, in), nil
+		case in.Ext == ExtRet:
+			if len(open) == 0 || open[len(open)-1].Ext != ExtProc {
+				return nil, fmt.Errorf("짝이 맞지 않는 ret: 니블 오프셋 %d", in.PC)
+			}
+			o := open[len(open)-1]
+			if o.PC+o.Len+uint64(o.Args[0]) != pc {
+				return nil, fmt.Errorf("구조화되지 않은 프로시저: 니블 오프셋 %d", o.PC)
+			}
+			proc := cnode{in: o, body: cur}
+			cur = append(outer[len(outer)-1], proc)
+			outer, open = outer[:len(outer)-1], open[:len(open)-1]
+		case in.Ext == ExtFork:
+			return nil, fmt.Errorf("클로저 백엔드는 fork를 지원하지 않습니다: 니블 오프셋 %d", in.PC)
+		case in.Ext != 0:
+			cur = append(cur, cnode{in: in})
+		case in.Count == 0: // NOP, EncodingV1의 압축된 . ,
+		case in.Op <= 3:
+			n := in.Count
+			if in.Op&1 == 1 {
+				n = -n
+			}
+			in.Op &^= 1
+			if l := len(cur) - 1; l >= 0 && cur[l].in.Ext == 0 && cur[l].in.Op == in.Op {
+				cur[l].n += n
+			} else {
+				cur = append(cur, cnode{in: in, n: n})
+			}
+		case in.Op == 4:
+			outer, open, cur = append(outer, cur), append(open, in), nil
+		case in.Op == 5:
+			if len(open) == 0 || open[len(open)-1].Ext != 0 {
+				return nil, fmt.Errorf("짝이 맞지 않는 ]: 니블 오프셋 %d", in.PC)
+			}
+			o := open[len(open)-1]
+			if (o.Jump && o.Target != pc) || (in.Jump && in.Target != o.PC+o.Len) {
+				return nil, fmt.Errorf("구조화되지 않은 점프: 니블 오프셋 %d", in.PC)
+			}
+			loop := cnode{in: o, body: cur}
+			cur = append(outer[len(outer)-1], loop)
+			outer, open = outer[:len(outer)-1], open[:len(open)-1]
+		default:
+			cur = append(cur, cnode{in: in})
+		}
+	}
+	if l := len(open) - 1; l >= 0 && open[l].Ext != 0 {
+		return nil, fmt.Errorf("짝이 맞지 않는 proc: 니블 오프셋 %d", open[l].PC)
+	} else if l >= 0 {
+		return nil, fmt.Errorf("짝이 맞지 않는 [: 니블 오프셋 %d", open[l].PC)
+	}
+	return cur, nil
+}
+
+// block 메서드는 명령어 목록을 하나의 클로저로 만듭니다.
+// 호출 깊이가 너무 깊어지지 않도록 closureChain개마다 사슬을 끊고 차례로 호출합니다.
+// top이면 프로그램의 마지막 명령어를 포함하는 최상위 목록입니다.
+func (c *closureCompiler) block(nodes []cnode, top bool) closure {
+	var chains []closure
+	for len(nodes) > 0 {
+		k := len(nodes)
+		if k > closureChain {
+			k = closureChain
+		}
+		next := closure(done)
+		for i := k - 1; i >= 0; i-- {
+			next = c.node(nodes[i], next, top && k == len(nodes) && i == k-1)
+		}
+		chains, nodes = append(chains, next), nodes[k:]
+	}
+	switch len(chains) {
+	case 0:
+		return done
+	case 1:
+		return chains[0]
+	}
+	return func(vm *MinFuckVM) error {
+		for _, ch := range chains {
+			if err := ch(vm); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// node 메서드는 명령어 하나를 클로저로 만듭니다. last이면 프로그램의 마지막 명령어입니다.
+func (c *closureCompiler) node(nd cnode, next closure, last bool) closure {
+	pc, n := nd.in.PC, nd.n
+	switch nd.in.Ext {
+	case ExtSet:
+		v := uint32(nd.in.Args[0])
+		return func(vm *MinFuckVM) error {
+			vm.Mem[vm.mp] = vm.Mem[vm.mp] - vm.loopCount() + v
+			return next(vm)
+		}
+	case ExtMul:
+		off, k := uint32(nd.in.Args[0]), uint32(nd.in.Args[1])
+		return func(vm *MinFuckVM) error {
+			if n := vm.loopCount(); n != 0 {
+				t := vm.mp + off
+				if t >= uint32(len(vm.Mem)) {
+					return rangeError(vm, pc, t)
+				}
+				vm.Mem[t] += k * n
+			}
+			return next(vm)
+		}
+	case ExtScan:
+		step := uint32(nd.in.Args[0])
+		return func(vm *MinFuckVM) error {
+			for !vm.zero() {
+				if vm.mp += step; vm.mp >= uint32(len(vm.Mem)) {
+					return rangeError(vm, pc, vm.mp)
+				}
+			}
+			return next(vm)
+		}
+	case ExtProc:
+		body := c.block(nd.body, false)
+		return func(vm *MinFuckVM) error {
+			if c.procs == nil {
+				c.procs = make(map[uint32]closure)
+			}
+			c.procs[vm.loopCount()] = body
+			return next(vm)
+		}
+	case ExtEBF:
+		x := EBFOp(nd.in.Args[0])
+		if x == EBFEnd {
+			return func(vm *MinFuckVM) error {
+				vm.pc = pc + nd.in.Len
+				return errEnd
+			}
+		}
+		return func(vm *MinFuckVM) error {
+			if err := vm.ebf(pc, x); err != nil {
+				return err
+			}
+			return next(vm)
+		}
+	case ExtDebug:
+		return func(vm *MinFuckVM) error {
+			vm.pc = pc + nd.in.Len
+			vm.dump()
+			return next(vm)
+		}
+	case ExtCall:
+		return func(vm *MinFuckVM) error {
+			id := vm.loopCount()
+			body, ok := c.procs[id]
+			if !ok {
+				return vm.callError(ErrUndefinedProc, pc, id, c.depth)
+			}
+			if c.depth >= vm.callDepth() {
+				return vm.callError(ErrCallDepth, pc, id, c.depth)
+			}
+			c.depth++
+			err := body(vm)
+			c.depth--
+			if err != nil {
+				return err
+			}
+			return next(vm)
+		}
+	}
+
+	switch nd.in.Op {
+	case 0: // +-
+		return func(vm *MinFuckVM) error {
+			vm.Mem[vm.mp] += n
+			return next(vm)
+		}
+	case 2: // ><
+		if last {
+			// 마지막 이동 뒤에는 메모리에 접근하지 않으므로 인터프리터와 같이 검사하지 않습니다.
+			return func(vm *MinFuckVM) error {
+				vm.mp += n
+				return next(vm)
+			}
+		}
+		return func(vm *MinFuckVM) error {
+			if vm.mp += n; vm.mp >= uint32(len(vm.Mem)) {
+				return rangeError(vm, pc, vm.mp)
+			}
+			return next(vm)
+		}
+	case 4: // [ ]
+		if len(nd.body) == 1 && nd.body[0].in.Op == 0 && nd.body[0].in.Ext == 0 && nd.body[0].n == ^uint32(0) {
+			// [-]
+			return func(vm *MinFuckVM) error {
+				vm.Mem[vm.mp] -= vm.loopCount()
+				return next(vm)
+			}
+		}
+		body := c.block(nd.body, false)
+		return func(vm *MinFuckVM) error {
+			for !vm.zero() {
+				if err := body(vm); err != nil {
+					return err
+				}
+				if c.poll--; c.poll == 0 {
+					c.poll = closurePoll
+					select {
+					case <-c.stop:
+						vm.pc = pc
+						return fmt.Errorf("Interrupt")
+					default:
+					}
+				}
+			}
+			return next(vm)
+		}
+	case 6: // .
+		return func(vm *MinFuckVM) error {
+			c.buf[0] = byte(vm.Mem[vm.mp])
+			vm.Out.Write(c.buf)
+			return next(vm)
+		}
+	default: // ,
+		return func(vm *MinFuckVM) error {
+			c.buf[0] = 0
+			vm.In.Read(c.buf)
+			vm.Mem[vm.mp] = uint32(c.buf[0])
+			return next(vm)
+		}
+	}
+}
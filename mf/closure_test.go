@@ -0,0 +1,144 @@
+package mf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runBackend 함수는 주어진 백엔드로 VM을 실행하여 출력과 오류를 반환합니다.
+func runBackend(vm *MinFuckVM, b Backend, in string) (string, error) {
+	io := &IOStream{Stdin: in}
+	vm.In, vm.Out, vm.Backend = io, io, b
+	result := make(chan error, 1)
+	vm.Run(nil, result)
+	err := <-result
+	return io.Stdout, err
+}
+
+func TestClosureBackend(t *testing.T) {
+	for n, test := range rtTestEntries {
+		for _, opts := range rtOptions {
+			fd, _ := FromBfCodeOpts(test.bf, opts)
+			expect, err := runBackend(NewVM(fd), BackendInterp, test.in)
+			if err != nil {
+				t.Fatalf("Test #%d failed: %v", n+1, err)
+			}
+			vm := NewVM(fd)
+			out, err := runBackend(vm, BackendClosure, test.in)
+			if err != nil || out != expect {
+				t.Errorf("Test #%d failed (%+v): got %q (%v), expected %q", n+1, opts, out, err, expect)
+			}
+			ivm := NewVM(fd)
+			runBackend(ivm, BackendInterp, test.in)
+			if ivm.mp != vm.mp || !reflect.DeepEqual(ivm.Mem, vm.Mem) {
+				t.Errorf("Test #%d failed (%+v): memory mismatch", n+1, opts)
+			}
+		}
+
+		// 구조체 리터럴로 만든 VM은 [ ]에서 셀을 바이트로 비교합니다.
+		nw := new(NibbleWriter)
+		for _, b := range test.bf {
+			if op := FromBf(string(b)); op <= 7 {
+				nw.Put(op)
+			}
+		}
+		vm := &MinFuckVM{Code: nw.Nibbles, Mem: make([]uint32, 1<<16)}
+		if out, err := runBackend(vm, BackendClosure, test.in); err != nil || out != runBfr(test.bf, test.in) {
+			t.Errorf("Test #%d failed (byte cells): got %q (%v)", n+1, out, err)
+		}
+	}
+}
+
+var closureErrorEntries = []struct {
+	bf  string
+	out string
+	err string
+}{
+	{bf: "+.<<+.", out: "\x01", err: "메모리 범위를 벗어났습니다"},
+	{bf: "+>+>+>+[>]", err: "메모리 범위를 벗어났습니다"},
+	{bf: "+[->" + strings.Repeat(">", 5) + "+<" + strings.Repeat("<", 5) + "]", err: "메모리 범위를 벗어났습니다"},
+	{bf: "+]", err: "짝이 맞지 않는 ]"},
+	{bf: "+[", err: "짝이 맞지 않는 ["},
+	{bf: "+.<<", out: "\x01"},
+}
+
+func TestClosureBackendErrors(t *testing.T) {
+	for n, test := range closureErrorEntries {
+		for _, opt := range []bool{false, true} {
+			fd, _ := FromBfCodeOpts(test.bf, BfOptions{Mem: 4, Optimize: opt})
+			vm := NewVM(fd)
+			out, err := runBackend(vm, BackendClosure, "")
+			if out != test.out || (err == nil) != (test.err == "") || (err != nil && !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("Test #%d failed (optimize=%v): got %q (%v), expected %q (%s)", n+1, opt, out, err, test.out, test.err)
+				continue
+			}
+			// 범위 오류의 PC는 포인터를 옮긴 명령어를 가리킵니다.
+			if in, _ := vm.Encoding.Decode(vm.Code, vm.PC()); strings.HasPrefix(test.err, "메모리") &&
+				in.Op != 2 && in.Op != 3 && in.Ext != ExtMul && in.Ext != ExtScan {
+				t.Errorf("Test #%d failed (optimize=%v): PC %d points to %v", n+1, opt, vm.PC(), in)
+			}
+		}
+	}
+
+	vm := NewVM(NewFileData(4, []byte{0xc0, 0, 0, 0, 0, 0, 0, 0, 0x05}))
+	if _, err := runBackend(vm, BackendClosure, ""); err == nil || !strings.Contains(err.Error(), "구조화되지 않은 점프") {
+		t.Errorf("unmatched compressed jump should be rejected: %v", err)
+	}
+}
+
+func TestClosureBackendStop(t *testing.T) {
+	fd, _ := FromBfCodeOpts("+[]", BfOptions{Mem: 4})
+	vm := NewVM(fd)
+	vm.Backend = BackendClosure
+	stop, result := make(chan struct{}, 1), make(chan error, 1)
+	time.AfterFunc(10*time.Millisecond, func() { stop <- struct{}{} })
+	go vm.Run(stop, result)
+	select {
+	case err := <-result:
+		if err == nil || err.Error() != "Interrupt" {
+			t.Errorf("expected interrupt, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("VM did not stop")
+	}
+}
+
+func TestParseBackend(t *testing.T) {
+	for _, b := range []Backend{BackendInterp, BackendClosure} {
+		if p, err := ParseBackend(b.String()); err != nil || p != b {
+			t.Errorf("ParseBackend(%q) = %v, %v", b.String(), p, err)
+		}
+	}
+	if _, err := ParseBackend("jit"); err == nil {
+		t.Errorf("unknown backend should be rejected")
+	}
+}
+
+// benchProgram은 벤치마크에 사용하는 중첩 루프가 많은 Brainfuck 프로그램입니다.
+var benchProgram = strings.Repeat("++++++++[>++++++++[>++++++++[>+>++<<-]<-]<-]>>>[-]>[-]<<<<", 4) + hwBfCode
+
+func benchBackend(b *testing.B, backend Backend, opts BfOptions) {
+	fd, _ := FromBfCodeOpts(benchProgram, opts)
+	dio := new(dummyIO)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vm := NewVM(fd)
+		vm.In, vm.Out, vm.Backend = dio, dio, backend
+		result := make(chan error, 1)
+		vm.Run(nil, result)
+		if err := <-result; err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInterp(b *testing.B)  { benchBackend(b, BackendInterp, BfOptions{Mem: 64}) }
+func BenchmarkClosure(b *testing.B) { benchBackend(b, BackendClosure, BfOptions{Mem: 64}) }
+func BenchmarkInterpOptimized(b *testing.B) {
+	benchBackend(b, BackendInterp, BfOptions{Mem: 64, Optimize: true})
+}
+func BenchmarkClosureOptimized(b *testing.B) {
+	benchBackend(b, BackendClosure, BfOptions{Mem: 64, Optimize: true})
+}
@@ -0,0 +1,67 @@
+package mf
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+)
+
+// SectionCompressedCode는 DEFLATE로 압축된 코드 섹션의 ID입니다.
+// 이 섹션은 CODE 섹션을 대신하며, ReadFile과 OpenFile은 코드를 읽어들일 때 압축을 풉니다.
+// 서명은 압축을 푼 코드를 CODE 섹션으로 기록한 내용에 대해 계산되므로, 압축 여부와 관계없이 유지됩니다.
+const SectionCompressedCode = "CODZ"
+
+// maxCodeSize는 CODE 섹션에 기록할 수 있는 코드의 최대 크기입니다. CODZ 섹션의 압축도 이 크기까지만 풉니다.
+const maxCodeSize = 1<<32 - 1
+
+// Compressed 메서드는 코드를 CODZ 섹션에 압축하여 기록하는지 여부를 반환합니다.
+func (f *FileData) Compressed() bool {
+	return f.compress
+}
+
+// SetCompressed 메서드는 String 메서드가 코드를 DEFLATE로 압축하여 CODZ 섹션에 기록할지를 설정합니다.
+func (f *FileData) SetCompressed(c bool) {
+	f.compress, f.zsize = c, 0
+}
+
+// CompressedSize 메서드는 압축된 코드의 크기를 바이트 단위로 반환합니다.
+// 압축된 파일에서 읽어들인 경우 파일에 기록된 크기를, 그렇지 않으면 새로 압축한 크기를 반환합니다.
+// 코드를 읽을 수 없으면 0을 반환합니다.
+func (f *FileData) CompressedSize() int64 {
+	if !f.compress {
+		return 0
+	}
+	if f.zsize == 0 {
+		code, err := f.Code()
+		if err != nil {
+			return 0
+		}
+		f.zsize = int64(len(deflateCode(code)))
+	}
+	return f.zsize
+}
+
+// deflateCode 함수는 코드를 DEFLATE로 압축합니다.
+func deflateCode(code []byte) []byte {
+	buf := new(bytes.Buffer)
+	w, _ := flate.NewWriter(buf, flate.BestCompression)
+	w.Write(code)
+	w.Close()
+	return buf.Bytes()
+}
+
+// inflateCode 함수는 CODZ 섹션의 압축을 풉니다.
+func inflateCode(data []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	buf := new(bytes.Buffer)
+	n, err := io.CopyN(buf, r, maxCodeSize+1)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s 섹션의 압축을 푸는 중 오류: %v", SectionCompressedCode, err)
+	}
+	if n > maxCodeSize {
+		return nil, fmt.Errorf("%s 섹션의 코드가 너무 큽니다", SectionCompressedCode)
+	}
+	return buf.Bytes(), nil
+}
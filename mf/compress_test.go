@@ -0,0 +1,68 @@
+package mf
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"strings"
+	"testing"
+)
+
+func TestCompressedCode(t *testing.T) {
+	bf := strings.Repeat("+>-<[.]", 2000) + hwBfCode
+	fd, _ := FromBfCodeOpts(bf, BfOptions{Mem: 64})
+	plain := fd.String()
+	fd.SetCompressed(true)
+	file := fd.String()
+	if len(file) >= len(plain)/4 {
+		t.Errorf("compressed file is too large: %d bytes (uncompressed %d bytes)", len(file), len(plain))
+	}
+	if fd.CompressedSize() == 0 || fd.CompressedSize() >= fd.CodeSize() {
+		t.Errorf("unexpected compressed size %d (code %d)", fd.CompressedSize(), fd.CodeSize())
+	}
+
+	rfd, err := ReadFile(bytes.NewBufferString(file))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	ofd, err := OpenFile(strings.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	for _, f := range []FileData{rfd, ofd} {
+		if !bytes.Equal(codeOf(t, &f), codeOf(t, &fd)) {
+			t.Errorf("inflated code mismatch")
+		}
+		if !f.Compressed() || f.CompressedSize() != fd.CompressedSize() {
+			t.Errorf("compressed size mismatch: got %d, expected %d", f.CompressedSize(), fd.CompressedSize())
+		}
+		if len(f.Sections()) != 0 {
+			t.Errorf("CODZ should not be listed as an extra section: %v", f.Sections())
+		}
+		if f.String() != file {
+			t.Errorf("compressed file should be rewritten unchanged")
+		}
+	}
+}
+
+func TestCompressedSignature(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(nil)
+	fd, _ := FromBfCodeOpts(hwBfCode, BfOptions{Mem: 64})
+	if err := fd.Sign(priv); err != nil {
+		t.Fatal(err)
+	}
+	fd.SetCompressed(true)
+	rfd, err := ReadFile(bytes.NewBufferString(fd.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rfd.Verify([]ed25519.PublicKey{priv.Public().(ed25519.PublicKey)}); err != nil {
+		t.Errorf("compression should not invalidate the signature: %v", err)
+	}
+}
+
+func TestCorruptCompressedCode(t *testing.T) {
+	file := sectionedFile(16, nil, SectionCompressedCode, "\xff\xff\xff")
+	if _, err := ReadFile(bytes.NewReader(file)); err == nil {
+		t.Errorf("corrupt CODZ section should fail to load")
+	}
+}
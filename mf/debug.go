@@ -0,0 +1,78 @@
+package mf
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// OpDebug는 Brainfuck의 # 디버그 덤프 명령어 토큰입니다.
+const OpDebug = OpEBF + byte(len(ebfChars))
+
+// Debug는 # 로 VM의 상태를 출력하는 확장입니다. (minfuck --debug)
+var Debug = &Extension{Name: "debug", Chars: "#", First: OpDebug}
+
+// SectionInput은 프로그램보다 먼저 입력으로 읽을 데이터를 기록하는 섹션의 ID입니다.
+const SectionInput = "INPT"
+
+// InputSeparator는 소스 코드에서 프로그램과 입력을 나누는 문자입니다.
+const InputSeparator = '!'
+
+/*
+SplitInput 함수는 소스 코드를 첫 번째 ! 앞의 프로그램과 그 뒤의 입력으로 나눕니다.
+
+ dbfi 같은 자체 인터프리터를 실행할 때 쓰이는 관례입니다. !가 없으면 입력은 nil입니다.
+*/
+func SplitInput(src string) (string, []byte) {
+	i := strings.IndexByte(src, InputSeparator)
+	if i < 0 {
+		return src, nil
+	}
+	return src[:i], []byte(src[i+1:])
+}
+
+// CanSplitInput 함수는 방언 d의 소스 코드를 SplitInput으로 나눌 수 있는지 확인합니다.
+// !가 명령어이거나, Ook!과 Blub처럼 명령어를 쓸 때 !가 들어가는 방언은 나눌 수 없습니다.
+func CanSplitInput(d Dialect) bool {
+	if strings.ContainsRune(d.Emit([]byte{0, 1, 2, 3, 4, 5, 6, 7}), InputSeparator) {
+		return false
+	}
+	toks, _ := d.Tokens(string(InputSeparator))
+	return len(toks) == 0
+}
+
+// Input 메서드는 INPT 섹션에 기록된 입력을 반환합니다.
+func (f *FileData) Input() []byte {
+	b, _ := f.Section(SectionInput)
+	return b
+}
+
+// SetInput 메서드는 프로그램이 표준 입력보다 먼저 읽을 데이터를 INPT 섹션에 기록합니다.
+// 빈 입력은 섹션을 기록하지 않습니다.
+func (f *FileData) SetInput(in []byte) {
+	if len(in) == 0 {
+		f.RemoveSection(SectionInput)
+		return
+	}
+	f.setSection(SectionInput, in)
+}
+
+// withInput 함수는 in을 모두 읽은 뒤 r을 읽는 Reader를 반환합니다.
+func withInput(in []byte, r io.Reader) io.Reader {
+	if len(in) == 0 {
+		return r
+	}
+	return io.MultiReader(bytes.NewReader(in), r)
+}
+
+// dumpWindow는 dump가 현재 셀 앞뒤로 출력하는 셀의 수입니다.
+const dumpWindow = 8
+
+// debugWriter 메서드는 디버그 덤프를 기록할 곳을 반환합니다.
+func (vm *MinFuckVM) debugWriter() io.Writer {
+	if vm.Debug != nil {
+		return vm.Debug
+	}
+	return os.Stderr
+}
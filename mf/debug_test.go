@@ -0,0 +1,108 @@
+package mf
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+var splitInputTestEntries = []struct {
+	src, prog, in string
+}{
+	{src: ",.", prog: ",.", in: ""},
+	{src: ",.!", prog: ",.", in: ""},
+	{src: ",.!ab!c", prog: ",.", in: "ab!c"},
+	{src: "!\n", prog: "", in: "\n"},
+}
+
+func TestSplitInput(t *testing.T) {
+	for n, test := range splitInputTestEntries {
+		prog, in := SplitInput(test.src)
+		if prog != test.prog || string(in) != test.in {
+			t.Errorf("Test #%d failed: got %q, %q, expected %q, %q", n+1, prog, in, test.prog, test.in)
+		}
+	}
+}
+
+func TestCanSplitInput(t *testing.T) {
+	bang, _ := ParseDialect("table:a,b,c,d,e,f,g,!")
+	for _, test := range []struct {
+		d      Dialect
+		expect bool
+	}{
+		{Brainfuck, true}, {TinyBF, true}, {Pbrain, true},
+		{Ook, false}, {Blub, false}, {bang, false},
+	} {
+		if got := CanSplitInput(test.d); got != test.expect {
+			t.Errorf("%s: got %v, expected %v", test.d.Name(), got, test.expect)
+		}
+	}
+}
+
+func TestInputSection(t *testing.T) {
+	prog, in := SplitInput(",.,.!hi")
+	fd, _, err := FromSource(prog, Brainfuck, BfOptions{Mem: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd.SetInput(in)
+	rd, err := ReadFile(strings.NewReader(fd.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rd.Input()) != "hi" {
+		t.Fatalf("unexpected input section: %q", rd.Input())
+	}
+	vm := NewVM(rd)
+	b := make([]byte, 2)
+	if _, err := io.ReadFull(vm.In, b); err != nil || string(b) != "hi" {
+		t.Errorf("unexpected input: %q (%v)", b, err)
+	}
+
+	fd.SetInput(nil)
+	if _, ok := fd.Section(SectionInput); ok {
+		t.Errorf("empty input should remove the section")
+	}
+}
+
+func TestDebugDump(t *testing.T) {
+	d, _ := Extend(Brainfuck, Debug)
+	fd, _, err := FromSource("+++[>+#<-]", d, BfOptions{Mem: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []Backend{BackendInterp, BackendClosure} {
+		var dbg bytes.Buffer
+		vm := NewVM(fd)
+		vm.Debug = &dbg
+		if _, err := runBackend(vm, b, ""); err != nil {
+			t.Fatalf("%v: %v", b, err)
+		}
+		out := dbg.String()
+		if n := strings.Count(out, "VM Status Dump"); n != 3 {
+			t.Errorf("%v: %d dumps, expected 3", b, n)
+		}
+		// 현재 셀은 b2m 레이아웃의 Brainfuck 셀 1 (메모리 번지 17)입니다.
+		for _, s := range []string{"MP: 17", "Mem[9:26]: 3 ", " [1] ", " [3] "} {
+			if !strings.Contains(out, s) {
+				t.Errorf("%v: dump does not contain %q:\n%s", b, s, out)
+			}
+		}
+	}
+
+	// 코드 생성기는 #을 무시합니다.
+	if _, err := ToCCode(fd, Policy{}); err != nil {
+		t.Errorf("ToCCode: %v", err)
+	}
+	if src, err := ToSource(fd, Brainfuck); err != nil || strings.Contains(src, "#") {
+		t.Errorf("ToSource: %q (%v)", src, err)
+	}
+}
+
+func TestDebugDecode(t *testing.T) {
+	in, err := EncodingV2.Decode([]byte{0xf9}, 0)
+	if err != nil || in.Ext != ExtDebug || in.Len != 2 || in.String() != "debug" {
+		t.Errorf("unexpected instruction: %v (%v)", in, err)
+	}
+}
@@ -0,0 +1,418 @@
+package mf
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Token 구조체는 소스 코드에서 읽은 명령어 하나와 그 위치입니다.
+type Token struct {
+	Op  byte      // 니블코드 (0~7) 또는 확장 명령어 (OpProc 등)
+	Off int       // 명령어가 시작하는 소스 코드의 바이트 오프셋
+	Pos SourcePos // 명령어가 시작하는 소스 위치
+}
+
+/*
+Dialect 인터페이스는 Brainfuck 방언의 소스 코드와 MinFuck 니블코드 0~7(+ - > < [ ] . ,)을 서로 변환합니다.
+
+ Tokens는 소스 코드를 명령어 목록으로 나눕니다. 명령어가 아닌 부분은 주석으로 취급하며,
+ 명령어로 읽을 수 없는 부분이 있으면 오류를 반환합니다.
+ Emit은 명령어 목록을 그 방언의 소스 코드로 작성합니다. Tokens(Emit(ops))는 ops와 같아야 합니다.
+*/
+type Dialect interface {
+	Name() string
+	Tokens(src string) ([]Token, error)
+	Emit(ops []byte) string
+}
+
+// 내장 방언입니다.
+var (
+	Brainfuck Dialect = brainfuck{}
+	Ook       Dialect = wordDialect{"ook", "Ook"}
+	Blub      Dialect = wordDialect{"blub", "Blub"}
+	Spoon     Dialect = spoon{}
+	TinyBF    Dialect = tinyBF{}
+	Pbrain    Dialect = pbrain{}
+	Brainfork Dialect = brainfork{}
+)
+
+// ParseDialect 함수는 이름으로 방언을 찾습니다.
+// "table:"로 시작하면 뒤따르는 쉼표로 구분된 8개의 토큰으로 TableDialect를 만듭니다.
+func ParseDialect(name string) (Dialect, error) {
+	if spec := strings.TrimPrefix(name, "table:"); spec != name {
+		d := &TableDialect{Sep: " "}
+		toks := strings.Split(spec, ",")
+		if len(toks) != len(d.Table) {
+			return nil, fmt.Errorf("table 방언에는 %d개의 토큰이 필요합니다: %d개", len(d.Table), len(toks))
+		}
+		copy(d.Table[:], toks)
+		return d, d.validate()
+	}
+	for _, d := range []Dialect{Brainfuck, Ook, Blub, Spoon, TinyBF, Pbrain, Brainfork} {
+		if d.Name() == name {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("알 수 없는 방언: %s", name)
+}
+
+// posTracker 구조체는 소스 코드의 바이트 오프셋을 bfTokens와 같은 방식으로 SourcePos로 변환합니다.
+// 오프셋은 증가하는 순서로 주어져야 합니다.
+type posTracker struct {
+	src string
+	off int
+	pos SourcePos
+}
+
+func newPosTracker(src string) *posTracker {
+	return &posTracker{src: src, pos: SourcePos{Line: 1}}
+}
+
+// at 메서드는 off에서 시작하는 문자의 위치를 반환합니다.
+func (t *posTracker) at(off int) SourcePos {
+	for t.off <= off && t.off < len(t.src) {
+		r, n := utf8.DecodeRuneInString(t.src[t.off:])
+		t.pos.Col++
+		if r == '\n' {
+			t.pos.Line, t.pos.Col = t.pos.Line+1, 0
+		}
+		t.off += n
+	}
+	return t.pos
+}
+
+// brainfuck 타입은 + - > < [ ] . , 여덟 문자를 사용하는 Brainfuck입니다.
+type brainfuck struct{}
+
+func (brainfuck) Name() string { return "bf" }
+
+func (brainfuck) Tokens(src string) ([]Token, error) {
+	return bfTokens(src), nil
+}
+
+func (brainfuck) Emit(ops []byte) string {
+	return emitChars(brainfuck{}, ops)
+}
+
+func (brainfuck) op(r rune) byte { return FromBf(string(r)) }
+
+func (brainfuck) char(op byte) string { return ToBf(op) }
+
+// wordMarks는 Ook!, Blub에서 명령어를 나타내는 두 구두점입니다. 인덱스는 니블코드입니다.
+var wordMarks = [8]string{"..", "!!", ".?", "?.", "!?", "?!", "!.", ".!"}
+
+// wordDialect 타입은 한 단어 뒤에 붙는 구두점(. ? !) 두 개로 명령어 하나를 나타내는 Ook!, Blub입니다.
+type wordDialect struct {
+	name, word string
+}
+
+func (d wordDialect) Name() string { return d.name }
+
+func (d wordDialect) Tokens(src string) ([]Token, error) {
+	var (
+		toks  []Token
+		marks []byte
+		first int // 짝의 첫 단어의 오프셋
+	)
+	pt := newPosTracker(src)
+	for i := 0; i < len(src); {
+		j := strings.Index(src[i:], d.word)
+		if j < 0 {
+			break
+		}
+		i += j + len(d.word)
+		if i >= len(src) || strings.IndexByte(".?!", src[i]) < 0 {
+			continue
+		}
+		if len(marks) == 0 {
+			first = i - len(d.word)
+		}
+		marks = append(marks, src[i])
+		i++
+		if len(marks) < 2 {
+			continue
+		}
+		op := -1
+		for k, m := range wordMarks {
+			if m == string(marks) {
+				op = k
+			}
+		}
+		if op < 0 {
+			return nil, &SourceError{Pos: pt.at(first), Msg: fmt.Sprintf("정의되지 않은 명령어 %s%c %s%c", d.word, marks[0], d.word, marks[1])}
+		}
+		toks = append(toks, Token{Op: byte(op), Off: first, Pos: pt.at(first)})
+		marks = marks[:0]
+	}
+	if len(marks) > 0 {
+		return nil, &SourceError{Pos: pt.at(first), Msg: fmt.Sprintf("짝이 맞지 않는 %s%c", d.word, marks[0])}
+	}
+	return toks, nil
+}
+
+func (d wordDialect) Emit(ops []byte) string {
+	var sb strings.Builder
+	for i, op := range ops {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		m := wordMarks[op]
+		fmt.Fprintf(&sb, "%s%c %s%c", d.word, m[0], d.word, m[1])
+	}
+	return sb.String()
+}
+
+// spoonCodes는 Spoon의 접두 부호입니다. 인덱스는 니블코드입니다.
+var spoonCodes = [8]string{"1", "000", "010", "011", "00100", "0011", "001010", "0010110"}
+
+// spoon 타입은 명령어를 0과 1의 접두 부호로 나타내는 Spoon입니다. 0과 1이 아닌 문자는 무시합니다.
+// Spoon의 DEBUG(00101110)와 EXIT(00101111)는 MinFuck에 대응하는 명령어가 없어 오류로 취급합니다.
+type spoon struct{}
+
+func (spoon) Name() string { return "spoon" }
+
+func (spoon) Tokens(src string) ([]Token, error) {
+	var (
+		toks  []Token
+		code  []byte
+		first int
+	)
+	pt := newPosTracker(src)
+	for i := 0; i < len(src); i++ {
+		if src[i] != '0' && src[i] != '1' {
+			continue
+		}
+		if len(code) == 0 {
+			first = i
+		}
+		code = append(code, src[i])
+		for op, c := range spoonCodes {
+			if c == string(code) {
+				toks = append(toks, Token{Op: byte(op), Off: first, Pos: pt.at(first)})
+				code = code[:0]
+				break
+			}
+		}
+		if len(code) >= 8 {
+			return nil, &SourceError{Pos: pt.at(first), Msg: "지원하지 않는 Spoon 명령어 " + string(code)}
+		}
+	}
+	if len(code) > 0 {
+		return nil, &SourceError{Pos: pt.at(first), Msg: "끝나지 않은 Spoon 명령어 " + string(code)}
+	}
+	return toks, nil
+}
+
+func (spoon) Emit(ops []byte) string {
+	var sb strings.Builder
+	for _, op := range ops {
+		sb.WriteString(spoonCodes[op])
+	}
+	return sb.String()
+}
+
+// tinyBF 타입은 = + > | 네 문자를 사용하는 TinyBF입니다.
+// =는 방향을 바꾸며, 방향에 따라 +는 + 또는 -, >는 > 또는 <, |는 [ 또는 ], ==는 . 또는 ,가 됩니다.
+type tinyBF struct{}
+
+// tinyOps는 정방향일 때의 TinyBF 명령어입니다. 역방향이면 니블코드에 1을 더합니다.
+var tinyOps = map[byte]string{0: "+", 2: ">", 4: "|", 6: "=="}
+
+func (tinyBF) Name() string { return "tinybf" }
+
+func (tinyBF) Tokens(src string) ([]Token, error) {
+	var (
+		toks []Token
+		rev  byte
+	)
+	pt := newPosTracker(src)
+	for i := 0; i < len(src); i++ {
+		var op byte
+		switch src[i] {
+		case '+':
+			op = 0
+		case '>':
+			op = 2
+		case '|':
+			op = 4
+		case '=':
+			if i+1 >= len(src) || src[i+1] != '=' {
+				rev ^= 1
+				continue
+			}
+			op = 6
+		default:
+			continue
+		}
+		toks = append(toks, Token{Op: op | rev, Off: i, Pos: pt.at(i)})
+		if op == 6 {
+			i++
+		}
+	}
+	return toks, nil
+}
+
+func (tinyBF) Emit(ops []byte) string {
+	var (
+		sb  strings.Builder
+		rev byte
+	)
+	for _, op := range ops {
+		if op&1 != rev {
+			sb.WriteByte('=')
+			rev ^= 1
+		}
+		tok := tinyOps[op&^1]
+		// = 바로 뒤의 ==는 ===로 읽히지 않도록 띄어 씁니다.
+		if tok[0] == '=' && strings.HasSuffix(sb.String(), "=") {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(tok)
+	}
+	return sb.String()
+}
+
+// TableDialect 구조체는 명령어마다 임의의 토큰을 지정하는 방언입니다.
+// 소스 코드의 각 위치에서 가장 긴 토큰을 찾으며, 어떤 토큰과도 맞지 않는 문자는 무시합니다.
+type TableDialect struct {
+	Table [8]string // 니블코드 0~7(+ - > < [ ] . ,)에 대응하는 토큰
+	Sep   string    // Emit에서 토큰 사이에 넣을 구분자
+}
+
+func (d *TableDialect) Name() string { return "table" }
+
+// validate 메서드는 토큰이 비어 있지 않고 서로 다른지 확인합니다.
+func (d *TableDialect) validate() error {
+	for i, t := range d.Table {
+		if t == "" {
+			return fmt.Errorf("%s의 토큰이 비어 있습니다", ToBf(byte(i)))
+		}
+		for j := 0; j < i; j++ {
+			if d.Table[j] == t {
+				return fmt.Errorf("%s와 %s의 토큰이 같습니다: %s", ToBf(byte(j)), ToBf(byte(i)), t)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *TableDialect) Tokens(src string) ([]Token, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	var toks []Token
+	pt := newPosTracker(src)
+	for i := 0; i < len(src); {
+		best := -1
+		for op, t := range d.Table {
+			if strings.HasPrefix(src[i:], t) && (best < 0 || len(t) > len(d.Table[best])) {
+				best = op
+			}
+		}
+		if best < 0 {
+			_, n := utf8.DecodeRuneInString(src[i:])
+			i += n
+			continue
+		}
+		toks = append(toks, Token{Op: byte(best), Off: i, Pos: pt.at(i)})
+		i += len(d.Table[best])
+	}
+	return toks, nil
+}
+
+func (d *TableDialect) Emit(ops []byte) string {
+	var sb strings.Builder
+	for i, op := range ops {
+		if i > 0 {
+			sb.WriteString(d.Sep)
+		}
+		sb.WriteString(d.Table[op])
+	}
+	return sb.String()
+}
+
+/*
+ToSource 함수는 MinFuck 프로그램을 주어진 방언의 소스 코드로 변환합니다.
+
+ VMFile과 같이 메모리를 초기화하는 루프를 먼저 작성하며, 메모리 셀 하나가 방언의 셀 하나에 대응합니다.
+ 확장 명령어는 같은 동작을 하는 루프로 풀어 씁니다. 셋 명령어가 뒤따르지 않는 곱셈 명령어는 변환할 수 없습니다.
+*/
+func ToSource(fd FileData, d Dialect) (string, error) {
+	ir, err := buildIR(&fd)
+	if err != nil {
+		return "", err
+	}
+	var ops []byte
+	// repeat 함수는 n이 양수이면 pos를, 음수이면 neg를 |n|번 작성합니다. n은 2^32로 나눈 나머지로 다룹니다.
+	repeat := func(pos, neg byte, n int64) {
+		n = int64(int32(n))
+		if n < 0 {
+			pos, n = neg, -n
+		}
+		for ; n > 0; n-- {
+			ops = append(ops, pos)
+		}
+	}
+
+	// bf 함수는 Brainfuck 코드를 그대로 작성합니다.
+	bf := func(code string) {
+		for _, r := range code {
+			ops = append(ops, FromBf(string(r)))
+		}
+	}
+
+	// 8+2i번째 셀을 i+1로 초기화합니다. 먼저 7+2i번째 셀에 1을 표시하고(반복 횟수는 255씩 나눕니다),
+	// 표시마다 그 오른쪽에 있는 모든 짝수 번째 셀에 1을 더한 뒤 표시를 지웁니다.
+	// 0과 1인 표시, 255 이하의 반복 횟수만 비교하므로 [ ]가 셀의 하위 8비트만 보아도 동작합니다.
+	if n := int64(fd.memsize); n > 0 {
+		repeat(2, 3, 7)
+		for left := n; left > 0; left -= 255 {
+			k := left
+			if k > 255 {
+				k = 255
+			}
+			repeat(0, 1, k)
+			bf("[[->>+<<]+>>-]")
+		}
+		bf("<<[<<]>>[->+>[>+>]<<[<<]>>]")
+		repeat(3, 2, 7+2*n)
+	}
+
+	for i := 0; i < len(ir); i++ {
+		switch op := ir[i]; op.kind {
+		case irAdd:
+			repeat(0, 1, op.n)
+		case irMove:
+			repeat(2, 3, op.n)
+		case irOpen:
+			ops = append(ops, 4)
+		case irClose:
+			ops = append(ops, 5)
+		case irOut:
+			ops = append(ops, 6)
+		case irIn:
+			ops = append(ops, 7)
+		case irSet:
+			ops = append(ops, 4, 1, 5)
+			repeat(0, 1, op.n)
+		case irMul:
+			ops = append(ops, 4, 1)
+			for ; i < len(ir) && ir[i].kind == irMul; i++ {
+				repeat(2, 3, ir[i].off)
+				repeat(0, 1, ir[i].n)
+				repeat(3, 2, ir[i].off)
+			}
+			if i == len(ir) || ir[i].kind != irSet {
+				return "", fmt.Errorf("셋 명령어가 뒤따르지 않는 곱셈 명령어는 변환할 수 없습니다: 니블 오프셋 %d", op.pc)
+			}
+			ops = append(ops, 5)
+			repeat(0, 1, ir[i].n)
+		case irScan:
+			ops = append(ops, 4)
+			repeat(2, 3, op.n)
+			ops = append(ops, 5)
+		}
+	}
+	return d.Emit(ops), nil
+}
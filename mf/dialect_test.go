@@ -0,0 +1,197 @@
+package mf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var dialectTestEntries = []struct {
+	d      Dialect
+	src    string
+	expect string // Brainfuck
+}{
+	{d: Brainfuck, src: "a+b-c>", expect: "+->"},
+	{d: Ook, src: "Ook. Ook? Ook. Ook. Ook! Ook.", expect: ">+."},
+	{d: Ook, src: "Ook! Ook? Ook!\nOok! Ook? Ook! Ook. Ook!", expect: "[-],"},
+	{d: Blub, src: "Blub. Blub. Blub? Blub.", expect: "+<"},
+	{d: Spoon, src: "1 1 000 010 011 00100 0011 001010 0010110", expect: "++-><[].,"},
+	{d: Spoon, src: "110000100110010000110010100010110", expect: "++-><[].,"},
+	{d: TinyBF, src: "+++=+=>", expect: "+++->"},
+	{d: TinyBF, src: "==|=|", expect: ".[]"},
+	{d: TinyBF, src: "===+", expect: ".-"},
+	{d: TinyBF, src: "= ==", expect: ","},
+	{d: &TableDialect{Table: [8]string{"inc", "dec", "right", "left", "while", "end", "out", "in"}},
+		src: "inc inc while dec right inc left end right out input", expect: "++[->+<]>.,"},
+	{d: &TableDialect{Table: [8]string{"a", "aa", "b", "c", "d", "e", "f", "g"}}, src: "aaab", expect: "-+>"},
+}
+
+// tokensBf 함수는 명령어 목록을 Brainfuck 코드로 변환합니다.
+func tokensBf(toks []Token) string {
+	var sb strings.Builder
+	for _, t := range toks {
+		sb.WriteString(ToBf(t.Op))
+	}
+	return sb.String()
+}
+
+func TestDialectTokens(t *testing.T) {
+	for n, test := range dialectTestEntries {
+		toks, err := test.d.Tokens(test.src)
+		if err != nil {
+			t.Errorf("Test #%d failed (%s): %v", n+1, test.d.Name(), err)
+			continue
+		}
+		if got := tokensBf(toks); got != test.expect {
+			t.Errorf("Test #%d failed (%s): got %q, expected %q", n+1, test.d.Name(), got, test.expect)
+		}
+	}
+
+	toks, _ := Ook.Tokens("Ook. Ook.\n  Ook! Ook!")
+	if toks[1].Pos != (SourcePos{2, 3}) || toks[1].Off != 12 {
+		t.Errorf("unexpected token position: %+v", toks[1])
+	}
+}
+
+func TestDialectErrors(t *testing.T) {
+	for _, test := range []struct {
+		d   Dialect
+		src string
+	}{
+		{Ook, "Ook. Ook? Ook."},
+		{Ook, "Ook? Ook?"},
+		{Spoon, "00101110"},
+		{Spoon, "1 00"},
+		{&TableDialect{Table: [8]string{"a", "a", "b", "c", "d", "e", "f", "g"}}, "a"},
+		{&TableDialect{}, ""},
+	} {
+		if _, err := test.d.Tokens(test.src); err == nil {
+			t.Errorf("%s: %q should be rejected", test.d.Name(), test.src)
+		}
+	}
+	for _, name := range []string{"whitespace", "table:a,b", "table:a,b,c,d,e,f,g,g"} {
+		if _, err := ParseDialect(name); err == nil {
+			t.Errorf("ParseDialect(%q) should fail", name)
+		}
+	}
+	if _, _, err := FromSource("Ook.", Ook, BfOptions{Mem: 16}); err == nil {
+		t.Errorf("FromSource should return tokenizer error")
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	for _, d := range []Dialect{Brainfuck, Ook, Blub, Spoon, TinyBF} {
+		if p, err := ParseDialect(d.Name()); err != nil || p != d {
+			t.Errorf("ParseDialect(%q) = %v, %v", d.Name(), p, err)
+		}
+	}
+	d, err := ParseDialect("table:inc,dec,right,left,while,end,out,in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toks, _ := d.Tokens("inc while end"); tokensBf(toks) != "+[]" {
+		t.Errorf("unexpected table dialect tokens: %v", toks)
+	}
+}
+
+func TestDialectEmit(t *testing.T) {
+	var ops []byte
+	for _, tok := range bfTokens(hwBfCode + ",[.,]+-<>==") {
+		ops = append(ops, tok.Op)
+	}
+	for _, d := range []Dialect{Brainfuck, Ook, Blub, Spoon, TinyBF, &TableDialect{Table: [8]string{"a", "aa", "b", "c", "d", "e", "f", "g"}, Sep: " "}} {
+		toks, err := d.Tokens(d.Emit(ops))
+		if err != nil {
+			t.Errorf("%s: %v", d.Name(), err)
+			continue
+		}
+		got := make([]byte, len(toks))
+		for i, tok := range toks {
+			got[i] = tok.Op
+		}
+		if !bytes.Equal(got, ops) {
+			t.Errorf("%s: round trip mismatch\ngot      %s\nexpected %s", d.Name(), tokensBf(toks), Brainfuck.Emit(ops))
+		}
+	}
+}
+
+func TestFromSource(t *testing.T) {
+	for n, test := range rtTestEntries {
+		expect := runBfr(test.bf, test.in)
+		var ops []byte
+		for _, tok := range bfTokens(test.bf) {
+			ops = append(ops, tok.Op)
+		}
+		for _, d := range []Dialect{Ook, Spoon, TinyBF} {
+			fd, sm, err := FromSource(d.Emit(ops), d, BfOptions{Mem: 4096, SourceMap: true, Optimize: true})
+			if err != nil {
+				t.Fatalf("Test #%d failed (%s): %v", n+1, d.Name(), err)
+			}
+			bfd, bsm := FromBfCodeOpts(test.bf, BfOptions{Mem: 4096, SourceMap: true, Optimize: true})
+			if !bytes.Equal(codeOf(t, &fd), codeOf(t, &bfd)) || len(sm.Entries) != len(bsm.Entries) {
+				t.Errorf("Test #%d failed (%s): code differs from Brainfuck", n+1, d.Name())
+			}
+			if out, err := runBackend(NewVM(fd), BackendInterp, test.in); err != nil || out != expect {
+				t.Errorf("Test #%d failed (%s): got %q (%v), expected %q", n+1, d.Name(), out, err, expect)
+			}
+		}
+	}
+}
+
+func TestToSource(t *testing.T) {
+	for n, test := range rtTestEntries {
+		for _, opts := range rtOptions[:3] {
+			opts.Mem = 64
+			fd, _ := FromBfCodeOpts(test.bf, opts)
+			expect, _ := runBackend(NewVM(fd), BackendInterp, test.in)
+			for _, d := range []Dialect{Brainfuck, Ook, TinyBF} {
+				src, err := ToSource(fd, d)
+				if err != nil {
+					t.Fatalf("Test #%d failed (%s): %v", n+1, d.Name(), err)
+				}
+				toks, err := d.Tokens(src)
+				if err != nil {
+					t.Fatalf("Test #%d failed (%s): %v", n+1, d.Name(), err)
+				}
+				if out := runBfr(tokensBf(toks), test.in); out != expect {
+					t.Errorf("Test #%d failed (%+v, %s): got %q, expected %q", n+1, opts, d.Name(), out, expect)
+				}
+			}
+		}
+	}
+
+	// 셋 명령어가 뒤따르지 않는 곱셈 명령어
+	nw := &NibbleWriterOptimized{NibbleWriter: new(NibbleWriter), Encoding: EncodingV2}
+	nw.putExt(ExtMul, SourceRange{}, zigzag(1), zigzag(2))
+	nw.Align()
+	fd := NewFileData(16, nw.Nibbles)
+	fd.SetEncoding(EncodingV2)
+	if _, err := ToSource(fd, Brainfuck); err == nil {
+		t.Errorf("lone multiply should be rejected")
+	}
+
+	// 메모리 초기화 루프는 VMFile과 같은 메모리를 만들고 포인터를 0번째 셀로 되돌립니다.
+	// [ ]는 bfr과 같이 셀의 하위 8비트만 비교합니다.
+	for _, memsize := range []uint32{0, 1, 2, 3, 10, 300} {
+		src, _ := ToSource(NewFileData(memsize, nil), Brainfuck)
+		nw := new(NibbleWriter)
+		for _, b := range src {
+			nw.Put(FromBf(string(b)))
+		}
+		// 바이트를 채우는 마지막 니블은 실행하지 않습니다.
+		vm := &MinFuckVM{Code: nw.Nibbles, Mem: make([]uint32, 1024)}
+		for vm.PC() < uint64(len(src)) {
+			if err := vm.Process(); err != nil {
+				t.Fatalf("memsize %d: %v", memsize, err)
+			}
+		}
+		expect := make([]uint32, 1024)
+		copy(expect, NewVM(NewFileData(memsize, nil)).Mem)
+		if vm.mp != 0 || !equalMem(vm.Mem, expect) {
+			t.Errorf("memsize %d: unexpected memory initialization (pointer %d)", memsize, vm.mp)
+		}
+	}
+	if src, _ := ToSource(NewFileData(4096, nil), Brainfuck); len(src) > 4*4096 {
+		t.Errorf("memory initialization is too long: %d bytes", len(src))
+	}
+}
@@ -0,0 +1,170 @@
+package mf
+
+import (
+	"fmt"
+	"io"
+)
+
+// Instr 구조체는 디코딩된 MinFuck 명령어 하나를 나타냅니다.
+type Instr struct {
+	PC     uint64  // 명령어가 시작하는 니블 오프셋
+	Len    uint64  // 명령어가 차지하는 니블 수
+	Op     byte    // 니블코드 (0~7)
+	Count  uint32  // 반복 횟수 (압축되지 않은 경우 1)
+	Jump   bool    // 점프 대상을 포함하는 압축된 [ 또는 ]인지 여부
+	Target uint64  // 점프 대상 니블 오프셋
+	Nop    bool    // 아무 일도 하지 않는 명령어인지 여부 (EncodingV2)
+	Ext    ExtOp   // 확장 명령어의 종류 (EncodingV2, 확장 명령어가 아니면 0)
+	Args   []int64 // 확장 명령어의 인자
+}
+
+// String 메서드는 명령어를 사람이 읽을 수 있는 형태로 변환합니다.
+func (i Instr) String() string {
+	switch {
+	case i.Nop:
+		return "nop"
+	case i.Ext == ExtEBF:
+		return "ebf " + EBFOp(i.Args[0]).String()
+	case i.Ext != 0:
+		s := i.Ext.String()
+		for _, a := range i.Args {
+			s += fmt.Sprintf(" %d", a)
+		}
+		return s
+	case i.Jump:
+		return fmt.Sprintf("%s -> %d", ToBf(i.Op), i.Target)
+	case i.Len > 1:
+		return fmt.Sprintf("%s x%d", ToBf(i.Op), i.Count)
+	}
+	return ToBf(i.Op)
+}
+
+// nibbleAt 함수는 코드의 pc번째 니블을 반환합니다.
+func nibbleAt(code []byte, pc uint64) (byte, bool) {
+	if pc>>1 >= uint64(len(code)) {
+		return 0, false
+	}
+	return (code[pc>>1] >> (((pc & 1) ^ 1) << 2)) & 0xf, true
+}
+
+// DecodeInstr 함수는 EncodingV1 코드의 pc번째 니블에서 시작하는 명령어를 디코딩합니다.
+// 코드의 끝에서는 io.EOF를, 명령어가 중간에 잘린 경우 io.ErrUnexpectedEOF를 반환합니다.
+func DecodeInstr(code []byte, pc uint64) (Instr, error) {
+	return EncodingV1.Decode(code, pc)
+}
+
+// Decode 메서드는 코드의 pc번째 니블에서 시작하는 명령어를 디코딩합니다.
+// 코드의 끝에서는 io.EOF를, 명령어가 중간에 잘린 경우 io.ErrUnexpectedEOF를 반환합니다.
+func (e Encoding) Decode(code []byte, pc uint64) (Instr, error) {
+	return e.decode(func(pc uint64) (byte, bool) {
+		return nibbleAt(code, pc)
+	}, pc)
+}
+
+// decode 메서드는 fetch로 니블을 읽어 pc에서 시작하는 명령어를 디코딩합니다.
+func (e Encoding) decode(fetch func(uint64) (byte, bool), pc uint64) (Instr, error) {
+	c, ok := fetch(pc)
+	if !ok {
+		return Instr{}, io.EOF
+	}
+	in := Instr{PC: pc, Len: 1, Op: c & 7, Count: 1}
+	if c&8 == 0 {
+		return in, nil
+	}
+	next := func() (byte, bool) {
+		nb, ok := fetch(pc + in.Len)
+		in.Len++
+		return nb, ok
+	}
+
+	switch in.Op {
+	case 0, 1, 2, 3:
+		if in.Count, ok = e.readCount(next); !ok {
+			return Instr{}, io.ErrUnexpectedEOF
+		}
+	case 4, 5:
+		in.Jump = true
+		for i := 0; i < jumpNibbles; i++ {
+			nb, ok := next()
+			if !ok {
+				return Instr{}, io.ErrUnexpectedEOF
+			}
+			in.Target = in.Target<<4 | uint64(nb)
+		}
+	case 6:
+		in.Nop, in.Count = e >= EncodingV2, 0
+	case 7:
+		in.Count = 0
+		if e < EncodingV2 {
+			break
+		}
+		x, ok := next()
+		if !ok {
+			return Instr{}, io.ErrUnexpectedEOF
+		}
+		in.Ext = ExtOp(x)
+		if in.Ext.args() < 0 {
+			return Instr{}, ErrUnknownNibble
+		}
+		for i := 0; i < in.Ext.args(); i++ {
+			v, ok := e.readCount(next)
+			if !ok {
+				return Instr{}, io.ErrUnexpectedEOF
+			}
+			if in.Ext.signed() {
+				in.Args = append(in.Args, int64(unzigzag(v)))
+			} else {
+				in.Args = append(in.Args, int64(v))
+			}
+		}
+		if in.Ext == ExtEBF && in.Args[0] >= int64(len(ebfChars)) || in.Ext == ExtScan && in.Args[0] == 0 {
+			return Instr{}, ErrUnknownNibble
+		}
+	}
+	return in, nil
+}
+
+// CodeStats 구조체는 MinFuck 코드의 통계입니다.
+type CodeStats struct {
+	Nibbles    uint64    // 코드의 니블 수
+	Instrs     uint64    // 명령어 수
+	Compressed uint64    // 압축된 명령어 수
+	Nops       uint64    // NOP 명령어 수
+	Ext        uint64    // 확장 명령어 수
+	Ops        [8]uint64 // 압축을 풀었을 때 니블코드별 개수
+	MaxDepth   int       // 대괄호의 최대 중첩 깊이
+}
+
+// Stats 함수는 주어진 인코딩으로 기록된 MinFuck 코드의 통계를 계산합니다.
+func Stats(code []byte, enc Encoding) (CodeStats, error) {
+	st := CodeStats{Nibbles: uint64(len(code)) * 2}
+	depth := 0
+	for pc := uint64(0); ; {
+		in, err := enc.Decode(code, pc)
+		if err == io.EOF {
+			return st, nil
+		} else if err != nil {
+			return st, fmt.Errorf("니블 오프셋 %d: %v", pc, err)
+		}
+		st.Instrs++
+		if in.Len > 1 {
+			st.Compressed++
+		}
+		if in.Nop {
+			st.Nops++
+		}
+		if in.Ext != 0 {
+			st.Ext++
+		}
+		st.Ops[in.Op] += uint64(in.Count)
+		switch in.Op {
+		case 4:
+			if depth++; depth > st.MaxDepth {
+				st.MaxDepth = depth
+			}
+		case 5:
+			depth--
+		}
+		pc += in.Len
+	}
+}
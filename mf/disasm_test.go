@@ -0,0 +1,71 @@
+package mf
+
+import (
+	"io"
+	"testing"
+)
+
+var diTestEntries = []struct {
+	code  []byte
+	instr []string
+	err   error
+}{
+	{
+		code:  []byte{0x01, 0x23, 0x45, 0x67},
+		instr: []string{"+", "-", ">", "<", "[", "]", ".", ","},
+	},
+	{
+		code:  []byte{0x80, 0x00, 0x00, 0x12, 0x31},
+		instr: []string{"+ x291", "-"},
+	},
+	{
+		code:  []byte{0xd0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x12, 0x30},
+		instr: []string{"] -> 291", "+"},
+	},
+	{
+		code:  []byte{0x02, 0x90, 0x00},
+		instr: []string{"+", ">"},
+		err:   io.ErrUnexpectedEOF,
+	},
+}
+
+func TestDecodeInstr(t *testing.T) {
+	for n, test := range diTestEntries {
+		var got []string
+		var err error
+		for pc := uint64(0); ; {
+			var in Instr
+			in, err = DecodeInstr(test.code, pc)
+			if err != nil {
+				break
+			}
+			got = append(got, in.String())
+			pc += in.Len
+		}
+		if err == io.EOF {
+			err = nil
+		}
+		if err != test.err || len(got) != len(test.instr) {
+			t.Errorf("Test #%d failed: got %v (%v), expected %v (%v)", n+1, got, err, test.instr, test.err)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.instr[i] {
+				t.Errorf("Test #%d failed: instruction #%d is %q, expected %q", n+1, i+1, got[i], test.instr[i])
+			}
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	st, err := Stats([]byte{0x44, 0x80, 0x00, 0x00, 0x00, 0xa5, 0x53, 0x60}, EncodingV1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Nibbles != 16 || st.Instrs != 8 || st.Compressed != 1 || st.MaxDepth != 2 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+	if st.Ops != [8]uint64{11, 0, 0, 1, 2, 2, 1, 0} {
+		t.Errorf("unexpected op counts: %v", st.Ops)
+	}
+}
@@ -0,0 +1,172 @@
+package mf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EBFOp 타입은 Extended Brainfuck Type I 명령어입니다. 확장 페이지 명령어(ExtEBF)의 인자로 기록됩니다.
+type EBFOp byte
+
+// Extended Brainfuck Type I 명령어의 종류입니다. 셀과 저장소는 32비트 값입니다.
+const (
+	EBFEnd   EBFOp = iota // @ 실행 중인 스레드를 끝냅니다 (스레드가 하나이면 프로그램이 끝납니다)
+	EBFStore              // $ 현재 셀을 저장소에 기록합니다
+	EBFLoad               // ! 저장소를 현재 셀에 기록합니다
+	EBFShr                // } 현재 셀을 오른쪽으로 1비트 논리 시프트합니다
+	EBFShl                // { 현재 셀을 왼쪽으로 1비트 시프트합니다
+	EBFNot                // ~ 현재 셀의 비트를 반전합니다
+	EBFXor                // ^ 현재 셀에 저장소를 XOR합니다
+	EBFAnd                // & 현재 셀에 저장소를 AND합니다
+	EBFOr                 // | 현재 셀에 저장소를 OR합니다
+)
+
+// ebfChars는 EBFOp 순서대로 나열한 Extended Brainfuck Type I 명령어 문자입니다.
+const ebfChars = "@$!}{~^&|"
+
+// OpEBF는 Extended Brainfuck Type I 명령어 토큰의 시작입니다. 토큰은 OpEBF + EBFOp입니다.
+const OpEBF = OpFork + 1
+
+// String 메서드는 명령어의 문자를 반환합니다.
+func (x EBFOp) String() string {
+	if int(x) < len(ebfChars) {
+		return ebfChars[x : x+1]
+	}
+	return fmt.Sprintf("EBFOp(%d)", byte(x))
+}
+
+// errEnd는 클로저 백엔드에서 @로 프로그램을 끝낼 때 사용합니다.
+var errEnd = errors.New("end")
+
+// ebf 메서드는 Extended Brainfuck Type I 명령어를 실행합니다. pc는 명령어의 니블 오프셋입니다.
+// @이면 현재 셀에 접근하지 않고 io.EOF를 반환합니다.
+func (vm *MinFuckVM) ebf(pc uint64, x EBFOp) error {
+	if x == EBFEnd {
+		return io.EOF
+	}
+	if err := vm.checkCell(pc); err != nil {
+		return err
+	}
+	c := &vm.Mem[vm.mp]
+	switch x {
+	case EBFStore:
+		vm.st = *c
+	case EBFLoad:
+		*c = vm.st
+	case EBFShr:
+		*c >>= 1
+	case EBFShl:
+		*c <<= 1
+	case EBFNot:
+		*c = ^*c
+	case EBFXor:
+		*c ^= vm.st
+	case EBFAnd:
+		*c &= vm.st
+	case EBFOr:
+		*c |= vm.st
+	}
+	return nil
+}
+
+// Extension 구조체는 문자 하나가 명령어 하나인 방언에 더할 수 있는 명령어 집합입니다.
+type Extension struct {
+	Name  string
+	Chars string // 명령어 문자 (ASCII)
+	First byte   // Chars[i]의 명령어 토큰은 First + i입니다
+}
+
+// EBF1은 Extended Brainfuck Type I 명령어 @ $ ! } { ~ ^ & | 입니다.
+var EBF1 = &Extension{Name: "ebf1", Chars: ebfChars, First: OpEBF}
+
+// ParseExtension 함수는 이름으로 확장을 찾습니다.
+func ParseExtension(name string) (*Extension, error) {
+	for _, e := range []*Extension{EBF1, Debug} {
+		if e.Name == name {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("알 수 없는 확장: %s", name)
+}
+
+// charDialect 인터페이스는 문자 하나가 명령어 하나인 방언입니다. Extend로 명령어를 더할 수 있습니다.
+type charDialect interface {
+	Dialect
+	op(r rune) byte      // 문자에 해당하는 명령어 토큰 (명령어가 아니면 255)
+	char(op byte) string // 명령어 토큰에 해당하는 문자
+}
+
+// emitChars 함수는 명령어 목록을 문자 방언의 소스 코드로 작성합니다.
+func emitChars(d charDialect, ops []byte) string {
+	var sb strings.Builder
+	for _, op := range ops {
+		sb.WriteString(d.char(op))
+	}
+	return sb.String()
+}
+
+// extDialect 타입은 확장 명령어를 더한 문자 방언입니다.
+type extDialect struct {
+	base charDialect
+	exts []*Extension
+}
+
+/*
+Extend 함수는 방언 d에 확장 명령어를 더한 방언을 반환합니다.
+
+ d는 bf, pbrain, brainfork처럼 문자 하나가 명령어 하나인 방언이어야 하며,
+ 확장의 문자가 d나 다른 확장의 명령어와 겹치면 오류를 반환합니다.
+*/
+func Extend(d Dialect, exts ...*Extension) (Dialect, error) {
+	cd, ok := d.(charDialect)
+	if !ok {
+		return nil, fmt.Errorf("%s 방언에는 확장을 더할 수 없습니다", d.Name())
+	}
+	ed := extDialect{base: cd}
+	for _, e := range exts {
+		for _, r := range e.Chars {
+			if ed.op(r) != 255 {
+				return nil, fmt.Errorf("확장 %s의 문자 %c가 %s 방언의 명령어와 겹칩니다", e.Name, r, ed.Name())
+			}
+		}
+		ed.exts = append(ed.exts, e)
+	}
+	return ed, nil
+}
+
+func (d extDialect) Name() string {
+	name := d.base.Name()
+	for _, e := range d.exts {
+		name += "+" + e.Name
+	}
+	return name
+}
+
+func (d extDialect) Tokens(src string) ([]Token, error) {
+	toks := scanTokens(src, d.op)
+	return toks, checkProcs(toks)
+}
+
+func (d extDialect) Emit(ops []byte) string {
+	return emitChars(d, ops)
+}
+
+func (d extDialect) op(r rune) byte {
+	for _, e := range d.exts {
+		if i := strings.IndexRune(e.Chars, r); i >= 0 {
+			return e.First + byte(i)
+		}
+	}
+	return d.base.op(r)
+}
+
+func (d extDialect) char(op byte) string {
+	for _, e := range d.exts {
+		if op >= e.First && int(op-e.First) < len(e.Chars) {
+			return e.Chars[op-e.First : op-e.First+1]
+		}
+	}
+	return d.base.char(op)
+}
@@ -0,0 +1,132 @@
+package mf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var ebfTestEntries = []struct {
+	src, out string
+}{
+	{src: strings.Repeat("+", 65) + ".@.", out: "A"},
+	{src: strings.Repeat("+", 65) + "$>!.", out: "A"},
+	{src: strings.Repeat("+", 130) + "}.", out: "A"},
+	{src: strings.Repeat("+", 33) + "{.", out: "B"},
+	{src: "~" + strings.Repeat("+", 66) + ".", out: "A"}, // 32비트 셀
+	{src: strings.Repeat("+", 96) + "$>" + strings.Repeat("+", 33) + "^.", out: "A"},
+	{src: strings.Repeat("+", 127) + "$>" + strings.Repeat("+", 193) + "&.", out: "A"},
+	{src: strings.Repeat("+", 64) + "$>+|.", out: "A"},
+	{src: "+[>+++++[<{>-]<.@]", out: "\x20"},
+}
+
+func TestEBFRun(t *testing.T) {
+	ebf, _ := Extend(Brainfuck, EBF1)
+	for n, test := range ebfTestEntries {
+		for _, opts := range rtOptions {
+			fd, _, err := FromSource(test.src, ebf, opts)
+			if err != nil {
+				t.Fatalf("Test #%d failed: %v", n+1, err)
+			}
+			for _, b := range []Backend{BackendInterp, BackendClosure} {
+				out, err := runBackend(NewVM(fd), b, "")
+				if err != nil || out != test.out {
+					t.Errorf("Test #%d failed (%+v, %v): got %q (%v), expected %q", n+1, opts, b, out, err, test.out)
+				}
+			}
+		}
+
+		// minfuck bfr
+		toks, _ := ebf.Tokens(test.src)
+		code, enc, _ := TokenCode(toks)
+		vm := &MinFuckVM{Code: code, Encoding: enc, Mem: make([]uint32, 64)}
+		if out, err := runBackend(vm, BackendInterp, ""); err != nil || out != test.out {
+			t.Errorf("Test #%d failed (bfr): got %q (%v), expected %q", n+1, out, err, test.out)
+		}
+	}
+}
+
+func TestEBFEnd(t *testing.T) {
+	// 프로시저 안의 @
+	d, _ := Extend(Pbrain, EBF1)
+	fd, _, _ := FromSource("("+strings.Repeat("+", 65)+".@):.", d, BfOptions{Mem: 16})
+	for _, b := range []Backend{BackendInterp, BackendClosure} {
+		if out, err := runBackend(NewVM(fd), b, ""); err != nil || out != "A" {
+			t.Errorf("%v: got %q (%v)", b, out, err)
+		}
+	}
+
+	// @는 실행 중인 스레드만 끝냅니다.
+	d, _ = Extend(Brainfork, EBF1)
+	fd, _, _ = FromSource("Y[@]"+strings.Repeat("+", 65)+".", d, BfOptions{Mem: 16})
+	for _, s := range []Scheduler{SchedRoundRobin, SchedGoroutine} {
+		vm := NewVM(fd)
+		vm.Scheduler = s
+		if out, err := runBackend(vm, BackendInterp, ""); err != nil || out != "A" {
+			t.Errorf("%v: got %q (%v)", s, out, err)
+		}
+	}
+}
+
+func TestEBFRange(t *testing.T) {
+	// @는 현재 셀에 접근하지 않으므로 범위를 벗어난 포인터로도 프로그램을 끝냅니다.
+	ebf, _ := Extend(Brainfuck, EBF1)
+	for _, test := range []struct {
+		src string
+		err error
+	}{{src: "+.<<@"}, {src: "+.<<$", err: ErrOutOfRange}, {src: "+.<<~", err: ErrOutOfRange}} {
+		toks, _ := ebf.Tokens(test.src)
+		code, enc, _ := TokenCode(toks)
+		vm := &MinFuckVM{Code: code, Encoding: enc, Mem: make([]uint32, 16)}
+		if out, err := runBackend(vm, BackendInterp, ""); out != "\x01" || !errors.Is(err, test.err) {
+			t.Errorf("%q: got %q (%v), expected error %v", test.src, out, err, test.err)
+		}
+	}
+}
+
+func TestExtend(t *testing.T) {
+	d, err := Extend(Pbrain, EBF1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name() != "pbrain+ebf1" {
+		t.Errorf("unexpected name: %s", d.Name())
+	}
+	toks, err := d.Tokens("+@$!}{~^&|(:)x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := make([]byte, len(toks))
+	for i, tok := range toks {
+		ops[i] = tok.Op
+	}
+	if src := d.Emit(ops); src != "+@$!}{~^&|(:)" {
+		t.Errorf("unexpected emit: %q", src)
+	}
+	if toks[1].Op != OpEBF+byte(EBFEnd) || toks[9].Op != OpEBF+byte(EBFOr) {
+		t.Errorf("unexpected tokens: %v", toks)
+	}
+
+	if _, err := Extend(Ook, EBF1); err == nil {
+		t.Errorf("Extend(Ook) should fail")
+	}
+	if _, err := Extend(Brainfuck, EBF1, EBF1); err == nil {
+		t.Errorf("overlapping extensions should be rejected")
+	}
+	if e, err := ParseExtension("ebf1"); err != nil || e != EBF1 {
+		t.Errorf("ParseExtension(ebf1) = %v, %v", e, err)
+	}
+	if _, err := ParseExtension("ebf2"); err == nil {
+		t.Errorf("ParseExtension(ebf2) should fail")
+	}
+}
+
+func TestEBFDecode(t *testing.T) {
+	code := []byte{0xf8, 0x05, 0xf8, 0x09}
+	if in, err := EncodingV2.Decode(code, 0); err != nil || in.String() != "ebf ~" {
+		t.Errorf("unexpected instruction: %v (%v)", in, err)
+	}
+	if _, err := EncodingV2.Decode(code, 4); err != ErrUnknownNibble {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
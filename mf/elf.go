@@ -0,0 +1,333 @@
+package mf
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// ELF 실행 파일의 배치입니다.
+const (
+	elfBase     = 0x400000 // 코드 세그먼트의 가상 주소
+	elfPage     = 0x1000
+	elfHdrSize  = 64
+	elfPhdrSize = 56
+	elfBufSize  = 4096            // 출력 버퍼 크기
+	elfTapeOff  = elfBufSize + 64 // BSS에서 테이프의 위치 (출력 버퍼, 입력 바이트 다음)
+)
+
+// x64 구조체는 x86-64 기계어를 작성하는 간단한 어셈블러입니다.
+// 레이블을 가리키는 rel32 변위와 BSS 주소는 resolve에서 채워집니다.
+type x64 struct {
+	b      []byte
+	labels []int
+	rels   [][2]int // 변위의 위치, 레이블
+	addrs  [][2]int // 주소의 위치, BSS 오프셋
+}
+
+func (a *x64) emit(b ...byte) {
+	a.b = append(a.b, b...)
+}
+
+func (a *x64) imm32(v int32) {
+	a.b = binary.LittleEndian.AppendUint32(a.b, uint32(v))
+}
+
+func (a *x64) imm64(v uint64) {
+	a.b = binary.LittleEndian.AppendUint64(a.b, v)
+}
+
+// label 메서드는 위치가 정해지지 않은 새 레이블을 만듭니다.
+func (a *x64) label() int {
+	a.labels = append(a.labels, -1)
+	return len(a.labels) - 1
+}
+
+// bind 메서드는 레이블을 현재 위치로 정합니다.
+func (a *x64) bind(l int) {
+	a.labels[l] = len(a.b)
+}
+
+// rel 메서드는 레이블까지의 rel32 변위를 작성합니다.
+func (a *x64) rel(l int) {
+	a.rels = append(a.rels, [2]int{len(a.b), l})
+	a.imm32(0)
+}
+
+// addr 메서드는 BSS 안의 절대 주소를 64비트로 작성합니다.
+func (a *x64) addr(off int) {
+	a.addrs = append(a.addrs, [2]int{len(a.b), off})
+	a.imm64(0)
+}
+
+// resolve 메서드는 BSS가 bss 주소에 적재될 때의 변위와 주소를 채웁니다.
+func (a *x64) resolve(bss uint64) {
+	for _, r := range a.rels {
+		binary.LittleEndian.PutUint32(a.b[r[0]:], uint32(int32(a.labels[r[1]]-r[0]-4)))
+	}
+	for _, r := range a.addrs {
+		binary.LittleEndian.PutUint64(a.b[r[0]:], bss+uint64(r[1]))
+	}
+}
+
+// jmp, call, jcc 메서드는 레이블로 점프합니다. cc는 조건 코드입니다. (0x83: jae, 0x84: je, 0x85: jne, 0x8e: jle)
+func (a *x64) jmp(l int)          { a.emit(0xe9); a.rel(l) }
+func (a *x64) call(l int)         { a.emit(0xe8); a.rel(l) }
+func (a *x64) jcc(cc byte, l int) { a.emit(0x0f, cc); a.rel(l) }
+
+// addReg 메서드는 rbx(reg=0xc3) 또는 rcx(reg=0xc1)에 d를 더합니다. rax를 덮어쓸 수 있습니다.
+func (a *x64) addReg(reg byte, d int64) {
+	if d >= math.MinInt32 && d <= math.MaxInt32 {
+		a.emit(0x48, 0x81, reg) // add r64, imm32
+		a.imm32(int32(d))
+		return
+	}
+	a.emit(0x48, 0xb8) // mov rax, imm64
+	a.imm64(uint64(d))
+	a.emit(0x48, 0x01, reg) // add r64, rax
+}
+
+// check 메서드는 rbx(src=0xd8) 또는 rcx(src=0xc8)가 테이프 밖을 가리키면 fail로 점프합니다.
+func (a *x64) check(src byte, fail int) {
+	a.emit(0x48, 0x89, src)  // mov rax, r64
+	a.emit(0x4c, 0x29, 0xe0) // sub rax, r12
+	a.emit(0x4c, 0x39, 0xe8) // cmp rax, r13
+	a.jcc(0x83, fail)
+}
+
+// cmpZero 메서드는 현재 셀을 0과 비교합니다.
+func (a *x64) cmpZero() {
+	a.emit(0x83, 0x3b, 0x00) // cmp dword [rbx], 0
+}
+
+// syscall 메서드는 eax에 번호를 넣고 시스템 콜을 호출합니다.
+func (a *x64) syscall(nr int32) {
+	a.emit(0xb8) // mov eax, imm32
+	a.imm32(nr)
+	a.emit(0x0f, 0x05)
+}
+
+/*
+ToELF 함수는 MinFuck 프로그램을 x86-64 리눅스용 정적 ELF 실행 파일로 변환합니다.
+
+ 어셈블러나 링커 없이 기계어를 직접 작성하며, 입출력은 read, write, exit 시스템 콜을 사용합니다.
+ 테이프는 헤더의 메모리 번지 제한에 맞춘 크기로 BSS에 배치되고, 셀은 VM과 같이 32비트입니다.
+ 연속된 +-><는 하나의 add, sub 명령어가 되며, [ ]는 짝이 맞는 대괄호로 직접 점프합니다.
+ 출력은 4096바이트 단위로 버퍼링되며 입력을 읽기 전과 종료할 때 비워집니다.
+ 입력이 끝나면 셀은 0이 됩니다. rbx를 옮기는 add와 곱셈의 대상 주소를 구하는 add 뒤에서
+ 범위를 검사하여, 테이프 밖이면 출력 버퍼를 비우고 표준 오류에 메시지를 쓴 뒤 종료 코드 2로 종료합니다.
+ 프로그램 끝의 add는 검사하지 않으며, 곱셈은 현재 셀이 0이면 건너뜁니다.
+
+ 레지스터: rbx 메모리 포인터, r12 테이프 주소, r13 테이프 크기, r14 출력 버퍼에 쌓인 바이트 수, r15 출력 버퍼 주소
+*/
+func ToELF(fd FileData) ([]byte, error) {
+	ops, err := buildIR(&fd)
+	if err != nil {
+		return nil, err
+	}
+	if len(ops) > 0 && ops[len(ops)-1].kind == irMove {
+		ops = ops[:len(ops)-1]
+	}
+	a := new(x64)
+	fail, putc, getc, flush, msg := a.label(), a.label(), a.label(), a.label(), a.label()
+
+	a.emit(0x49, 0xbc) // mov r12, 테이프 주소
+	a.addr(elfTapeOff)
+	a.emit(0x49, 0xbd) // mov r13, 테이프 크기
+	a.imm64(tapeSize(fd.memsize) * 4)
+	a.emit(0x49, 0xbf) // mov r15, 출력 버퍼 주소
+	a.addr(0)
+	a.emit(0x45, 0x31, 0xf6) // xor r14d, r14d
+	a.emit(0x4c, 0x89, 0xe3) // mov rbx, r12
+	elfInit(a, fd.memsize)
+
+	var loops [][2]int // 루프의 시작과 끝 레이블
+	for _, op := range ops {
+		switch op.kind {
+		case irAdd:
+			v := int32(op.n)
+			switch {
+			case v == 0:
+			case v == math.MinInt32: // -v를 나타낼 수 없지만 2^31을 더하는 것과 같습니다
+				a.emit(0x81, 0x03) // add dword [rbx], imm32
+				a.imm32(v)
+			case v < 0 && v >= -128:
+				a.emit(0x83, 0x2b, byte(-v)) // sub dword [rbx], imm8
+			case v < 0:
+				a.emit(0x81, 0x2b) // sub dword [rbx], imm32
+				a.imm32(-v)
+			case v <= 127:
+				a.emit(0x83, 0x03, byte(v)) // add dword [rbx], imm8
+			default:
+				a.emit(0x81, 0x03) // add dword [rbx], imm32
+				a.imm32(v)
+			}
+		case irMove:
+			a.addReg(0xc3, op.n*4)
+			a.check(0xd8, fail)
+		case irOpen:
+			l := [2]int{a.label(), a.label()}
+			loops = append(loops, l)
+			a.cmpZero()
+			a.jcc(0x84, l[1])
+			a.bind(l[0])
+		case irClose:
+			l := loops[len(loops)-1]
+			loops = loops[:len(loops)-1]
+			a.cmpZero()
+			a.jcc(0x85, l[0])
+			a.bind(l[1])
+		case irOut:
+			a.call(putc)
+		case irIn:
+			a.call(getc)
+		case irSet:
+			a.emit(0xc7, 0x03) // mov dword [rbx], imm32
+			a.imm32(int32(op.n))
+		case irMul:
+			skip := a.label()
+			a.cmpZero()
+			a.jcc(0x84, skip)
+			a.emit(0x48, 0x89, 0xd9) // mov rcx, rbx
+			a.addReg(0xc1, op.off*4)
+			a.check(0xc8, fail)
+			a.emit(0x8b, 0x03) // mov eax, [rbx]
+			if k := int32(op.n); k != 1 {
+				a.emit(0x69, 0xc0) // imul eax, eax, imm32
+				a.imm32(k)
+			}
+			a.emit(0x01, 0x01) // add [rcx], eax
+			a.bind(skip)
+		case irScan:
+			top, end := a.label(), a.label()
+			a.bind(top)
+			a.cmpZero()
+			a.jcc(0x84, end)
+			a.addReg(0xc3, op.n*4)
+			a.check(0xd8, fail)
+			a.jmp(top)
+			a.bind(end)
+		}
+	}
+	a.call(flush)
+	a.emit(0x31, 0xff) // xor edi, edi
+	a.syscall(60)      // exit
+
+	// putc: 현재 셀의 하위 8비트를 출력 버퍼에 넣고, 버퍼가 가득 차면 비웁니다.
+	a.bind(putc)
+	a.emit(0x8a, 0x03)             // mov al, [rbx]
+	a.emit(0x43, 0x88, 0x04, 0x37) // mov [r15+r14], al
+	a.emit(0x49, 0xff, 0xc6)       // inc r14
+	a.emit(0x49, 0x81, 0xfe)       // cmp r14, imm32
+	a.imm32(elfBufSize)
+	a.jcc(0x83, flush)
+	a.emit(0xc3) // ret
+
+	// flush: 출력 버퍼를 표준 출력에 기록합니다.
+	write, done := a.label(), a.label()
+	a.bind(flush)
+	a.emit(0x4c, 0x89, 0xfe) // mov rsi, r15
+	a.emit(0x4c, 0x89, 0xf2) // mov rdx, r14
+	a.bind(write)
+	a.emit(0x48, 0x85, 0xd2) // test rdx, rdx
+	a.jcc(0x84, done)
+	a.emit(0xbf, 1, 0, 0, 0) // mov edi, 1
+	a.syscall(1)             // write
+	a.emit(0x48, 0x85, 0xc0) // test rax, rax
+	a.jcc(0x8e, done)        // jle: 기록할 수 없으면 버립니다
+	a.emit(0x48, 0x01, 0xc6) // add rsi, rax
+	a.emit(0x48, 0x29, 0xc2) // sub rdx, rax
+	a.jmp(write)
+	a.bind(done)
+	a.emit(0x45, 0x31, 0xf6) // xor r14d, r14d
+	a.emit(0xc3)             // ret
+
+	// getc: 출력 버퍼를 비운 뒤 표준 입력에서 1바이트를 읽습니다.
+	eof := a.label()
+	a.bind(getc)
+	a.call(flush)
+	a.emit(0x31, 0xff)       // xor edi, edi
+	a.emit(0x49, 0x8d, 0xb7) // lea rsi, [r15+elfBufSize]
+	a.imm32(elfBufSize)
+	a.emit(0xba, 1, 0, 0, 0)       // mov edx, 1
+	a.syscall(0)                   // read
+	a.emit(0x48, 0x83, 0xf8, 0x01) // cmp rax, 1
+	a.jcc(0x85, eof)
+	a.emit(0x0f, 0xb6, 0x06) // movzx eax, byte [rsi]
+	a.emit(0x89, 0x03)       // mov [rbx], eax
+	a.emit(0xc3)             // ret
+	a.bind(eof)
+	a.emit(0xc7, 0x03) // mov dword [rbx], 0
+	a.imm32(0)
+	a.emit(0xc3) // ret
+
+	// fail: 출력 버퍼를 비우고 표준 에러에 오류 메시지를 출력한 뒤 종료 코드 2로 종료합니다.
+	const text = "\n메모리 범위를 벗어났습니다\n"
+	a.bind(fail)
+	a.call(flush)
+	a.emit(0xbf, 2, 0, 0, 0) // mov edi, 2
+	a.emit(0x48, 0x8d, 0x35) // lea rsi, [rip+msg]
+	a.rel(msg)
+	a.emit(0xba) // mov edx, len
+	a.imm32(int32(len(text)))
+	a.syscall(1)
+	a.syscall(60) // exit(2)
+	a.bind(msg)
+	a.emit([]byte(text)...)
+
+	return elfFile(a, tapeSize(fd.memsize)*4), nil
+}
+
+// elfInit 함수는 VMFile과 같이 8+2i번째 셀을 i+1로 초기화하는 코드를 작성합니다.
+func elfInit(a *x64, memsize uint32) {
+	top, end := a.label(), a.label()
+	a.emit(0x31, 0xc9)                   // xor ecx, ecx
+	a.emit(0x49, 0x8d, 0x7c, 0x24, 0x20) // lea rdi, [r12+32]
+	a.emit(0x48, 0xba)                   // mov rdx, memsize
+	a.imm64(uint64(memsize))
+	a.bind(top)
+	a.emit(0x48, 0x39, 0xd1) // cmp rcx, rdx
+	a.jcc(0x83, end)
+	a.emit(0x8d, 0x41, 0x01) // lea eax, [rcx+1]
+	a.emit(0x89, 0x04, 0xcf) // mov [rdi+rcx*8], eax
+	a.emit(0x48, 0xff, 0xc1) // inc rcx
+	a.jmp(top)
+	a.bind(end)
+}
+
+// elfFile 함수는 ELF 헤더와 코드, BSS 세그먼트의 프로그램 헤더를 작성합니다.
+func elfFile(a *x64, tape uint64) []byte {
+	const hdr = elfHdrSize + 2*elfPhdrSize
+	size := uint64(hdr + len(a.b))
+	bss := (elfBase+size+elfPage-1)&^(elfPage-1) + elfPage
+	a.resolve(bss)
+
+	le := binary.LittleEndian
+	b := []byte("\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+	b = le.AppendUint16(b, 2)    // ET_EXEC
+	b = le.AppendUint16(b, 0x3e) // EM_X86_64
+	b = le.AppendUint32(b, 1)
+	b = le.AppendUint64(b, elfBase+hdr) // 진입점
+	b = le.AppendUint64(b, elfHdrSize)  // 프로그램 헤더 위치
+	b = le.AppendUint64(b, 0)           // 섹션 헤더 없음
+	b = le.AppendUint32(b, 0)
+	b = le.AppendUint16(b, elfHdrSize)
+	b = le.AppendUint16(b, elfPhdrSize)
+	b = le.AppendUint16(b, 2)
+	b = le.AppendUint16(b, 0)
+	b = le.AppendUint16(b, 0)
+	b = le.AppendUint16(b, 0)
+
+	phdr := func(b []byte, flags uint32, vaddr, filesz, memsz uint64) []byte {
+		b = le.AppendUint32(b, 1) // PT_LOAD
+		b = le.AppendUint32(b, flags)
+		b = le.AppendUint64(b, 0) // 파일 오프셋
+		b = le.AppendUint64(b, vaddr)
+		b = le.AppendUint64(b, vaddr)
+		b = le.AppendUint64(b, filesz)
+		b = le.AppendUint64(b, memsz)
+		return le.AppendUint64(b, elfPage)
+	}
+	b = phdr(b, 5, elfBase, size, size)     // R+X
+	b = phdr(b, 6, bss, 0, elfTapeOff+tape) // R+W
+	return append(b, a.b...)
+}
@@ -0,0 +1,135 @@
+package mf
+
+import (
+	"bytes"
+	"debug/elf"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestToELFHeader(t *testing.T) {
+	fd, _ := FromBfCodeOpts("+[>+<-].", BfOptions{Mem: 100})
+	b, err := ToELF(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := elf.NewFile(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("invalid ELF file: %v", err)
+	}
+	if f.Class != elf.ELFCLASS64 || f.Machine != elf.EM_X86_64 || f.Type != elf.ET_EXEC {
+		t.Errorf("unexpected header: %v %v %v", f.Class, f.Machine, f.Type)
+	}
+	if len(f.Progs) != 2 {
+		t.Fatalf("expected 2 program headers, got %d", len(f.Progs))
+	}
+	text, bss := f.Progs[0], f.Progs[1]
+	if text.Flags != elf.PF_R|elf.PF_X || text.Filesz != uint64(len(b)) || f.Entry != text.Vaddr+elfHdrSize+2*elfPhdrSize {
+		t.Errorf("unexpected text segment: %+v, entry %x", text.ProgHeader, f.Entry)
+	}
+	if bss.Flags != elf.PF_R|elf.PF_W || bss.Filesz != 0 || bss.Memsz != elfTapeOff+tapeSize(100)*4 ||
+		bss.Vaddr < text.Vaddr+text.Memsz || bss.Vaddr%elfPage != 0 {
+		t.Errorf("unexpected bss segment: %+v", bss.ProgHeader)
+	}
+
+	for _, code := range [][]byte{{0x40}, {0x50}} {
+		if _, err := ToELF(NewFileData(16, code)); err == nil {
+			t.Errorf("%x: expected error", code)
+		}
+	}
+}
+
+// runELF 함수는 ELF 실행 파일을 실행하여 출력과 종료 코드를 반환합니다.
+func runELF(t *testing.T, dir string, b []byte, in string) (string, int) {
+	bin := filepath.Join(dir, "prog")
+	if err := ioutil.WriteFile(bin, b, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(bin)
+	cmd.Stdin = strings.NewReader(in)
+	out, err := cmd.Output()
+	if ee, ok := err.(*exec.ExitError); ok {
+		return string(out), ee.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), 0
+}
+
+func TestToELFRun(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("x86-64 리눅스가 아닙니다")
+	}
+	dir, err := ioutil.TempDir("", "mfelf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for n, test := range rtTestEntries {
+		for _, opts := range rtOptions[:3] {
+			fd, _ := FromBfCodeOpts(test.bf, opts)
+			expect, _ := runBackend(NewVM(fd), BackendInterp, test.in)
+
+			b, err := ToELF(fd)
+			if err != nil {
+				t.Fatalf("Test #%d failed: %v", n+1, err)
+			}
+			if out, code := runELF(t, dir, b, test.in); out != expect || code != 0 {
+				t.Errorf("Test #%d failed (%+v): got %q (exit %d), VM %q", n+1, opts, out, code, expect)
+			}
+		}
+	}
+
+	errors := []struct {
+		bf, in string
+		out    string
+		code   int
+	}{
+		{bf: "+.<<+.", out: "\x01", code: 2},
+		{bf: "+[>+]", code: 2},
+		{bf: "+>+>+>+[>]", code: 2},
+		{bf: "+[->" + strings.Repeat(">", 200) + "+<" + strings.Repeat("<", 200) + "]", code: 2},
+		{bf: "+.<<", out: "\x01"},
+		{bf: "[->>>>>>+<<<<<<]+.", out: "\x01"},
+		{bf: "-.", out: "\xff"},
+		{bf: "-[-]" + strings.Repeat("+", 65) + ".", out: "A"},
+		{bf: strings.Repeat("+", 5000) + "[-]" + strings.Repeat(".", 5000), out: strings.Repeat("\x00", 5000)},
+		{bf: ",.,.", in: "x", out: "x\x00"},
+	}
+	for n, test := range errors {
+		for _, opt := range []bool{false, true} {
+			fd, _ := FromBfCodeOpts(test.bf, BfOptions{Mem: 16, Optimize: opt})
+			b, err := ToELF(fd)
+			if err != nil {
+				t.Fatalf("Error #%d failed: %v", n+1, err)
+			}
+			if out, code := runELF(t, dir, b, test.in); out != test.out || code != test.code {
+				t.Errorf("Error #%d failed (optimize=%v): got %q (exit %d), expected %q (exit %d)",
+					n+1, opt, out, code, test.out, test.code)
+			}
+		}
+	}
+	// 2^31을 더하면 셀이 0이 아니어야 합니다: +(2^31) [ +(2^31) + . - ]
+	nw := new(NibbleWriter)
+	for _, op := range []byte{8, 4, 8, 0, 6, 1, 5} {
+		nw.Put(op)
+		if op == 8 {
+			for _, nb := range U32Nibbles(1 << 31) {
+				nw.Put(nb)
+			}
+		}
+	}
+	b, err := ToELF(NewFileData(16, nw.Nibbles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, code := runELF(t, dir, b, ""); out != "\x01" || code != 0 {
+		t.Errorf("adding 2^31: got %q (exit %d), expected %q", out, code, "\x01")
+	}
+}
@@ -0,0 +1,227 @@
+package mf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SectionEncoding은 코드 인코딩 버전을 1바이트로 기록하는 섹션의 ID입니다.
+// 이 섹션이 없는 파일은 EncodingV1을 사용합니다.
+const SectionEncoding = "ENCV"
+
+/*
+Encoding 타입은 MinFuck 코드의 인코딩 버전입니다.
+
+ EncodingV1
+
+ 압축된 니블코드(첫 비트가 1) 다음의 8니블은 반복 횟수를 나타냅니다.
+
+ EncodingV2
+
+ 압축된 +-<> 다음의 반복 횟수는 첫 니블의 상위 비트에 따라 2, 4, 8니블 중 하나의 길이를 가집니다:
+ 0xxx xxxx: 2니블, 7비트 (0 ~ 127)
+ 10xx xxxx xxxx xxxx: 4니블, 14비트 (0 ~ 16383)
+ 11xx xxxx ... xxxx: 8니블, 30비트 (0 ~ 1073741823)
+ 이보다 긴 반복은 여러 개의 압축된 니블코드로 나누어 기록합니다.
+
+ 니블코드 14(압축된 .)는 아무 일도 하지 않는 NOP이며, 정렬과 코드 끝의 홀수 니블을 채우는 데 사용됩니다.
+ 니블코드 15(압축된 ,)는 확장 명령어(ExtOp)를 나타냅니다.
+ 압축된 [ ]는 두 버전 모두 다음의 16니블에 점프할 니블 오프셋을 기록합니다.
+*/
+type Encoding byte
+
+// 지원하는 인코딩 버전입니다.
+const (
+	EncodingV1 Encoding = 1
+	EncodingV2 Encoding = 2
+)
+
+// jumpNibbles는 압축된 [ ]의 점프 대상을 기록하는 니블 수입니다.
+const jumpNibbles = 16
+
+// NibbleNOP는 EncodingV2의 NOP 니블코드입니다.
+const NibbleNOP = 8 | 6
+
+// v2 반복 횟수로 표현할 수 있는 최댓값입니다.
+const maxCountV2 = 1<<30 - 1
+
+// ErrUnknownNibble은 인코딩에 정의되지 않은 니블코드나 확장 명령어를 만났을 때 반환됩니다.
+var ErrUnknownNibble = errors.New("정의되지 않은 니블코드")
+
+// Encoding 메서드는 ENCV 섹션에 기록된 코드 인코딩 버전을 반환합니다.
+func (f *FileData) Encoding() Encoding {
+	b, ok := f.Section(SectionEncoding)
+	if !ok || len(b) != 1 {
+		return EncodingV1
+	}
+	return Encoding(b[0])
+}
+
+// SetEncoding 메서드는 코드 인코딩 버전을 ENCV 섹션에 기록합니다.
+// EncodingV1은 섹션을 기록하지 않습니다.
+func (f *FileData) SetEncoding(e Encoding) {
+	if e <= EncodingV1 {
+		f.RemoveSection(SectionEncoding)
+		return
+	}
+	f.setSection(SectionEncoding, []byte{byte(e)})
+}
+
+// Valid 메서드는 지원하는 인코딩 버전인지 확인합니다.
+func (e Encoding) Valid() bool {
+	return e == EncodingV1 || e == EncodingV2
+}
+
+// countNibbles 메서드는 반복 횟수 n을 기록할 때 필요한 니블 수를 반환합니다.
+func (e Encoding) countNibbles(n uint32) uint32 {
+	switch {
+	case e < EncodingV2:
+		return 8
+	case n < 1<<7:
+		return 2
+	case n < 1<<14:
+		return 4
+	}
+	return 8
+}
+
+// appendCount 메서드는 반복 횟수를 니블 배열로 변환합니다. v2에서 n은 maxCountV2 이하여야 합니다.
+func (e Encoding) appendCount(nb []byte, n uint32) []byte {
+	if e < EncodingV2 {
+		return append(nb, U32Nibbles(n)...)
+	}
+	switch e.countNibbles(n) {
+	case 2:
+		return append(nb, byte(n>>4), byte(n)&0xf)
+	case 4:
+		return append(nb, 8|byte(n>>12), byte(n>>8)&0xf, byte(n>>4)&0xf, byte(n)&0xf)
+	}
+	v := U32Nibbles(n)
+	v[0] |= 0xc
+	return append(nb, v...)
+}
+
+// readCount 메서드는 next로 니블을 읽어 반복 횟수를 디코딩합니다.
+func (e Encoding) readCount(next func() (byte, bool)) (uint32, bool) {
+	first, ok := next()
+	if !ok {
+		return 0, false
+	}
+	var n, v uint32
+	switch {
+	case e < EncodingV2:
+		n, v = 7, uint32(first)
+	case first&8 == 0:
+		n, v = 1, uint32(first)
+	case first&4 == 0:
+		n, v = 3, uint32(first&3)
+	default:
+		n, v = 7, uint32(first&3)
+	}
+	for i := uint32(0); i < n; i++ {
+		nb, ok := next()
+		if !ok {
+			return 0, false
+		}
+		v = v<<4 | uint32(nb)
+	}
+	return v, true
+}
+
+// String 메서드는 인코딩 버전을 "v1", "v2" 형식으로 변환합니다.
+func (e Encoding) String() string {
+	return fmt.Sprintf("v%d", byte(e))
+}
+
+/*
+ExtOp 타입은 EncodingV2의 확장 명령어입니다.
+
+ 확장 명령어는 니블코드 15(압축된 ,) 다음의 1니블로 종류를 나타내며,
+ 이어지는 인자는 EncodingV2의 반복 횟수와 같은 가변 길이로 기록됩니다.
+ 부호 있는 인자는 지그재그 인코딩(0, -1, 1, -2, ... 순서)을 사용합니다.
+ 루프를 대신하는 명령어는 [ ]와 같은 방식으로 현재 셀을 0과 비교하여 반복 횟수를 계산하므로,
+ 원래의 루프와 항상 같은 결과를 냅니다.
+
+ 1 v: 셋 - [-] 다음에 v번의 +를 실행한 것과 같습니다. 현재 셀을 v로 설정합니다.
+ 2 off k: 곱셈 - 현재 셀이 0이 될 때까지 -를 반복하는 횟수에 k를 곱해 off만큼 떨어진 셀에 더합니다.
+          현재 셀은 바꾸지 않으므로, 곱셈 루프는 곱셈 명령어들과 셋 0으로 변환됩니다.
+ 3 step: 스캔 - 현재 셀이 0이 될 때까지 메모리 포인터를 step만큼 옮깁니다.
+ 4 len: 프로시저 정의 - 현재 셀의 값을 번호로 하는 프로시저를 정의합니다. (pbrain의 ( )
+        프로시저의 본문은 이 명령어 바로 다음의 len 니블이며 반환 명령어로 끝납니다. 정의할 때 본문은 건너뜁니다.
+ 5: 반환 - 프로시저를 호출한 명령어 다음으로 돌아갑니다.
+ 6: 호출 - 현재 셀의 값을 번호로 하는 프로시저를 호출합니다. (pbrain의 :)
+ 7 off: 포크 - 실행 중인 스레드를 복제합니다. (Brainfork의 Y)
+        원래 스레드는 현재 셀을 0으로 설정하고, 새 스레드는 메모리 포인터를 off만큼 옮긴 뒤 그 셀을 1로 설정합니다.
+ 8 x: 확장 페이지 - Extended Brainfuck Type I 명령어 x(EBFOp)를 실행합니다.
+ 9: 디버그 - 레지스터와 현재 셀 주변의 메모리를 출력합니다. (Brainfuck의 #, 코드 생성기는 무시합니다)
+*/
+type ExtOp byte
+
+// 확장 명령어의 종류입니다.
+const (
+	ExtSet   ExtOp = 1
+	ExtMul   ExtOp = 2
+	ExtScan  ExtOp = 3
+	ExtProc  ExtOp = 4
+	ExtRet   ExtOp = 5
+	ExtCall  ExtOp = 6
+	ExtFork  ExtOp = 7
+	ExtEBF   ExtOp = 8
+	ExtDebug ExtOp = 9
+)
+
+// NibbleExt는 EncodingV2의 확장 명령어 니블코드입니다.
+const NibbleExt = 8 | 7
+
+// args 메서드는 확장 명령어의 인자 수를 반환합니다. 정의되지 않은 명령어이면 -1을 반환합니다.
+func (x ExtOp) args() int {
+	switch x {
+	case ExtRet, ExtCall, ExtDebug:
+		return 0
+	case ExtSet, ExtScan, ExtProc, ExtFork, ExtEBF:
+		return 1
+	case ExtMul:
+		return 2
+	}
+	return -1
+}
+
+// signed 메서드는 인자가 지그재그 인코딩된 부호 있는 정수인지 확인합니다.
+func (x ExtOp) signed() bool {
+	return x == ExtMul || x == ExtScan || x == ExtFork
+}
+
+// String 메서드는 확장 명령어의 이름을 반환합니다.
+func (x ExtOp) String() string {
+	switch x {
+	case ExtSet:
+		return "set"
+	case ExtMul:
+		return "mul"
+	case ExtScan:
+		return "scan"
+	case ExtProc:
+		return "proc"
+	case ExtRet:
+		return "ret"
+	case ExtCall:
+		return "call"
+	case ExtFork:
+		return "fork"
+	case ExtEBF:
+		return "ebf"
+	case ExtDebug:
+		return "debug"
+	}
+	return fmt.Sprintf("ext%d", byte(x))
+}
+
+// zigzag 함수는 부호 있는 정수를 지그재그 인코딩합니다.
+func zigzag(v int32) uint32 {
+	return uint32(v<<1) ^ uint32(v>>31)
+}
+
+// unzigzag 함수는 지그재그 인코딩된 정수를 복원합니다.
+func unzigzag(u uint32) int32 {
+	return int32(u>>1) ^ -int32(u&1)
+}
@@ -0,0 +1,126 @@
+package mf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestCountV2(t *testing.T) {
+	for _, n := range []uint32{0, 1, 127, 128, 16383, 16384, maxCountV2} {
+		nb := EncodingV2.appendCount(nil, n)
+		if uint32(len(nb)) != EncodingV2.countNibbles(n) {
+			t.Errorf("count %d: encoded in %d nibbles, expected %d", n, len(nb), EncodingV2.countNibbles(n))
+		}
+		i := 0
+		got, ok := EncodingV2.readCount(func() (byte, bool) {
+			if i >= len(nb) {
+				return 0, false
+			}
+			i++
+			return nb[i-1], true
+		})
+		if !ok || got != n || i != len(nb) {
+			t.Errorf("count %d: decoded %d (%v) from %x", n, got, ok, nb)
+		}
+	}
+}
+
+var nbv2TestEntries = []struct {
+	toWrite []byte
+	cnt     int
+	expect  []byte
+}{
+	{ // 3회 반복은 압축하지 않습니다
+		toWrite: []byte{0x1}, cnt: 3,
+		expect: []byte{0x11, 0x1e},
+	},
+	{
+		toWrite: []byte{0x1}, cnt: 4,
+		expect: []byte{0x90, 0x4e},
+	},
+	{
+		toWrite: []byte{0x2}, cnt: 200,
+		expect: []byte{0xa8, 0x0c, 0x8e},
+	},
+	{ // [ ] . ,는 압축하지 않습니다
+		toWrite: []byte{0x6}, cnt: 5,
+		expect: []byte{0x66, 0x66, 0x6e},
+	},
+}
+
+func TestNibblesOptimizedV2(t *testing.T) {
+	for n, test := range nbv2TestEntries {
+		nw := &NibbleWriterOptimized{NibbleWriter: new(NibbleWriter), Encoding: EncodingV2}
+		for i := 0; i < test.cnt; i++ {
+			for _, b := range test.toWrite {
+				nw.Put(b)
+			}
+		}
+		nw.Align()
+		if !bytes.Equal(test.expect, nw.Nibbles) {
+			t.Errorf("Test #%d failed:\ngot      %s\nexpected %s", n+1, hex.EncodeToString(nw.Nibbles), hex.EncodeToString(test.expect))
+		}
+	}
+}
+
+// runBf 함수는 Brainfuck 코드를 주어진 인코딩으로 변환하여 VM에서 실행한 출력을 반환합니다.
+func runBf(t *testing.T, bf string, enc Encoding, in string) string {
+	nw := &NibbleWriterOptimized{NibbleWriter: new(NibbleWriter), Encoding: enc}
+	for _, b := range bf {
+		if op := FromBf(string(b)); op <= 7 {
+			nw.Put(op)
+		}
+	}
+	nw.Align()
+	vm := &MinFuckVM{Code: nw.Nibbles, Encoding: enc, Mem: make([]uint32, 1024)}
+	out, err := runBackend(vm, BackendInterp, in)
+	if err != nil {
+		t.Errorf("VM returned error: %v", err)
+	}
+	return out
+}
+
+var bfRunEntries = []struct {
+	bf, in, out string
+}{
+	{bf: hwBfCode, out: "Hello World!\n"},
+	{bf: "[]" + strings.Repeat("+", 65) + ".", out: "A"},
+	{bf: "[[.]+.]" + strings.Repeat("+", 66) + ".", out: "B"},
+	{bf: strings.Repeat("+", 20) + "[>" + strings.Repeat("+", 69) + "<-]>" + strings.Repeat("-", 1315) + ".", out: "A"},
+	{bf: ",[.,]", in: "echo", out: "echo"},
+	{bf: strings.Repeat(">", 300) + strings.Repeat("+", 67) + "." + strings.Repeat("<", 300) + strings.Repeat("+", 68) + ".", out: "CD"},
+}
+
+func TestRunEncodings(t *testing.T) {
+	for n, test := range bfRunEntries {
+		for _, enc := range []Encoding{EncodingV1, EncodingV2} {
+			if out := runBf(t, test.bf, enc, test.in); out != test.out {
+				t.Errorf("Test #%d (%v) failed: got %q, expected %q", n+1, enc, out, test.out)
+			}
+		}
+	}
+}
+
+func TestFileEncoding(t *testing.T) {
+	fd, _ := FromBfCodeOpts(hwBfCode, BfOptions{Mem: 64, Encoding: EncodingV2})
+	v1, _ := FromBfCodeOpts(hwBfCode, BfOptions{Mem: 64})
+	if len(codeOf(t, &fd)) >= len(codeOf(t, &v1)) {
+		t.Errorf("v2 code (%d bytes) should be shorter than v1 code (%d bytes)", len(codeOf(t, &fd)), len(codeOf(t, &v1)))
+	}
+	rfd, err := ReadFile(bytes.NewBufferString(fd.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfd.Encoding() != EncodingV2 || NewVM(rfd).Encoding != EncodingV2 {
+		t.Errorf("encoding should be preserved: got %v", rfd.Encoding())
+	}
+	if v1.Encoding() != EncodingV1 || len(v1.Sections()) != 0 {
+		t.Errorf("v1 file should not have an encoding section")
+	}
+	st, err := Stats(codeOf(t, &rfd), rfd.Encoding())
+	if err != nil || st.Ops[6] != 13 {
+		t.Errorf("unexpected v2 stats: %+v (%v)", st, err)
+	}
+}
@@ -0,0 +1,218 @@
+package mf
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// OpFork는 Brainfork의 Y 명령어 토큰입니다.
+const OpFork = OpCall + 1
+
+// maxThreads는 동시에 존재할 수 있는 스레드의 최대 수입니다.
+const maxThreads = 1 << 12
+
+// Scheduler 타입은 fork로 만든 스레드를 실행하는 방식입니다.
+type Scheduler int
+
+const (
+	// SchedRoundRobin은 한 고루틴에서 스레드를 Quantum개의 명령어씩 차례로 실행합니다. (기본값)
+	// 실행 순서가 항상 같으므로 결과를 재현할 수 있습니다.
+	SchedRoundRobin Scheduler = iota
+	// SchedGoroutine은 스레드마다 고루틴을 만들어 실행합니다.
+	SchedGoroutine
+)
+
+// String 메서드는 스케줄러의 이름을 반환합니다.
+func (s Scheduler) String() string {
+	switch s {
+	case SchedRoundRobin:
+		return "roundrobin"
+	case SchedGoroutine:
+		return "goroutine"
+	}
+	return fmt.Sprintf("Scheduler(%d)", int(s))
+}
+
+// ParseScheduler 함수는 이름으로 스케줄러를 찾습니다.
+func ParseScheduler(name string) (Scheduler, error) {
+	for _, s := range []Scheduler{SchedRoundRobin, SchedGoroutine} {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("알 수 없는 스케줄러: %s", name)
+}
+
+// thread 구조체는 스레드 하나의 레지스터입니다. 메모리와 코드는 모든 스레드가 공유합니다.
+type thread struct {
+	pc    uint64            // Program counter, 'nibble' offset
+	mp    uint32            // Memory offset
+	bs    []uint64          // Braces stack; 실행 중인 루프 본문의 시작 오프셋
+	cs    []uint64          // Call stack; 프로시저를 호출한 명령어 다음의 오프셋
+	procs map[uint32]uint64 // 프로시저 번호별 본문의 시작 오프셋
+	st    uint32            // Extended Brainfuck의 저장소
+}
+
+// clone 메서드는 스택과 프로시저 표, 저장소를 복사한 새 스레드를 만듭니다.
+func (t *thread) clone() thread {
+	c := thread{
+		pc: t.pc,
+		mp: t.mp,
+		bs: append([]uint64(nil), t.bs...),
+		cs: append([]uint64(nil), t.cs...),
+		st: t.st,
+	}
+	if t.procs != nil {
+		c.procs = make(map[uint32]uint64, len(t.procs))
+		for id, body := range t.procs {
+			c.procs[id] = body
+		}
+	}
+	return c
+}
+
+/*
+fork 메서드는 실행 중인 스레드를 복제합니다. pc는 포크 명령어의 니블 오프셋입니다.
+
+ 원래 스레드의 현재 셀은 0이 되고, 새 스레드는 메모리 포인터를 off만큼 옮긴 셀을 1로 설정한 뒤
+ 포크 명령어 다음부터 실행합니다. 새 스레드는 대괄호 스택, 호출 스택, 프로시저 표와 저장소의 복사본을 가집니다.
+*/
+func (vm *MinFuckVM) fork(pc uint64, off uint32) error {
+	t := vm.mp + off
+	if t >= uint32(len(vm.Mem)) {
+		return rangeError(vm, pc, t)
+	}
+	child := vm.thread.clone()
+	child.mp = t
+	vm.Mem[vm.mp], vm.Mem[t] = 0, 1
+
+	if g := vm.group; g != nil {
+		if g.n >= maxThreads {
+			return fmt.Errorf("스레드가 너무 많습니다: 니블 오프셋 %d", pc)
+		}
+		c := *vm
+		c.thread, c.page, c.pageOff = child, nil, 0
+		g.start(&c)
+		return nil
+	}
+	if len(vm.threads)+1 >= maxThreads {
+		return fmt.Errorf("스레드가 너무 많습니다: 니블 오프셋 %d", pc)
+	}
+	vm.threads = append(vm.threads, child)
+	return nil
+}
+
+// schedule 메서드는 라운드 로빈에서 실행 중인 스레드가 Quantum개의 명령어를 실행했으면 다음 스레드로 바꿉니다.
+func (vm *MinFuckVM) schedule() {
+	if vm.slice++; vm.slice < vm.Quantum {
+		return
+	}
+	vm.threads = append(vm.threads, vm.thread)
+	vm.exitThread()
+}
+
+// exitThread 메서드는 실행 중인 스레드를 버리고 다음 스레드를 실행합니다. 남은 스레드가 없으면 false를 반환합니다.
+func (vm *MinFuckVM) exitThread() bool {
+	if len(vm.threads) == 0 {
+		return false
+	}
+	vm.thread, vm.threads = vm.threads[0], vm.threads[1:]
+	vm.slice = 0
+	return true
+}
+
+// forkGroup 구조체는 SchedGoroutine으로 실행하는 스레드들이 공유하는 상태입니다.
+// 각 스레드는 mu를 잠근 채로 명령어 하나를 실행하므로, 셀 갱신과 입출력은 명령어 단위로 원자적입니다.
+type forkGroup struct {
+	mu   sync.Mutex
+	n    int // 실행 중인 스레드 수 (mu로 보호됩니다)
+	wg   sync.WaitGroup
+	once sync.Once
+	quit chan struct{} // 오류가 발생하거나 VM을 멈추면 닫힙니다
+	err  error
+}
+
+// runGoroutines 메서드는 SchedGoroutine으로 VM을 구동합니다.
+// 스레드 하나에서 오류가 발생하면 모든 스레드를 멈추고 첫 오류를 보고합니다.
+func (vm *MinFuckVM) runGoroutines(stop <-chan struct{}, report chan<- error) {
+	g := &forkGroup{quit: make(chan struct{})}
+	vm.group = g
+	g.start(vm)
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-stop:
+		g.fail(fmt.Errorf("Interrupt"))
+		<-done
+	case <-done:
+	}
+	vm.group = nil
+	report <- g.err
+}
+
+// start 메서드는 vm의 스레드를 새 고루틴에서 실행합니다.
+func (g *forkGroup) start(vm *MinFuckVM) {
+	g.n++
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		for {
+			select {
+			case <-g.quit:
+				return
+			default:
+			}
+			g.mu.Lock()
+			err := vm.Process()
+			if err != nil {
+				g.n--
+			}
+			g.mu.Unlock()
+			if err == io.EOF {
+				return
+			} else if err != nil {
+				g.fail(err)
+				return
+			}
+		}
+	}()
+}
+
+// fail 메서드는 첫 오류를 기록하고 모든 스레드를 멈춥니다.
+func (g *forkGroup) fail(err error) {
+	g.once.Do(func() {
+		g.err = err
+		close(g.quit)
+	})
+}
+
+// brainfork 타입은 Brainfuck에 스레드를 복제하는 Y를 더한 Brainfork입니다.
+type brainfork struct{}
+
+func (brainfork) Name() string { return "brainfork" }
+
+func (d brainfork) Tokens(src string) ([]Token, error) {
+	return scanTokens(src, d.op), nil
+}
+
+func (d brainfork) Emit(ops []byte) string {
+	return emitChars(d, ops)
+}
+
+func (brainfork) op(r rune) byte {
+	if r == 'Y' {
+		return OpFork
+	}
+	return FromBf(string(r))
+}
+
+func (brainfork) char(op byte) string {
+	if op == OpFork {
+		return "Y"
+	}
+	return ToBf(op)
+}
@@ -0,0 +1,109 @@
+package mf
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+var forkTestEntries = []struct {
+	src, in string
+	out     string // 스레드를 바꾸지 않고 순서대로 실행했을 때의 출력
+}{
+	// 원래 스레드는 A를, 새 스레드는 B와 A를 출력합니다.
+	{src: "Y[" + strings.Repeat("+", 65) + ".[-]]>>>" + strings.Repeat("+", 65) + ".", out: "ABA"},
+	// 원래 스레드는 새 스레드가 공유 메모리에 기록한 값을 기다렸다가 출력합니다.
+	{src: "Y>>+<<[->>-<<>>>" + strings.Repeat("+", 67) + "<<<]>>[->>>+[<[.[-]>-<]>]]", out: "C"},
+}
+
+// sortString 함수는 문자열의 문자를 정렬합니다.
+func sortString(s string) string {
+	b := []byte(s)
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	return string(b)
+}
+
+func TestForkRun(t *testing.T) {
+	for n, test := range forkTestEntries {
+		for _, opts := range rtOptions {
+			fd, _, err := FromSource(test.src, Brainfork, opts)
+			if err != nil {
+				t.Fatalf("Test #%d failed: %v", n+1, err)
+			}
+			vm := NewVM(fd)
+			vm.Quantum = 1 << 20
+			if out, err := runBackend(vm, BackendInterp, test.in); err != nil || out != test.out {
+				t.Errorf("Test #%d failed (%+v): got %q (%v), expected %q", n+1, opts, out, err, test.out)
+			}
+
+			// 스케줄러가 같으면 항상 같은 순서로 실행합니다.
+			var outs [2]string
+			for i := range outs {
+				outs[i], err = runBackend(NewVM(fd), BackendInterp, test.in)
+				if err != nil || sortString(outs[i]) != sortString(test.out) {
+					t.Errorf("Test #%d failed (%+v, quantum 1): got %q (%v)", n+1, opts, outs[i], err)
+				}
+			}
+			if outs[0] != outs[1] {
+				t.Errorf("Test #%d failed (%+v): round robin is not deterministic: %q, %q", n+1, opts, outs[0], outs[1])
+			}
+
+			vm = NewVM(fd)
+			vm.Scheduler = SchedGoroutine
+			if out, err := runBackend(vm, BackendInterp, test.in); err != nil || sortString(out) != sortString(test.out) {
+				t.Errorf("Test #%d failed (%+v, goroutine): got %q (%v)", n+1, opts, out, err)
+			}
+		}
+
+		// minfuck bfr
+		toks, _ := Brainfork.Tokens(test.src)
+		code, enc, _ := TokenCode(toks)
+		vm := &MinFuckVM{Code: code, Encoding: enc, Mem: make([]uint32, 64), Quantum: 1 << 20}
+		if out, err := runBackend(vm, BackendInterp, test.in); err != nil || out != test.out {
+			t.Errorf("Test #%d failed (bfr): got %q (%v), expected %q", n+1, out, err, test.out)
+		}
+	}
+}
+
+func TestForkErrors(t *testing.T) {
+	fd, _, _ := FromSource(">>Y", Brainfork, BfOptions{Mem: 2})
+	for _, s := range []Scheduler{SchedRoundRobin, SchedGoroutine} {
+		vm := NewVM(fd)
+		vm.Scheduler = s
+		if _, err := runBackend(vm, BackendInterp, ""); err == nil || !strings.Contains(err.Error(), "메모리 범위를 벗어났습니다") {
+			t.Errorf("%v: unexpected error %v", s, err)
+		}
+	}
+	if _, err := runBackend(NewVM(fd), BackendClosure, ""); err == nil || !strings.Contains(err.Error(), "fork") {
+		t.Errorf("closure: unexpected error %v", err)
+	}
+	if _, err := ToLLVM(fd); err == nil {
+		t.Errorf("ToLLVM should reject fork")
+	}
+
+	// 스레드 하나가 끝나지 않으면 stop 채널로 모두 멈춥니다.
+	fd, _, _ = FromSource("Y+[]", Brainfork, BfOptions{Mem: 16})
+	for _, s := range []Scheduler{SchedRoundRobin, SchedGoroutine} {
+		vm := NewVM(fd)
+		vm.Scheduler = s
+		stop, result := make(chan struct{}, 1), make(chan error, 1)
+		go vm.Run(stop, result)
+		time.Sleep(10 * time.Millisecond)
+		stop <- struct{}{}
+		if err := <-result; err == nil || err.Error() != "Interrupt" {
+			t.Errorf("%v: unexpected error %v", s, err)
+		}
+	}
+}
+
+func TestParseScheduler(t *testing.T) {
+	for _, s := range []Scheduler{SchedRoundRobin, SchedGoroutine} {
+		if p, err := ParseScheduler(s.String()); err != nil || p != s {
+			t.Errorf("ParseScheduler(%q) = %v, %v", s, p, err)
+		}
+	}
+	if _, err := ParseScheduler("fifo"); err == nil {
+		t.Errorf("ParseScheduler should fail")
+	}
+}
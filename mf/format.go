@@ -0,0 +1,221 @@
+package mf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultFormatWidth는 FormatBf의 width가 0일 때 사용하는 한 줄의 최대 길이입니다.
+const DefaultFormatWidth = 72
+
+// 들여쓰기 한 단계와, 한 줄에 그대로 두는 루프의 최대 길이입니다.
+const (
+	formatIndent = "    "
+	inlineLoop   = 16
+)
+
+// bfOps는 대괄호를 제외한 Brainfuck 명령어입니다.
+const bfOps = "+-<>.,"
+
+// isBfOp 함수는 b가 대괄호를 포함한 Brainfuck 명령어인지 확인합니다.
+func isBfOp(b byte) bool {
+	return b == '[' || b == ']' || strings.IndexByte(bfOps, b) >= 0
+}
+
+// formatter 구조체는 FormatBf가 출력을 작성하는 상태입니다.
+type formatter struct {
+	out   strings.Builder
+	width int
+	open  []SourcePos // 열려 있는 [ 의 위치
+	blank bool        // 다음 줄 앞에 빈 줄을 넣어야 하는지 여부
+	run   []string    // 현재 줄의 명령어 (한 줄에 둔 루프는 원소 하나)
+}
+
+// line 메서드는 현재 루프 깊이로 들여쓴 한 줄을 작성합니다.
+func (f *formatter) line(s string) {
+	if f.blank && f.out.Len() > 0 {
+		f.out.WriteByte('\n')
+	}
+	f.blank = false
+	f.out.WriteString(strings.Repeat(formatIndent, len(f.open)))
+	f.out.WriteString(s)
+	f.out.WriteByte('\n')
+}
+
+// flush 메서드는 현재 줄의 명령어를 width에 맞추어 나누어 작성합니다. comment는 마지막 줄 뒤에 붙입니다.
+func (f *formatter) flush(comment string) {
+	if len(f.run) == 0 {
+		if comment != "" {
+			f.line(comment)
+		}
+		return
+	}
+	max := f.width - len(formatIndent)*len(f.open)
+	if max < inlineLoop {
+		max = inlineLoop
+	}
+	var cur string
+	for _, s := range f.run {
+		if cur != "" && len(cur)+len(s) > max {
+			f.line(cur)
+			cur = ""
+		}
+		cur += s
+	}
+	if comment != "" {
+		cur += " " + comment
+	}
+	f.line(cur)
+	f.run = f.run[:0]
+}
+
+// inline 함수는 s[i]의 [ 가 같은 줄에서 닫히는 짧은 루프이면 공백을 뺀 루프와 그 길이(바이트)를 반환합니다.
+// 루프 안에는 대괄호를 제외한 명령어와 공백만 있어야 합니다.
+func inline(s string, i int) (string, int) {
+	loop := "["
+	for j := i + 1; j < len(s) && len(loop) < inlineLoop; j++ {
+		switch c := s[j]; {
+		case c == ']':
+			return loop + "]", j - i + 1
+		case strings.IndexByte(bfOps, c) >= 0:
+			loop += string(c)
+		case c != ' ' && c != '\t':
+			return "", 0
+		}
+	}
+	return "", 0
+}
+
+/*
+FormatBf 함수는 Brainfuck 소스 코드를 표준 형식으로 정리합니다. (minfuck fmt)
+
+ [ 와 ] 는 각각 한 줄을 차지하고 그 사이의 본문은 루프 깊이만큼 들여씁니다.
+ 단, 같은 줄에서 닫히는 짧은 루프([-], [->+<] 등)는 그대로 둡니다.
+ 한 줄의 명령어는 공백 없이 이어 쓰고, width보다 길면 여러 줄로 나눕니다. (0이면 DefaultFormatWidth)
+ 주석의 내용은 그대로 남기며, 명령어 뒤의 주석은 같은 줄에, 그 외의 주석은 한 줄을 차지합니다.
+ 연속된 빈 줄은 하나로 합칩니다. 결과를 다시 정리해도 바뀌지 않으며, 대괄호의 짝이 맞지 않으면 오류를 반환합니다.
+*/
+func FormatBf(src string, width int) (string, error) {
+	if width <= 0 {
+		width = DefaultFormatWidth
+	}
+	f := &formatter{width: width}
+	for n, s := range strings.Split(src, "\n") {
+		s = strings.TrimRight(s, "\r")
+		if strings.TrimSpace(s) == "" {
+			f.blank = true
+			continue
+		}
+		for i := 0; i < len(s); i++ {
+			pos := SourcePos{Line: n + 1, Col: i + 1}
+			switch c := s[i]; {
+			case c == '[':
+				if loop, l := inline(s, i); l > 0 {
+					f.run = append(f.run, loop)
+					i += l - 1
+					continue
+				}
+				f.flush("")
+				f.line("[")
+				f.open = append(f.open, pos)
+			case c == ']':
+				if len(f.open) == 0 {
+					return "", fmt.Errorf("%s: 짝이 맞지 않는 ]", pos)
+				}
+				f.flush("")
+				f.open = f.open[:len(f.open)-1]
+				f.line("]")
+			case isBfOp(c):
+				f.run = append(f.run, string(c))
+			case c != ' ' && c != '\t':
+				j := i
+				for j < len(s) && !isBfOp(s[j]) {
+					j++
+				}
+				f.flush(strings.TrimRight(s[i:j], " \t"))
+				i = j - 1
+			}
+		}
+		f.flush("")
+	}
+	if len(f.open) > 0 {
+		return "", fmt.Errorf("%s: 짝이 맞지 않는 [", f.open[len(f.open)-1])
+	}
+	return f.out.String(), nil
+}
+
+// inverse 함수는 서로 상쇄되는 명령어 쌍인지 확인합니다.
+func inverse(a, b byte) bool {
+	switch a {
+	case '+':
+		return b == '-'
+	case '-':
+		return b == '+'
+	case '>':
+		return b == '<'
+	case '<':
+		return b == '>'
+	}
+	return false
+}
+
+/*
+MinifyBf 함수는 Brainfuck 소스 코드에서 주석과 공백을 지우고, 실행 결과가 같은 더 짧은 코드를 반환합니다.
+
+ +- , -+ , <> , >< 처럼 바로 이어지는 반대 명령어 쌍은 지웁니다. (+-- 는 - 가 됩니다)
+ 프로그램의 맨 앞이나 ] 바로 뒤의 루프는 현재 셀이 항상 0이므로 실행되지 않으며, 지웁니다.
+ 대괄호의 짝이 맞지 않으면 오류를 반환합니다.
+*/
+func MinifyBf(src string) (string, error) {
+	var out []byte
+	var open []int // 열려 있는 [ 의 소스 코드 오프셋
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '[':
+			if len(out) > 0 && out[len(out)-1] != ']' {
+				open = append(open, i)
+				out = append(out, c)
+				continue
+			}
+			// 실행되지 않는 루프는 짝이 맞는 ] 까지 건너뜁니다.
+			start, depth := i, 0
+			for ; i < len(src); i++ {
+				if src[i] == '[' {
+					depth++
+				} else if src[i] == ']' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			if depth > 0 {
+				return "", fmt.Errorf("%s: 짝이 맞지 않는 [", sourcePos(src, start))
+			}
+		case c == ']':
+			if len(open) == 0 {
+				return "", fmt.Errorf("%s: 짝이 맞지 않는 ]", sourcePos(src, i))
+			}
+			open = open[:len(open)-1]
+			out = append(out, c)
+		case strings.IndexByte(bfOps, c) >= 0:
+			if l := len(out) - 1; l >= 0 && inverse(out[l], c) {
+				out = out[:l]
+			} else {
+				out = append(out, c)
+			}
+		}
+	}
+	if len(open) > 0 {
+		return "", fmt.Errorf("%s: 짝이 맞지 않는 [", sourcePos(src, open[len(open)-1]))
+	}
+	if len(out) == 0 {
+		return "", nil
+	}
+	return string(out) + "\n", nil
+}
+
+// sourcePos 함수는 소스 코드의 바이트 오프셋 off의 위치를 반환합니다.
+func sourcePos(src string, off int) SourcePos {
+	line := strings.LastIndexByte(src[:off], '\n')
+	return SourcePos{Line: strings.Count(src[:off], "\n") + 1, Col: off - line}
+}
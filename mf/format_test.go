@@ -0,0 +1,93 @@
+package mf
+
+import (
+	"strings"
+	"testing"
+)
+
+var formatTestEntries = []struct {
+	src, out string
+}{
+	{src: "", out: ""},
+	{src: "+ + +\n", out: "+++\n"},
+	{src: "++[->+<]>.", out: "++[->+<]>.\n"},
+	{src: "++[>++[>+<-]<-]", out: "++\n[\n    >++[>+<-]<-\n]\n"},
+	{src: "+++ add three\n>> move . done", out: "+++ add three\n>> move\n. done\n"},
+	{src: "set up\n\n\n\n+++\n\n", out: "set up\n\n+++\n"},
+	{src: "[\n  comment loop [ with brackets ]\n]", out: "[\n    comment loop\n    [\n        with brackets\n    ]\n]\n"},
+	{src: strings.Repeat("+", 20), out: "++++++++++++++++\n++++\n"},
+}
+
+var minifyTestEntries = []struct {
+	src, out string
+}{
+	{src: "", out: ""},
+	{src: "+ comment +", out: "++\n"},
+	{src: "++-+-->><<.", out: ".\n"},
+	{src: "+>+<-<>", out: "+>+<-\n"},
+	{src: "[comment, loop.]+[-]+-[>]>-", out: "+[-]>-\n"},
+	{src: "+[[-]>[-]<]", out: "+[[-]>[-]<]\n"},
+	{src: "+[-]<>[.]", out: "+[-]\n"},
+}
+
+func TestFormatBf(t *testing.T) {
+	for n, test := range formatTestEntries {
+		out, err := FormatBf(test.src, 16)
+		if err != nil || out != test.out {
+			t.Errorf("Test #%d failed: got %q (%v), expected %q", n+1, out, err, test.out)
+		}
+		if again, _ := FormatBf(out, 16); again != out {
+			t.Errorf("Test #%d failed: not idempotent: %q", n+1, again)
+		}
+	}
+}
+
+func TestMinifyBf(t *testing.T) {
+	for n, test := range minifyTestEntries {
+		out, err := MinifyBf(test.src)
+		if err != nil || out != test.out {
+			t.Errorf("Test #%d failed: got %q (%v), expected %q", n+1, out, err, test.out)
+		}
+		if again, _ := MinifyBf(out); again != out {
+			t.Errorf("Test #%d failed: not idempotent: %q", n+1, again)
+		}
+	}
+}
+
+func TestFormatSemantics(t *testing.T) {
+	for n, test := range rtTestEntries {
+		fd, _ := FromBfCodeOpts(test.bf, BfOptions{Mem: 4096})
+		expect, _ := runBackend(NewVM(fd), BackendInterp, test.in)
+		formatted, err := FormatBf(test.bf, 0)
+		if err != nil {
+			t.Fatalf("Test #%d failed: %v", n+1, err)
+		}
+		minified, err := MinifyBf(formatted)
+		if err != nil {
+			t.Fatalf("Test #%d failed: %v", n+1, err)
+		}
+		for _, src := range []string{formatted, minified} {
+			fd, _ := FromBfCodeOpts(src, BfOptions{Mem: 4096})
+			if out, err := runBackend(NewVM(fd), BackendInterp, test.in); err != nil || out != expect {
+				t.Errorf("Test #%d failed: got %q (%v), expected %q\n%s", n+1, out, err, expect, src)
+			}
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	for _, src := range []string{"+]", "[\n+", "+[[-]"} {
+		if _, err := FormatBf(src, 0); err == nil {
+			t.Errorf("FormatBf(%q) should fail", src)
+		}
+		if _, err := MinifyBf(src); err == nil {
+			t.Errorf("MinifyBf(%q) should fail", src)
+		}
+	}
+	if _, err := FormatBf("+\n +]", 0); err == nil || !strings.HasPrefix(err.Error(), "2:3:") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := MinifyBf("+\n +]"); err == nil || !strings.HasPrefix(err.Error(), "2:3:") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
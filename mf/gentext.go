@@ -0,0 +1,173 @@
+package mf
+
+import (
+	"sort"
+	"strings"
+)
+
+// GenText가 곱셈 루프로 초기화하는 셀의 최대 수와 루프 반복 횟수의 최댓값입니다.
+const (
+	genCells  = 8
+	genLoops  = 24
+	genClimbs = 4 // 셀 수마다 초기값을 조정해 보는 루프 반복 횟수의 수
+)
+
+/*
+GenText 함수는 text를 출력하는 짧은 Brainfuck 코드를 생성합니다. (minfuck gen-text)
+
+ 먼저 n[>a>b...<<-] 형태의 곱셈 루프로 여러 셀을 출력할 문자들의 값 근처로 초기화한 뒤,
+ 각 문자마다 포인터 이동과 +- 의 수가 가장 적은 셀을 골라 값을 맞추고 출력합니다.
+ 셀 수와 루프 반복 횟수의 여러 조합에 대해 각 셀의 초기값을 조정해 보고, 곱셈 루프가 없는 코드를 포함하여 가장 짧은 코드를 반환합니다.
+ 생성한 코드는 셀이 8비트이든 32비트이든 같은 결과를 출력하며, 0번 셀에서 시작하는 연속된 셀만 사용합니다.
+*/
+func GenText(text string) string {
+	b := []byte(text)
+	best := genPrint(b, make([]byte, 1), nil)
+	distinct := make(map[byte]bool)
+	for _, c := range b {
+		distinct[c] = true
+	}
+	for k := 1; k <= genCells && k <= len(distinct); k++ {
+		targets := genTargets(b, k)
+		// 조정하기 전의 코드가 가장 짧은 genClimbs개의 루프 반복 횟수에 대해서만 초기값을 조정합니다.
+		loops := make([]int, 0, genLoops)
+		lens := make(map[int]int)
+		for n := 2; n <= genLoops; n++ {
+			loops = append(loops, n)
+			lens[n] = len(genLoopCode(b, genCoef(targets, n), n))
+		}
+		sort.SliceStable(loops, func(i, j int) bool { return lens[loops[i]] < lens[loops[j]] })
+		for _, n := range loops[:genClimbs] {
+			if code := genClimb(b, genCoef(targets, n), n); len(code) < len(best) {
+				best = code
+			}
+		}
+	}
+	return best
+}
+
+// genTargets 함수는 출력할 문자들의 값을 k개의 무리로 나누고 각 무리의 중심값을 반환합니다. (1차원 k-평균)
+func genTargets(b []byte, k int) []int {
+	vals := make([]int, len(b))
+	for i, c := range b {
+		vals[i] = int(c)
+	}
+	sort.Ints(vals)
+	centers := make([]int, k)
+	for i := range centers {
+		centers[i] = vals[(2*i+1)*len(vals)/(2*k)]
+	}
+	for iter := 0; iter < 16; iter++ {
+		sum, cnt := make([]int, k), make([]int, k)
+		for _, v := range vals {
+			j := 0
+			for i := range centers {
+				if abs(v-centers[i]) < abs(v-centers[j]) {
+					j = i
+				}
+			}
+			sum[j] += v
+			cnt[j]++
+		}
+		for i := range centers {
+			if cnt[i] > 0 {
+				centers[i] = (sum[i] + cnt[i]/2) / cnt[i]
+			}
+		}
+	}
+	return centers
+}
+
+// genCoef 함수는 n번 반복하는 곱셈 루프로 각 셀을 targets에 가장 가까운 값으로 채우기 위해 더할 값을 계산합니다.
+func genCoef(targets []int, n int) []int {
+	coef := make([]int, len(targets))
+	for i, t := range targets {
+		coef[i] = (t + n/2) / n
+	}
+	return coef
+}
+
+// genClimb 함수는 n번 반복하는 곱셈 루프로 채우는 각 셀의 값을 코드가 더 짧아지지 않을 때까지 1씩 조정합니다.
+func genClimb(b []byte, coef []int, n int) string {
+	best := genLoopCode(b, coef, n)
+	for improved := true; improved; {
+		improved = false
+		for i := range coef {
+			for _, d := range []int{-1, 1} {
+				if coef[i]+d < 0 {
+					continue
+				}
+				coef[i] += d
+				if code := genLoopCode(b, coef, n); len(code) < len(best) {
+					best, improved = code, true
+				} else {
+					coef[i] -= d
+				}
+			}
+		}
+	}
+	return best
+}
+
+// genLoopCode 함수는 n번 반복하는 곱셈 루프로 i+1번 셀에 n*coef[i]를 채운 뒤 b를 출력하는 코드를 생성합니다.
+func genLoopCode(b []byte, coef []int, n int) string {
+	var sb strings.Builder
+	cells := make([]byte, len(coef)+1)
+	sb.WriteString(strings.Repeat("+", n))
+	sb.WriteByte('[')
+	for i, a := range coef {
+		sb.WriteByte('>')
+		sb.WriteString(strings.Repeat("+", a))
+		cells[i+1] = byte(a * n)
+	}
+	sb.WriteString(strings.Repeat("<", len(coef)))
+	sb.WriteString("-]")
+	return genPrint(b, cells, &sb)
+}
+
+// genPrint 함수는 포인터가 0번 셀에 있고 셀의 값이 cells일 때, b를 출력하는 코드를 sb 뒤에 이어 작성합니다.
+func genPrint(b []byte, cells []byte, sb *strings.Builder) string {
+	if sb == nil {
+		sb = new(strings.Builder)
+	}
+	p := 0
+	for _, c := range b {
+		best, cost := p, -1
+		for i, v := range cells {
+			if d := abs(i-p) + wrapDist(v, c); cost < 0 || d < cost {
+				best, cost = i, d
+			}
+		}
+		if best > p {
+			sb.WriteString(strings.Repeat(">", best-p))
+		} else {
+			sb.WriteString(strings.Repeat("<", p-best))
+		}
+		p = best
+		if d := wrapDist(cells[p], c); d == int(c-cells[p]) {
+			sb.WriteString(strings.Repeat("+", d))
+		} else {
+			sb.WriteString(strings.Repeat("-", d))
+		}
+		cells[p] = c
+		sb.WriteByte('.')
+	}
+	return sb.String()
+}
+
+// wrapDist 함수는 8비트 셀의 값을 v에서 c로 바꾸는 데 필요한 + 또는 - 의 수를 반환합니다.
+func wrapDist(v, c byte) int {
+	d := int(c - v)
+	if d > 128 {
+		return 256 - d
+	}
+	return d
+}
+
+// abs 함수는 x의 절댓값을 반환합니다.
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,38 @@
+package mf
+
+import (
+	"strings"
+	"testing"
+)
+
+var genTextTestEntries = []struct {
+	text string
+	max  int // 생성한 코드의 최대 길이
+}{
+	{text: "", max: 0},
+	{text: "A", max: 24},
+	{text: "Hello, World!\n", max: 140},
+	{text: "\x00\xff\x80\x7f", max: 300},
+	{text: strings.Repeat("minfuck ", 8), max: 300},
+	{text: "The quick brown fox jumps over the lazy dog.", max: 400},
+}
+
+func TestGenText(t *testing.T) {
+	for n, test := range genTextTestEntries {
+		code := GenText(test.text)
+		if len(code) > test.max {
+			t.Errorf("Test #%d failed: %d bytes, expected at most %d\n%s", n+1, len(code), test.max, code)
+		}
+		fd, _ := FromBfCodeOpts(code, BfOptions{Mem: 64, Optimize: true})
+		if out, err := runBackend(NewVM(fd), BackendInterp, ""); err != nil || out != test.text {
+			t.Errorf("Test #%d failed: got %q (%v), expected %q", n+1, out, err, test.text)
+		}
+
+		// minfuck bfr (8비트 셀)
+		bc, enc, _ := TokenCode(bfTokens(code))
+		vm := &MinFuckVM{Code: bc, Encoding: enc, Mem: make([]uint32, 64)}
+		if out, err := runBackend(vm, BackendInterp, ""); err != nil || out != test.text {
+			t.Errorf("Test #%d failed (bfr): got %q (%v), expected %q", n+1, out, err, test.text)
+		}
+	}
+}
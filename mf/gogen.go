@@ -0,0 +1,166 @@
+package mf
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+)
+
+/*
+ToGoCode 함수는 MinFuck 프로그램을 주어진 패키지의 Go 코드로 변환합니다.
+
+ 생성된 코드는 func Run(in io.Reader, out io.Writer) error 함수를 정의하며,
+ MinFuckVM과 같이 32비트 셀, VMFile과 같은 크기와 초기값의 메모리를 사용하고,
+ 입력이 끝나면 셀을 0으로 설정합니다. 범위 밖의 셀을 인덱싱하면 발생하는 런타임 패닉을 복구하여
+ Run은 ErrOutOfRange를 반환합니다.
+ 출력은 버퍼에 모아 두었다가 입력을 읽기 전과 실행이 끝난 뒤에 out에 기록합니다.
+ 패키지 이름이 main이면 표준 입출력으로 Run을 실행하는 main 함수도 생성합니다.
+*/
+func ToGoCode(fd FileData, pkg string) (string, error) {
+	ops, err := buildIR(&fd)
+	if err != nil {
+		return "", err
+	}
+	uses := map[irKind]bool{}
+	for _, op := range ops {
+		uses[op.kind] = true
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "// Code generated by minfuck m2go. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	imports := []string{"bufio", "errors", "io"}
+	if pkg == "main" {
+		imports = append(imports, "os")
+	}
+	for _, im := range append(imports, "runtime") {
+		fmt.Fprintf(buf, "\t%q\n", im)
+	}
+	fmt.Fprintf(buf, goRunStart, tapeSize(fd.memsize), fd.memsize)
+	if uses[irIn] {
+		buf.WriteString(goInput)
+	}
+	if len(ops) > 0 {
+		buf.WriteString("\tp := 0\n\n")
+	}
+
+	for i, op := range ops {
+		switch op.kind {
+		case irAdd:
+			buf.WriteString(goAdd("m[p]", "", uint32(op.n)))
+		case irMove:
+			if op.n > 0 {
+				fmt.Fprintf(buf, "p += %d\n", op.n)
+			} else if op.n < 0 {
+				fmt.Fprintf(buf, "p -= %d\n", -op.n)
+			}
+		case irOpen:
+			buf.WriteString("for m[p] != 0 {\n")
+		case irClose:
+			buf.WriteString("}\n")
+		case irOut:
+			buf.WriteString("w.WriteByte(byte(m[p]))\n")
+		case irIn:
+			buf.WriteString("if err := input(p); err != nil {\nreturn err\n}\n")
+		case irSet:
+			fmt.Fprintf(buf, "m[p] = %d\n", uint32(op.n))
+		case irMul:
+			dst := fmt.Sprintf("m[p+%d]", op.off)
+			if op.off < 0 {
+				dst = fmt.Sprintf("m[p-%d]", -op.off)
+			}
+			// MinFuckVM과 같이 현재 셀이 0이면 대상 셀에 접근하지 않습니다.
+			if i == 0 || ops[i-1].kind != irMul {
+				buf.WriteString("if m[p] != 0 {\n")
+			}
+			buf.WriteString(goAdd(dst, "m[p]", uint32(op.n)))
+			if i == len(ops)-1 || ops[i+1].kind != irMul {
+				buf.WriteString("}\n")
+			}
+		case irScan:
+			if op.n > 0 {
+				fmt.Fprintf(buf, "for m[p] != 0 {\np += %d\n}\n", op.n)
+			} else {
+				fmt.Fprintf(buf, "for m[p] != 0 {\np -= %d\n}\n", -op.n)
+			}
+		}
+	}
+	buf.WriteString("return nil\n}\n")
+	if pkg == "main" {
+		buf.WriteString(goMain)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("생성한 Go 코드가 잘못되었습니다: %v", err)
+	}
+	return string(src), nil
+}
+
+// goAdd 함수는 dst에 factor * v를 더하는 Go 문장을 만듭니다. factor가 비어 있으면 v만 더합니다.
+// v가 2^31을 넘으면 뺄셈으로 나타냅니다.
+func goAdd(dst, factor string, v uint32) string {
+	if v == 0 {
+		return ""
+	}
+	op := "+="
+	if v > 1<<31 {
+		op, v = "-=", -v
+	}
+	switch {
+	case factor == "" && v == 1:
+		return dst + op[:1] + op[:1] + "\n"
+	case factor == "":
+		return fmt.Sprintf("%s %s %d\n", dst, op, v)
+	case v == 1:
+		return fmt.Sprintf("%s %s %s\n", dst, op, factor)
+	}
+	return fmt.Sprintf("%s %s %s * %d\n", dst, op, factor, v)
+}
+
+const goRunStart = `)
+
+// ErrOutOfRange는 프로그램이 메모리 범위를 벗어났을 때 반환됩니다.
+var ErrOutOfRange = errors.New("메모리 범위를 벗어났습니다")
+
+// Run 함수는 in에서 입력을 읽고 out에 출력하며 프로그램을 실행합니다.
+func Run(in io.Reader, out io.Writer) (err error) {
+	m := make([]uint32, %d)
+	for i := 0; i < %d; i++ {
+		m[8+2*i] = uint32(i + 1)
+	}
+	w := bufio.NewWriter(out)
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(runtime.Error); !ok {
+				panic(r)
+			}
+			err = ErrOutOfRange
+		}
+		if ferr := w.Flush(); err == nil {
+			err = ferr
+		}
+	}()
+`
+
+const goInput = `	r := bufio.NewReader(in)
+	input := func(p int) error {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		c, err := r.ReadByte()
+		if err == io.EOF {
+			c, err = 0, nil
+		}
+		m[p] = uint32(c)
+		return err
+	}
+`
+
+const goMain = `
+func main() {
+	if err := Run(os.Stdin, os.Stdout); err != nil {
+		os.Stderr.WriteString(err.Error() + "\n")
+		os.Exit(2)
+	}
+}
+`
@@ -0,0 +1,48 @@
+package mf
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+var goCodeTestEntries = []struct {
+	bf     string
+	expect []string
+}{
+	{bf: "+++++.", expect: []string{"m[p] += 5\n", "w.WriteByte(byte(m[p]))\n"}},
+	{bf: "-<", expect: []string{"m[p]--\n", "p -= 8\n"}},
+	{bf: "[-]", expect: []string{"m[p] = 0\n"}},
+	{bf: "[->++<]", expect: []string{"if m[p] != 0 {\n\t\tm[p+8] += m[p] * 2\n\t}\n", "m[p] = 0\n"}},
+	{bf: "[-<->]", expect: []string{"m[p-8] -= m[p]\n"}},
+	{bf: "[<]", expect: []string{"for m[p] != 0 {\n\t\tp -= 8\n\t}\n"}},
+	{bf: ",[.,]", expect: []string{"if err := input(p); err != nil {", "for m[p] != 0 {"}},
+}
+
+func TestToGoCode(t *testing.T) {
+	for n, test := range goCodeTestEntries {
+		for _, pkg := range []string{"main", "prog"} {
+			fd, _ := FromBfCodeOpts(test.bf, BfOptions{Mem: 16, Optimize: true})
+			code, err := ToGoCode(fd, pkg)
+			if err != nil {
+				t.Fatalf("Test #%d failed: %v", n+1, err)
+			}
+			f, err := parser.ParseFile(token.NewFileSet(), "prog.go", code, 0)
+			if err != nil {
+				t.Fatalf("Test #%d failed: generated code does not parse: %v\n%s", n+1, err, code)
+			}
+			if f.Name.Name != pkg || strings.Contains(code, "func main()") != (pkg == "main") {
+				t.Errorf("Test #%d failed: unexpected package %s", n+1, f.Name.Name)
+			}
+			for _, e := range test.expect {
+				if !strings.Contains(code, e) {
+					t.Errorf("Test #%d failed: %q not found in\n%s", n+1, e, code)
+				}
+			}
+		}
+	}
+	if _, err := ToGoCode(NewFileData(16, []byte{0x40}), "main"); err == nil {
+		t.Errorf("unmatched bracket should be rejected")
+	}
+}
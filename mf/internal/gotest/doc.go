@@ -0,0 +1,7 @@
+// Package gotest는 m2go로 생성한 Go 코드가 MinFuckVM과 같은 결과를 내는지 확인합니다.
+//
+// hello.go는 go generate로 다시 생성할 수 있습니다.
+package gotest
+
+//go:generate go run github.com/cr0sh/minfuck b2m --optimize hello.bf 64
+//go:generate go run github.com/cr0sh/minfuck m2go -pkg gotest -o hello.go hello.mf
@@ -0,0 +1,3 @@
+인사를 출력하고 입력을 그대로 출력합니다
+>++++++++[-<+++++++++>]<.>>+>-[+]++>++>+++[>[->+++<<+++>]<<]>-----.>->+++..+++.>-.<<+[>[+>+]>>]<--------------.>>.+++.------.--------.>+.>+.
+>>>>>>>>,[.,]
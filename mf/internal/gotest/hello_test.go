@@ -0,0 +1,64 @@
+package gotest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cr0sh/minfuck/mf"
+	"github.com/cr0sh/minfuck/mf/internal/vmtest"
+)
+
+func TestRun(t *testing.T) {
+	for _, in := range []string{"", "echo", "multi\nline\n"} {
+		f, err := os.Open("hello.mf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fd, err := mf.ReadFile(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		vmOut, err := vmtest.Run(fd, mf.BackendInterp, in)
+		if err != nil {
+			t.Fatalf("VM failed: %v", err)
+		}
+
+		out := new(bytes.Buffer)
+		if err := Run(strings.NewReader(in), out); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if out.String() != vmOut {
+			t.Errorf("input %q: got %q, VM %q", in, out.String(), vmOut)
+		}
+		if !strings.HasPrefix(out.String(), "Hello World!\n") {
+			t.Errorf("unexpected output %q", out.String())
+		}
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	f, err := os.Open("hello.mf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd, err := mf.ReadFile(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := mf.ToGoCode(fd, "gotest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile("hello.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != code {
+		t.Errorf("hello.go is out of date; run go generate")
+	}
+}
@@ -0,0 +1,15 @@
+// Package vmtest는 mf 밖의 패키지가 테스트에서 MinFuckVM을 구동할 때 쓰는 도우미를 제공합니다.
+package vmtest
+
+import "github.com/cr0sh/minfuck/mf"
+
+// Run 함수는 fd로 만든 VM을 backend로 구동하여 in을 입력으로 실행하고, 출력과 Run이 보고한 오류를 반환합니다.
+func Run(fd mf.FileData, backend mf.Backend, in string) (string, error) {
+	s := &mf.IOStream{Stdin: in}
+	vm := mf.NewVM(fd)
+	vm.In, vm.Out, vm.Backend = s, s, backend
+	result := make(chan error, 1)
+	vm.Run(nil, result)
+	err := <-result
+	return s.Stdout, err
+}
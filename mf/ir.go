@@ -0,0 +1,182 @@
+package mf
+
+import (
+	"fmt"
+	"io"
+)
+
+// irKind 타입은 코드 생성에 사용하는 중간 표현 명령어의 종류입니다.
+type irKind byte
+
+const (
+	irAdd   irKind = iota // 현재 셀에 n을 더합니다
+	irMove                // 메모리 포인터를 n만큼 옮깁니다
+	irOpen                // 현재 셀이 0이 아닌 동안 반복하는 루프의 시작
+	irClose               // 루프의 끝
+	irOut                 // 현재 셀을 출력합니다
+	irIn                  // 현재 셀에 입력을 읽어들입니다
+	irSet                 // 현재 셀을 n으로 설정합니다
+	irMul                 // off만큼 떨어진 셀에 현재 셀 * n을 더합니다
+	irScan                // 현재 셀이 0이 될 때까지 메모리 포인터를 n만큼 옮깁니다
+)
+
+// irOp 구조체는 중간 표현 명령어 하나입니다.
+type irOp struct {
+	kind irKind
+	n    int64
+	off  int64
+	pc   uint64 // 원래 명령어의 니블 오프셋
+}
+
+/*
+buildIR 함수는 파일의 MinFuck 코드를 코드 생성용 중간 표현으로 변환합니다.
+
+ 연속된 +- 와 >< 는 하나의 덧셈과 이동으로 합치고,
+ +-<> 만으로 이루어진 루프는 optimizeLoop와 같은 방식으로 곱셈, 셋, 스캔으로 변환합니다.
+ 압축된 [ ]의 점프 대상은 짝이 맞는 대괄호와 일치해야 하며, 그렇지 않으면 오류를 반환합니다.
+ pbrain 프로시저 명령어는 지원하지 않으므로 오류를 반환합니다.
+ 디버그 명령어(#)는 무시합니다.
+*/
+func buildIR(fd *FileData) ([]irOp, error) {
+	code, err := fd.Code()
+	if err != nil {
+		return nil, err
+	}
+	enc := fd.Encoding()
+	var (
+		ops   []irOp
+		loops []int // ops에서 irOpen의 위치
+		open  []Instr
+	)
+	for pc := uint64(0); ; {
+		in, err := enc.Decode(code, pc)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("니블 오프셋 %d: %v", pc, err)
+		}
+		pc += in.Len
+
+		switch {
+		case in.Ext == ExtSet:
+			ops = append(ops, irOp{kind: irSet, n: in.Args[0], pc: in.PC})
+		case in.Ext == ExtMul:
+			ops = append(ops, irOp{kind: irMul, off: in.Args[0], n: in.Args[1], pc: in.PC})
+		case in.Ext == ExtScan:
+			ops = append(ops, irOp{kind: irScan, n: in.Args[0], pc: in.PC})
+		case in.Ext == ExtDebug:
+		case in.Ext != 0:
+			return nil, fmt.Errorf("지원하지 않는 확장 명령어 %s: 니블 오프셋 %d", in.Ext, in.PC)
+		case in.Count == 0: // NOP, EncodingV1의 압축된 . ,
+		case in.Op == 0 || in.Op == 1:
+			n := int64(in.Count)
+			if in.Op == 1 {
+				n = -n
+			}
+			if l := len(ops) - 1; l >= 0 && ops[l].kind == irAdd {
+				ops[l].n += n
+			} else {
+				ops = append(ops, irOp{kind: irAdd, n: n, pc: in.PC})
+			}
+		case in.Op == 2 || in.Op == 3:
+			n := int64(in.Count)
+			if in.Op == 3 {
+				n = -n
+			}
+			if l := len(ops) - 1; l >= 0 && ops[l].kind == irMove {
+				ops[l].n += n
+			} else {
+				ops = append(ops, irOp{kind: irMove, n: n, pc: in.PC})
+			}
+		case in.Op == 4:
+			loops, open = append(loops, len(ops)), append(open, in)
+			ops = append(ops, irOp{kind: irOpen, pc: in.PC})
+		case in.Op == 5:
+			if len(loops) == 0 {
+				return nil, fmt.Errorf("짝이 맞지 않는 ]: 니블 오프셋 %d", in.PC)
+			}
+			start, o := loops[len(loops)-1], open[len(open)-1]
+			loops, open = loops[:len(loops)-1], open[:len(open)-1]
+			if (o.Jump && o.Target != uint64(pc)) || (in.Jump && in.Target != uint64(o.PC+o.Len)) {
+				return nil, fmt.Errorf("구조화되지 않은 점프: 니블 오프셋 %d", in.PC)
+			}
+			if id, ok := matchIdiom(ops[start+1:]); ok {
+				ops = append(ops[:start], id.ops(in.PC)...)
+			} else {
+				ops = append(ops, irOp{kind: irClose, pc: in.PC})
+			}
+		case in.Op == 6:
+			ops = append(ops, irOp{kind: irOut, pc: in.PC})
+		case in.Op == 7:
+			ops = append(ops, irOp{kind: irIn, pc: in.PC})
+		}
+	}
+	if len(loops) > 0 {
+		return nil, fmt.Errorf("짝이 맞지 않는 [: 니블 오프셋 %d", open[len(open)-1].PC)
+	}
+	return ops, nil
+}
+
+// mulTerm 구조체는 곱셈 루프에서 off만큼 떨어진 셀에 반복마다 더하는 값 k입니다.
+type mulTerm struct {
+	off, k int64
+}
+
+// loopIdiom 구조체는 확장 명령어로 바꿀 수 있는 루프입니다.
+// scan이 0이 아니면 스캔 루프이고, 그렇지 않으면 곱셈 루프입니다.
+type loopIdiom struct {
+	scan int64
+	muls []mulTerm
+}
+
+// matchIdiom 함수는 irAdd와 irMove로만 이루어진 루프 본문이 스캔 또는 곱셈 루프인지 확인합니다.
+// 곱셈 루프는 포인터가 제자리로 돌아오고 현재 셀을 1씩 줄여야 합니다.
+func matchIdiom(body []irOp) (loopIdiom, bool) {
+	var (
+		ptr    int64
+		deltas = map[int64]int64{}
+		order  []int64
+	)
+	for _, op := range body {
+		switch op.kind {
+		case irAdd:
+			if _, ok := deltas[ptr]; !ok {
+				order = append(order, ptr)
+			}
+			deltas[ptr] += op.n
+		case irMove:
+			ptr += op.n
+		default:
+			return loopIdiom{}, false
+		}
+	}
+	changed := false
+	for _, d := range deltas {
+		changed = changed || d != 0
+	}
+	if !changed && ptr != 0 {
+		return loopIdiom{scan: ptr}, true
+	}
+	if ptr != 0 || deltas[0] != -1 {
+		return loopIdiom{}, false
+	}
+	var id loopIdiom
+	for _, off := range order {
+		if d := deltas[off]; off != 0 && d != 0 {
+			id.muls = append(id.muls, mulTerm{off: off, k: d})
+		}
+	}
+	return id, true
+}
+
+// ops 메서드는 루프를 대신하는 중간 표현 명령어를 반환합니다.
+func (id loopIdiom) ops(pc uint64) []irOp {
+	if id.scan != 0 {
+		return []irOp{{kind: irScan, n: id.scan, pc: pc}}
+	}
+	var ops []irOp
+	for _, m := range id.muls {
+		ops = append(ops, irOp{kind: irMul, off: m.off, n: m.k, pc: pc})
+	}
+	return append(ops, irOp{kind: irSet, pc: pc})
+}
@@ -0,0 +1,229 @@
+package mf
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const llvmHeader = `; MinFuck에서 생성된 LLVM IR입니다.
+; 테이프: %d셀 (32비트), 테이프 밖의 번지를 구하면 @fail이 종료 코드 2로 종료합니다.
+
+@tape = internal global [%d x i32] zeroinitializer
+@msg = private unnamed_addr constant [%d x i8] c"%s"
+
+declare i32 @getchar()
+declare i32 @putchar(i32)
+declare i32 @fflush(ptr)
+declare i64 @write(i32, ptr, i64)
+declare void @exit(i32) noreturn
+
+define internal void @fail() noreturn {
+  %%r = call i32 @fflush(ptr null)
+  %%w = call i64 @write(i32 2, ptr @msg, i64 %d)
+  call void @exit(i32 2)
+  unreachable
+}
+
+define i32 @main() {
+entry:
+  %%p = alloca i64
+  store i64 0, ptr %%p
+  br label %%init
+
+init:
+  %%i = phi i64 [ 0, %%entry ], [ %%i.next, %%init.body ]
+  %%init.done = icmp uge i64 %%i, %d
+  br i1 %%init.done, label %%start, label %%init.body
+
+init.body:
+  %%i.2 = shl i64 %%i, 1
+  %%i.cell = add i64 %%i.2, 8
+  %%i.ptr = getelementptr inbounds [%d x i32], ptr @tape, i64 0, i64 %%i.cell
+  %%i.next = add i64 %%i, 1
+  %%i.val = trunc i64 %%i.next to i32
+  store i32 %%i.val, ptr %%i.ptr
+  br label %%init
+
+start:
+`
+
+const llvmFooter = `  %flush = call i32 @fflush(ptr null)
+  ret i32 0
+
+oob:
+  call void @fail()
+  unreachable
+}
+`
+
+// llvmGen 구조체는 LLVM IR 함수 본문을 작성합니다.
+type llvmGen struct {
+	buf   *bytes.Buffer
+	cells uint64 // 테이프의 셀 수
+	tmp   int    // 다음 임시 값 번호
+	label int    // 다음 레이블 번호
+}
+
+// val 메서드는 새 임시 값 이름을 반환합니다.
+func (g *llvmGen) val() string {
+	g.tmp++
+	return fmt.Sprintf("%%t%d", g.tmp)
+}
+
+// emit 메서드는 명령어 한 줄을 작성합니다.
+func (g *llvmGen) emit(format string, args ...interface{}) {
+	g.buf.WriteString("  ")
+	fmt.Fprintf(g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+// block 메서드는 새 기본 블록을 시작합니다.
+func (g *llvmGen) block(name string) {
+	fmt.Fprintf(g.buf, "\n%s:\n", name)
+}
+
+// index 메서드는 메모리 포인터에서 off만큼 떨어진 셀의 번지를 구합니다. off가 0이 아니면 범위를 검사합니다.
+func (g *llvmGen) index(off int64) string {
+	p := g.val()
+	g.emit("%s = load i64, ptr %%p", p)
+	if off == 0 {
+		return p
+	}
+	np, oob := g.val(), g.val()
+	g.emit("%s = add i64 %s, %d", np, p, off)
+	g.emit("%s = icmp uge i64 %s, %d", oob, np, g.cells)
+	g.label++
+	ok := fmt.Sprintf("ok%d", g.label)
+	g.emit("br i1 %s, label %%oob, label %%%s", oob, ok)
+	g.block(ok)
+	return np
+}
+
+// cell 메서드는 메모리 포인터에서 off만큼 떨어진 셀의 주소를 구합니다.
+func (g *llvmGen) cell(off int64) string {
+	idx, ptr := g.index(off), g.val()
+	g.emit("%s = getelementptr inbounds [%d x i32], ptr @tape, i64 0, i64 %s", ptr, g.cells, idx)
+	return ptr
+}
+
+// move 메서드는 메모리 포인터를 n만큼 옮깁니다.
+func (g *llvmGen) move(n int64) {
+	g.emit("store i64 %s, ptr %%p", g.index(n))
+}
+
+// load 메서드는 현재 셀의 주소와 값을 구합니다.
+func (g *llvmGen) load() (ptr, v string) {
+	ptr, v = g.cell(0), g.val()
+	g.emit("%s = load i32, ptr %s", v, ptr)
+	return
+}
+
+// loop 메서드는 현재 셀이 0이 아닌 동안 반복하는 루프의 조건 블록을 작성하고 레이블 번호를 반환합니다.
+func (g *llvmGen) loop() int {
+	g.label++
+	n := g.label
+	g.emit("br label %%loop%d", n)
+	g.block(fmt.Sprintf("loop%d", n))
+	_, v := g.load()
+	c := g.val()
+	g.emit("%s = icmp ne i32 %s, 0", c, v)
+	g.emit("br i1 %s, label %%body%d, label %%end%d", c, n, n)
+	g.block(fmt.Sprintf("body%d", n))
+	return n
+}
+
+// end 메서드는 루프를 닫습니다.
+func (g *llvmGen) end(n int) {
+	g.emit("br label %%loop%d", n)
+	g.block(fmt.Sprintf("end%d", n))
+}
+
+/*
+ToLLVM 함수는 MinFuck 프로그램을 LLVM IR 텍스트 모듈(.ll)로 변환합니다.
+
+ 테이프는 32비트 셀의 전역 배열 @tape이며, 메모리 포인터는 main의 지역 변수 %p에 저장됩니다. (mem2reg로 레지스터가 됩니다)
+ 입출력은 getchar, putchar를 호출하며, 입력이 끝나면 셀은 0이 됩니다.
+ 연속된 +-><는 하나의 add가 되고, 확장 명령어와 +-<>만으로 이루어진 루프는 곱셈, 셋, 스캔으로 변환됩니다.
+ 0이 아닌 오프셋으로 번지를 구할 때마다(이동, 스캔, 곱셈의 대상 셀) 범위를 검사하며,
+ 벗어나면 @fail이 표준 오류에 메시지를 쓰고 exit(2)를 호출합니다. 프로그램 끝의 이동은 생성하지 않습니다.
+ 포인터 타입은 ptr(opaque pointer)을 사용합니다.
+*/
+func ToLLVM(fd FileData) (string, error) {
+	ops, err := buildIR(&fd)
+	if err != nil {
+		return "", err
+	}
+	if len(ops) > 0 && ops[len(ops)-1].kind == irMove {
+		ops = ops[:len(ops)-1]
+	}
+	const msg = "\n메모리 범위를 벗어났습니다\n"
+	cells := tapeSize(fd.memsize)
+	g := &llvmGen{buf: new(bytes.Buffer), cells: cells}
+	fmt.Fprintf(g.buf, llvmHeader, cells, cells, len(msg), llvmString(msg), len(msg), fd.memsize, cells)
+
+	var loops []int
+	for _, op := range ops {
+		switch op.kind {
+		case irAdd:
+			ptr, v := g.load()
+			s := g.val()
+			g.emit("%s = add i32 %s, %d", s, v, int32(op.n))
+			g.emit("store i32 %s, ptr %s", s, ptr)
+		case irMove:
+			g.move(op.n)
+		case irOpen:
+			loops = append(loops, g.loop())
+		case irClose:
+			g.end(loops[len(loops)-1])
+			loops = loops[:len(loops)-1]
+		case irOut:
+			_, v := g.load()
+			g.emit("%s = call i32 @putchar(i32 %s)", g.val(), v)
+		case irIn:
+			ptr := g.cell(0)
+			c, eof, v := g.val(), g.val(), g.val()
+			g.emit("%s = call i32 @getchar()", c)
+			g.emit("%s = icmp slt i32 %s, 0", eof, c)
+			g.emit("%s = select i1 %s, i32 0, i32 %s", v, eof, c)
+			g.emit("store i32 %s, ptr %s", v, ptr)
+		case irSet:
+			g.emit("store i32 %d, ptr %s", int32(op.n), g.cell(0))
+		case irMul:
+			// MinFuckVM과 같이 현재 셀이 0이면 대상 셀에 접근하지 않습니다.
+			_, v := g.load()
+			c := g.val()
+			g.label++
+			n := g.label
+			g.emit("%s = icmp ne i32 %s, 0", c, v)
+			g.emit("br i1 %s, label %%mul%d, label %%next%d", c, n, n)
+			g.block(fmt.Sprintf("mul%d", n))
+			t := g.cell(op.off)
+			tv, m, s := g.val(), g.val(), g.val()
+			g.emit("%s = load i32, ptr %s", tv, t)
+			g.emit("%s = mul i32 %s, %d", m, v, int32(op.n))
+			g.emit("%s = add i32 %s, %s", s, tv, m)
+			g.emit("store i32 %s, ptr %s", s, t)
+			g.emit("br label %%next%d", n)
+			g.block(fmt.Sprintf("next%d", n))
+		case irScan:
+			n := g.loop()
+			g.move(op.n)
+			g.end(n)
+		}
+	}
+	g.buf.WriteString(llvmFooter)
+	return g.buf.String(), nil
+}
+
+// llvmString 함수는 문자열을 LLVM IR의 c"..." 상수 형식으로 변환합니다.
+func llvmString(s string) string {
+	b := new(bytes.Buffer)
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= 0x20 && c < 0x7f && c != '"' && c != '\\' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(b, "\\%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,386 @@
+package mf
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// llInstr 구조체는 쉼표와 괄호를 공백으로 바꾸어 나눈 LLVM IR 명령어입니다.
+type llInstr struct {
+	def    string   // 정의하는 값 (없으면 빈 문자열)
+	fields []string // = 뒤의 필드
+	line   int
+}
+
+// llBlock 구조체는 기본 블록입니다.
+type llBlock struct {
+	name   string
+	instrs []llInstr
+}
+
+// llFunc 구조체는 함수 정의입니다.
+type llFunc struct {
+	blocks []llBlock
+	labels map[string]int
+}
+
+var llTerminators = map[string]bool{"br": true, "ret": true, "unreachable": true}
+
+var llOpcodes = map[string]bool{
+	"alloca": true, "load": true, "store": true, "getelementptr": true, "add": true, "shl": true,
+	"mul": true, "icmp": true, "select": true, "trunc": true, "phi": true, "call": true,
+	"br": true, "ret": true, "unreachable": true,
+}
+
+/*
+llCheck 함수는 ToLLVM이 생성한 모듈의 구조를 검사하고 함수들을 반환합니다.
+
+ 모든 전역 값은 정의되거나 선언되어야 하고, 함수의 모든 기본 블록은 정확히 하나의 종결 명령어로 끝나야 합니다.
+ 값은 한 번만 정의되어야 하며(SSA), 사용하는 값과 분기 대상 레이블은 모두 정의되어 있어야 합니다.
+*/
+func llCheck(ir string) (map[string]*llFunc, error) {
+	split := strings.NewReplacer(",", " ", "(", " ( ", ")", " ", "[", " ", "]", " ")
+	globals := map[string]bool{}
+	funcs := map[string]*llFunc{}
+	var (
+		fn   *llFunc
+		name string
+		uses [][2]string // 사용한 전역 값과 위치
+	)
+	for n, line := range strings.Split(ir, "\n") {
+		line = strings.TrimSpace(line)
+		pos := fmt.Sprintf("line %d", n+1)
+		switch {
+		case line == "" || strings.HasPrefix(line, ";"):
+		case fn == nil && strings.HasPrefix(line, "@"):
+			g := strings.Fields(line)[0]
+			if globals[g] {
+				return nil, fmt.Errorf("%s: %s redefined", pos, g)
+			}
+			globals[g] = true
+		case fn == nil && (strings.HasPrefix(line, "declare ") || strings.HasPrefix(line, "define ")):
+			f := strings.Fields(split.Replace(line))
+			name = ""
+			for i, s := range f {
+				if strings.HasPrefix(s, "@") && f[i+1] == "(" {
+					name = s
+				}
+			}
+			if name == "" || globals[name] {
+				return nil, fmt.Errorf("%s: bad function %q", pos, line)
+			}
+			globals[name] = true
+			if strings.HasPrefix(line, "define ") {
+				if !strings.HasSuffix(line, "{") {
+					return nil, fmt.Errorf("%s: missing {", pos)
+				}
+				fn = &llFunc{labels: map[string]int{}}
+				funcs[name] = fn
+			}
+		case fn == nil:
+			return nil, fmt.Errorf("%s: unexpected %q", pos, line)
+		case line == "}":
+			if err := fn.check(); err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			fn = nil
+		case strings.HasSuffix(line, ":"):
+			label := strings.TrimSuffix(line, ":")
+			if _, ok := fn.labels["%"+label]; ok {
+				return nil, fmt.Errorf("%s: label %s redefined", pos, label)
+			}
+			fn.labels["%"+label] = len(fn.blocks)
+			fn.blocks = append(fn.blocks, llBlock{name: "%" + label})
+		default:
+			if len(fn.blocks) == 0 {
+				fn.blocks = append(fn.blocks, llBlock{name: "%0"}) // 레이블 없는 첫 블록
+			}
+			in := llInstr{line: n + 1}
+			f := strings.Fields(split.Replace(line))
+			if len(f) > 2 && f[1] == "=" {
+				in.def, f = f[0], f[2:]
+			}
+			if !llOpcodes[f[0]] {
+				return nil, fmt.Errorf("%s: unknown opcode %s", pos, f[0])
+			}
+			in.fields = f
+			for _, s := range f {
+				if strings.HasPrefix(s, "@") {
+					uses = append(uses, [2]string{s, pos})
+				}
+			}
+			b := &fn.blocks[len(fn.blocks)-1]
+			if l := len(b.instrs); l > 0 && llTerminators[b.instrs[l-1].fields[0]] {
+				return nil, fmt.Errorf("%s: instruction after terminator", pos)
+			}
+			b.instrs = append(b.instrs, in)
+		}
+	}
+	if fn != nil {
+		return nil, errors.New("unterminated function")
+	}
+	for _, u := range uses {
+		if !globals[u[0]] {
+			return nil, fmt.Errorf("%s: undefined global %s", u[1], u[0])
+		}
+	}
+	return funcs, nil
+}
+
+// check 메서드는 함수의 기본 블록과 값의 정의, 사용을 검사합니다.
+func (fn *llFunc) check() error {
+	defs := map[string]bool{}
+	for _, b := range fn.blocks {
+		if len(b.instrs) == 0 || !llTerminators[b.instrs[len(b.instrs)-1].fields[0]] {
+			return fmt.Errorf("block %s does not end with terminator", b.name)
+		}
+		for _, in := range b.instrs {
+			if in.def == "" {
+				continue
+			}
+			if defs[in.def] {
+				return fmt.Errorf("line %d: %s redefined", in.line, in.def)
+			}
+			defs[in.def] = true
+		}
+	}
+	for _, b := range fn.blocks {
+		for _, in := range b.instrs {
+			for i, s := range in.fields {
+				if !strings.HasPrefix(s, "%") {
+					continue
+				}
+				label := in.fields[0] == "phi" && i%2 == 1 || i > 0 && in.fields[i-1] == "label"
+				if _, ok := fn.labels[s]; label && !ok {
+					return fmt.Errorf("line %d: undefined label %s", in.line, s)
+				} else if !label && !defs[s] {
+					return fmt.Errorf("line %d: undefined value %s", in.line, s)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// llRun 함수는 llCheck가 해석한 main 함수를 실행합니다. 테이프 밖을 가리키는 주소는 오류가 됩니다.
+func llRun(funcs map[string]*llFunc, cells uint64, input string) (string, int, error) {
+	var (
+		out   strings.Builder
+		tape  = make([]uint32, cells)
+		pvar  int64 // alloca로 할당된 메모리 포인터
+		vals  = map[string]int64{}
+		fn    = funcs["@main"]
+		prev  string
+		block = 0
+	)
+	get := func(s string) int64 {
+		if v, ok := vals[s]; ok {
+			return v
+		}
+		v, _ := strconv.ParseInt(s, 10, 64)
+		return v
+	}
+	norm := func(typ string, v int64) int64 {
+		if typ == "i32" {
+			return int64(int32(v))
+		}
+		return v
+	}
+	for steps := 0; steps < 1<<24; {
+		b := fn.blocks[block]
+		jump := ""
+		for _, in := range b.instrs {
+			steps++
+			f := in.fields
+			var v int64
+			switch f[0] {
+			case "alloca":
+				v = -1
+			case "load":
+				if f[3] == "%p" {
+					v = pvar
+				} else {
+					v = int64(int32(tape[get(f[3])]))
+				}
+			case "store":
+				if f[4] == "%p" {
+					pvar = get(f[2])
+				} else {
+					tape[get(f[4])] = uint32(get(f[2]))
+				}
+			case "getelementptr":
+				v = get(f[len(f)-1])
+				if v < 0 || uint64(v) >= cells {
+					return out.String(), 0, fmt.Errorf("line %d: out of bounds index %d", in.line, v)
+				}
+			case "add":
+				v = norm(f[1], get(f[2])+get(f[3]))
+			case "mul":
+				v = norm(f[1], get(f[2])*get(f[3]))
+			case "shl":
+				v = norm(f[1], get(f[2])<<uint(get(f[3])))
+			case "trunc":
+				v = norm(f[4], get(f[2]))
+			case "icmp":
+				a, c := get(f[3]), get(f[4])
+				var r bool
+				switch f[1] {
+				case "uge":
+					r = uint64(a) >= uint64(c)
+				case "ne":
+					r = a != c
+				case "slt":
+					r = a < c
+				default:
+					return "", 0, fmt.Errorf("line %d: unsupported predicate %s", in.line, f[1])
+				}
+				if r {
+					v = 1
+				}
+			case "select":
+				v = get(f[6])
+				if get(f[2]) != 0 {
+					v = get(f[4])
+				}
+			case "phi":
+				for i := 2; i+1 < len(f); i += 2 {
+					if f[i+1] == prev {
+						v = get(f[i])
+					}
+				}
+			case "call":
+				switch f[2] {
+				case "@getchar":
+					v = -1
+					if len(input) > 0 {
+						v, input = int64(input[0]), input[1:]
+					}
+				case "@putchar":
+					out.WriteByte(byte(get(f[5])))
+				case "@fail":
+					return out.String(), 2, nil
+				}
+			case "br":
+				jump = f[len(f)-1]
+				if f[1] == "i1" && get(f[2]) != 0 {
+					jump = f[4]
+				}
+			case "ret":
+				return out.String(), int(get(f[2])), nil
+			case "unreachable":
+				return out.String(), 0, fmt.Errorf("line %d: unreachable executed", in.line)
+			}
+			if in.def != "" {
+				vals[in.def] = v
+			}
+		}
+		prev, block = b.name, fn.labels[jump]
+	}
+	return out.String(), 0, errors.New("too many steps")
+}
+
+func TestToLLVM(t *testing.T) {
+	for n, test := range rtTestEntries {
+		for _, opts := range rtOptions[:3] {
+			fd, _ := FromBfCodeOpts(test.bf, opts)
+			expect, _ := runBackend(NewVM(fd), BackendInterp, test.in)
+
+			ir, err := ToLLVM(fd)
+			if err != nil {
+				t.Fatalf("Test #%d failed: %v", n+1, err)
+			}
+			funcs, err := llCheck(ir)
+			if err != nil {
+				t.Fatalf("Test #%d failed: malformed IR: %v\n%s", n+1, err, ir)
+			}
+			out, code, err := llRun(funcs, tapeSize(4096), test.in)
+			if err != nil || code != 0 || out != expect {
+				t.Errorf("Test #%d failed (%+v): got %q (exit %d, %v), VM %q", n+1, opts, out, code, err, expect)
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		bf   string
+		code int
+	}{
+		{bf: "+.<<+.", code: 2},
+		{bf: "+.<<"},
+		{bf: "+.-[->>>>>>+<<<<<<]"},
+	} {
+		fd, _ := FromBfCodeOpts(test.bf, BfOptions{Mem: 4})
+		ir, _ := ToLLVM(fd)
+		funcs, err := llCheck(ir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out, code, err := llRun(funcs, tapeSize(4), ""); out != "\x01" || code != test.code || err != nil {
+			t.Errorf("%s: got %q (exit %d, %v), expected exit %d", test.bf, out, code, err, test.code)
+		}
+	}
+
+	for _, code := range [][]byte{{0x40}, {0x50}} {
+		if _, err := ToLLVM(NewFileData(16, code)); err == nil {
+			t.Errorf("%x: expected error", code)
+		}
+	}
+}
+
+func TestLLCheck(t *testing.T) {
+	fd, _ := FromBfCodeOpts("+[.-]>.", BfOptions{Mem: 4})
+	ir, _ := ToLLVM(fd)
+	for _, broken := range []string{
+		strings.Replace(ir, "  ret i32 0\n", "", 1),                   // 종결 명령어 없음
+		strings.Replace(ir, "br label %loop", "br label %nowhere", 1), // 없는 레이블
+		strings.Replace(ir, "%t2 = ", "%t1 = ", 1),                    // SSA 위반
+		strings.Replace(ir, "declare i32 @putchar(i32)\n", "", 1),     // 선언 없음
+		strings.TrimSuffix(ir, "}\n"),
+	} {
+		if broken == ir {
+			t.Fatalf("test IR was not modified")
+		}
+		if _, err := llCheck(broken); err == nil {
+			t.Errorf("malformed IR should be rejected:\n%s", broken)
+		}
+	}
+}
+
+// TestToLLVMLli 테스트는 LLVM이 설치되어 있으면 lli로 IR을 실행해 봅니다.
+func TestToLLVMLli(t *testing.T) {
+	if _, err := exec.LookPath("lli"); err != nil || testing.Short() {
+		t.Skip("lli가 없습니다")
+	}
+	dir, err := ioutil.TempDir("", "mfllvm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "prog.ll")
+	for n, test := range rtTestEntries {
+		fd, _ := FromBfCodeOpts(test.bf, BfOptions{Mem: 4096, Optimize: true})
+		ir, _ := ToLLVM(fd)
+		if err := ioutil.WriteFile(name, []byte(ir), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var out []byte
+		// LLVM 14 이하에서는 opaque pointer를 명시적으로 켜야 합니다.
+		for _, args := range [][]string{{name}, {"-opaque-pointers", name}} {
+			cmd := exec.Command("lli", args...)
+			cmd.Stdin = strings.NewReader(test.in)
+			if out, err = cmd.Output(); err == nil {
+				break
+			}
+		}
+		if err != nil || string(out) != runBfr(test.bf, test.in) {
+			t.Errorf("Test #%d failed: got %q (%v)", n+1, out, err)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package mf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// codePageSize는 CodeSource에서 한 번에 읽어들이는 코드의 크기입니다.
+const codePageSize = 1 << 16
+
+// CodeSource 인터페이스는 메모리에 복사하지 않고 코드를 읽을 수 있는 원천을 정의합니다.
+// *io.SectionReader가 이 인터페이스를 구현합니다.
+//
+// 프로그램 카운터는 64비트 니블 오프셋이므로 코드의 크기는 제한되지 않습니다.
+// 다만 섹션 포맷의 CODE 섹션은 길이를 32비트로 기록하므로, 4GiB 이상의 코드는 기존 포맷으로만 기록할 수 있습니다.
+type CodeSource interface {
+	io.ReaderAt
+	Size() int64
+}
+
+// TruncatedError 구조체는 MinFuck 파일이 예상보다 일찍 끝났음을 나타냅니다.
+type TruncatedError struct {
+	Part   string // 잘린 부분 (magic, memsize, 섹션 헤더, 섹션 ID)
+	Offset int64  // 잘린 부분이 시작하는 파일 오프셋
+	Want   int64  // 읽어야 하는 바이트 수
+	Got    int64  // 실제로 읽은 바이트 수
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("MinFuck 파일이 잘렸습니다: 오프셋 %d의 %s 부분 %d바이트 중 %d바이트만 읽었습니다",
+		e.Offset, e.Part, e.Want, e.Got)
+}
+
+// Unwrap 메서드는 errors.Is(err, io.ErrUnexpectedEOF)가 성립하도록 합니다.
+func (e *TruncatedError) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
+
+// truncated 함수는 읽기 도중 발생한 EOF를 TruncatedError로 변환합니다.
+func truncated(part string, off, want, got int64, err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return &TruncatedError{Part: part, Offset: off, Want: want, Got: got}
+	}
+	return err
+}
+
+// checkMagic 함수는 Magic Byte를 검사하고 섹션 포맷 여부를 반환합니다.
+func checkMagic(magic []byte) (sectioned bool, err error) {
+	switch string(magic) {
+	case mfMagic:
+		return false, nil
+	case mfMagicSection:
+		return true, nil
+	}
+	return false, fmt.Errorf("잘못된 MinFuck Magic: 0x%s", hex.EncodeToString(magic))
+}
+
+// ReadFile 함수는 주어진 파일로부터 정보를 읽어 MinFuck 파일 메타데이터로 변환합니다.
+// 섹션 포맷 파일은 섹션 단위로 읽어들이므로, 잘린 섹션이 있으면 TruncatedError를 반환합니다.
+// 압축된 코드(CODZ 섹션)는 압축을 풀어 읽어들입니다.
+func ReadFile(f io.Reader) (FileData, error) {
+	var hdr [8]byte
+	if n, err := io.ReadFull(f, hdr[:4]); err != nil {
+		return FileData{}, truncated("magic", 0, 4, int64(n), err)
+	}
+	sectioned, err := checkMagic(hdr[:4])
+	if err != nil {
+		return FileData{}, err
+	}
+	if n, err := io.ReadFull(f, hdr[4:]); err != nil {
+		return FileData{}, truncated("memsize", 4, 4, int64(n), err)
+	}
+
+	fd := FileData{memsize: BytesU32(hdr[4:])}
+	if !sectioned {
+		code, err := ioutil.ReadAll(f)
+		if err != nil {
+			return FileData{}, err
+		}
+		fd.code = code
+		return fd, nil
+	}
+
+	off := int64(len(hdr))
+	for {
+		var sh [8]byte
+		n, err := io.ReadFull(f, sh[:])
+		if err == io.EOF {
+			return fd, nil
+		} else if err != nil {
+			return FileData{}, truncated("섹션 헤더", off, 8, int64(n), err)
+		}
+		id, size := string(sh[:4]), int64(BytesU32(sh[4:]))
+		off += 8
+
+		// 잘못된 길이로 인해 거대한 버퍼를 미리 할당하지 않도록 CopyN을 사용합니다.
+		buf := new(bytes.Buffer)
+		if n, err := io.CopyN(buf, f, size); err != nil {
+			return FileData{}, truncated(id, off, size, n, err)
+		}
+		if err := fd.putSection(id, buf.Bytes()); err != nil {
+			return FileData{}, err
+		}
+		off += size
+	}
+}
+
+// OpenFile 함수는 io.ReaderAt으로부터 MinFuck 파일을 엽니다.
+// 코드는 메모리에 복사되지 않으며, VM은 실행 중에 필요한 부분만 읽어들입니다.
+// 코드 이외의 섹션과 압축된 코드는 메모리로 읽어들입니다.
+func OpenFile(r io.ReaderAt, size int64) (FileData, error) {
+	var hdr [8]byte
+	if n, err := readAt(r, hdr[:4], 0, size); err != nil {
+		return FileData{}, truncated("magic", 0, 4, int64(n), err)
+	}
+	sectioned, err := checkMagic(hdr[:4])
+	if err != nil {
+		return FileData{}, err
+	}
+	if n, err := readAt(r, hdr[4:], 4, size); err != nil {
+		return FileData{}, truncated("memsize", 4, 4, int64(n), err)
+	}
+
+	fd := FileData{memsize: BytesU32(hdr[4:])}
+	off := int64(len(hdr))
+	if !sectioned {
+		fd.setCodeAt(r, off, size-off)
+		return fd, nil
+	}
+
+	for off < size {
+		var sh [8]byte
+		if n, err := readAt(r, sh[:], off, size); err != nil {
+			return FileData{}, truncated("섹션 헤더", off, 8, int64(n), err)
+		}
+		id, ssize := string(sh[:4]), int64(BytesU32(sh[4:]))
+		off += 8
+		if off+ssize > size {
+			return FileData{}, truncated(id, off, ssize, size-off, io.ErrUnexpectedEOF)
+		}
+		if id == SectionCode {
+			fd.setCodeAt(r, off, ssize)
+		} else {
+			data := make([]byte, ssize)
+			if n, err := readAt(r, data, off, size); err != nil {
+				return FileData{}, truncated(id, off, ssize, int64(n), err)
+			}
+			if err := fd.putSection(id, data); err != nil {
+				return FileData{}, err
+			}
+		}
+		off += ssize
+	}
+	return fd, nil
+}
+
+// readAt 함수는 size를 넘지 않는 범위에서 len(b)바이트를 모두 읽습니다.
+func readAt(r io.ReaderAt, b []byte, off, size int64) (int, error) {
+	if rem := size - off; rem < int64(len(b)) {
+		if rem < 0 {
+			rem = 0
+		}
+		n, err := r.ReadAt(b[:rem], off)
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return n, err
+	}
+	n, err := r.ReadAt(b, off)
+	if n == len(b) {
+		return n, nil
+	}
+	if err == nil || err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// byteSlicer 인터페이스는 메모리에 매핑된 파일처럼 복사 없이 바이트 슬라이스를 제공하는 원천입니다.
+type byteSlicer interface {
+	slice(off, n int64) []byte
+}
+
+// setCodeAt 메서드는 코드 위치를 기록합니다. 원천이 byteSlicer이면 복사 없이 슬라이스를 사용합니다.
+func (f *FileData) setCodeAt(r io.ReaderAt, off, n int64) {
+	if bs, ok := r.(byteSlicer); ok {
+		f.code, f.src = bs.slice(off, n), nil
+		return
+	}
+	f.code, f.src = nil, io.NewSectionReader(r, off, n)
+}
+
+// putSection 메서드는 파일에서 읽어들인 섹션을 저장합니다. 압축된 코드는 압축을 풀어 저장합니다.
+func (f *FileData) putSection(id string, data []byte) error {
+	switch id {
+	case SectionCode:
+		f.code, f.src = data, nil
+	case SectionCompressedCode:
+		code, err := inflateCode(data)
+		if err != nil {
+			return err
+		}
+		f.code, f.src = code, nil
+		f.compress, f.zsize = true, int64(len(data))
+	default:
+		f.setSection(id, data)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package mf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+)
+
+func sectionedFile(memsize uint32, code []byte, extra ...string) []byte {
+	fd := NewFileData(memsize, code)
+	for i := 0; i+1 < len(extra); i += 2 {
+		fd.SetSection(extra[i], []byte(extra[i+1]))
+	}
+	return []byte(fd.String())
+}
+
+var truncTestEntries = []struct {
+	file   []byte
+	part   string
+	offset int64
+	want   int64
+	got    int64
+}{
+	{ // Test #1: empty file
+		file: []byte{},
+		part: "magic", offset: 0, want: 4, got: 0,
+	},
+	{ // Test #2: truncated magic
+		file: []byte{0xff, 0x6d},
+		part: "magic", offset: 0, want: 4, got: 2,
+	},
+	{ // Test #3: truncated memsize
+		file: []byte(mfMagic + "\x00\x00\x01"),
+		part: "memsize", offset: 4, want: 4, got: 3,
+	},
+	{ // Test #4: truncated section header
+		file: sectionedFile(16, []byte{0x01}, "NOTE", "hello")[:12],
+		part: "섹션 헤더", offset: 8, want: 8, got: 4,
+	},
+	{ // Test #5: truncated code section
+		file: sectionedFile(16, []byte{0x01, 0x23, 0x45}, "NOTE", "hello")[:18],
+		part: SectionCode, offset: 16, want: 3, got: 2,
+	},
+	{ // Test #6: truncated extra section
+		file: sectionedFile(16, []byte{0x01}, "NOTE", "hello")[:28],
+		part: "NOTE", offset: 25, want: 5, got: 3,
+	},
+}
+
+func TestTruncatedFile(t *testing.T) {
+	for n, test := range truncTestEntries {
+		_, rerr := ReadFile(iotest.OneByteReader(bytes.NewReader(test.file)))
+		_, oerr := OpenFile(bytes.NewReader(test.file), int64(len(test.file)))
+		for _, err := range []error{rerr, oerr} {
+			var te *TruncatedError
+			if !errors.As(err, &te) {
+				t.Errorf("Test #%d failed: expected TruncatedError, got %v", n+1, err)
+				continue
+			}
+			if te.Part != test.part || te.Offset != test.offset || te.Want != test.want || te.Got != test.got {
+				t.Errorf("Test #%d failed: got %+v", n+1, *te)
+			}
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("Test #%d failed: error should wrap io.ErrUnexpectedEOF", n+1)
+			}
+		}
+	}
+}
+
+func TestShortReads(t *testing.T) {
+	for _, file := range [][]byte{
+		append([]byte(mfMagic+"\x00\x00\x01\x00"), 0x01, 0x23),
+		sectionedFile(256, []byte{0x01, 0x23}, SectionSourceMap, "\x00"),
+	} {
+		fd, err := ReadFile(iotest.OneByteReader(bytes.NewReader(file)))
+		if err != nil {
+			t.Errorf("ReadFile should not fail on short reads: %v", err)
+			continue
+		}
+		if fd.MemSize() != 256 || !bytes.Equal(codeOf(t, &fd), []byte{0x01, 0x23}) {
+			t.Errorf("unexpected file data: memsize %d, code %x", fd.MemSize(), codeOf(t, &fd))
+		}
+	}
+}
+
+// countingReaderAt 구조체는 ReadAt 호출 횟수를 셉니다.
+type countingReaderAt struct {
+	r     io.ReaderAt
+	reads int
+}
+
+func (c *countingReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	c.reads++
+	return c.r.ReadAt(b, off)
+}
+
+func TestOpenFileStreaming(t *testing.T) {
+	for _, sectioned := range []bool{false, true} {
+		fd, _ := FromBfCodeOpts(hwBfCode, BfOptions{Mem: 64, SourceMap: sectioned})
+		file := []byte(fd.String())
+
+		cr := &countingReaderAt{r: bytes.NewReader(file)}
+		ofd, err := OpenFile(cr, int64(len(file)))
+		if err != nil {
+			t.Fatalf("OpenFile failed: %v", err)
+		}
+		if ofd.CodeSize() != int64(len(codeOf(t, &fd))) {
+			t.Errorf("code size mismatch: got %d, expected %d", ofd.CodeSize(), len(codeOf(t, &fd)))
+		}
+		if _, ok := ofd.Section(SectionSourceMap); ok != sectioned {
+			t.Errorf("source map section presence mismatch")
+		}
+
+		vm := NewVM(ofd)
+		if vm.Code != nil {
+			t.Fatalf("VM should read code from CodeSource")
+		}
+		reads := cr.reads
+		for i := 0; i < 100; i++ {
+			if err := vm.Process(); err != nil {
+				t.Fatalf("Process failed: %v", err)
+			}
+		}
+		if cr.reads-reads != 1 {
+			t.Errorf("VM should read a single code page, got %d reads", cr.reads-reads)
+		}
+
+		mvm := NewVM(fd)
+		for i := 0; i < 100; i++ {
+			mvm.Process()
+		}
+		if vm.PC() != mvm.PC() || !equalMem(vm.Mem, mvm.Mem) {
+			t.Errorf("streaming VM state differs from in-memory VM")
+		}
+	}
+}
+
+var errReadAt = errors.New("read failed")
+
+// failingReaderAt 구조체는 limit 바이트 이후를 읽으면 errReadAt을 반환하는 io.ReaderAt입니다.
+type failingReaderAt struct {
+	r     io.ReaderAt
+	limit int64
+}
+
+func (f *failingReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	if off+int64(len(b)) > f.limit {
+		return 0, errReadAt
+	}
+	return f.r.ReadAt(b, off)
+}
+
+func TestCodeReadError(t *testing.T) {
+	fd, _ := FromBfCodeOpts(hwBfCode, BfOptions{Mem: 64})
+	file := []byte(fd.String())
+	ofd, err := OpenFile(&failingReaderAt{r: bytes.NewReader(file), limit: 8}, int64(len(file)))
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	if _, err := ofd.Code(); err != errReadAt {
+		t.Errorf("Code should return the read error, got %v", err)
+	}
+	if _, err := ofd.WriteTo(ioutil.Discard); err != errReadAt {
+		t.Errorf("WriteTo should return the read error, got %v", err)
+	}
+	if s := ofd.String(); s != "" {
+		t.Errorf("String should be empty, got %d bytes", len(s))
+	}
+}
+
+// sparseCode 구조체는 head와 tail 사이가 0으로 채워진 size바이트의 코드입니다.
+type sparseCode struct {
+	head, tail []byte
+	size       int64
+}
+
+func (s *sparseCode) Size() int64 {
+	return s.size
+}
+
+func (s *sparseCode) ReadAt(b []byte, off int64) (int, error) {
+	for i := range b {
+		switch o := off + int64(i); {
+		case o >= s.size:
+			return i, io.EOF
+		case o < int64(len(s.head)):
+			b[i] = s.head[o]
+		case o >= s.size-int64(len(s.tail)):
+			b[i] = s.tail[o-(s.size-int64(len(s.tail)))]
+		default:
+			b[i] = 0
+		}
+	}
+	return len(b), nil
+}
+
+func TestLargeCode(t *testing.T) {
+	// 압축된 [로 2GiB 이후의 니블 오프셋에 점프하여 +.를 실행합니다.
+	target := uint64(5) << 30
+	nw := new(NibbleWriter)
+	nw.Put(8 | 4)
+	for i := jumpNibbles - 1; i >= 0; i-- {
+		nw.Put(byte(target >> (4 * uint(i)) & 0xf))
+	}
+	src := &sparseCode{head: nw.Nibbles, tail: []byte{0x06}, size: int64(target/2) + 1}
+
+	vm := &MinFuckVM{Src: src, Mem: make([]uint32, 16)}
+	out, err := runBackend(vm, BackendInterp, "")
+	if err != nil || out != "\x01" || vm.PC() != target+2 {
+		t.Errorf("got output %q, pc %d (%v), expected pc %d", out, vm.PC(), err, target+2)
+	}
+}
+
+func equalMem(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMapFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mftest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fd, _ := FromBfCodeOpts(hwBfCode, BfOptions{Mem: 64, SourceMap: true})
+	name := filepath.Join(dir, "hw.mf")
+	if err := ioutil.WriteFile(name, []byte(fd.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mfd, c, err := MapFile(name)
+	if err != nil {
+		t.Fatalf("MapFile failed: %v", err)
+	}
+	defer c.Close()
+	if !bytes.Equal(codeOf(t, &mfd), codeOf(t, &fd)) {
+		t.Errorf("mapped code mismatch")
+	}
+	if sm, err := mfd.SourceMap(); err != nil || sm == nil {
+		t.Errorf("mapped source map missing: %v", err)
+	}
+
+	empty := filepath.Join(dir, "empty.mf")
+	ioutil.WriteFile(empty, nil, 0644)
+	var te *TruncatedError
+	if _, _, err := MapFile(empty); !errors.As(err, &te) {
+		t.Errorf("empty file should return TruncatedError, got %v", err)
+	}
+}
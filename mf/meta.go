@@ -0,0 +1,117 @@
+package mf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// SectionMeta는 메타데이터 섹션의 ID입니다.
+const SectionMeta = "META"
+
+// 자주 쓰이는 메타데이터 키입니다.
+const (
+	MetaName      = "name"       // 프로그램 이름
+	MetaAuthor    = "author"     // 작성자
+	MetaSource    = "source"     // 원본 소스 파일 이름
+	MetaCompiler  = "compiler"   // 프로그램을 생성한 컴파일러와 버전
+	MetaBuildTime = "build-time" // 생성 시각 (RFC 3339)
+	MetaCellWidth = "cell-width" // 권장 셀 크기 (비트)
+	MetaEOF       = "eof"        // 권장 EOF 처리 방식
+	MetaTape      = "tape"       // 권장 테이프 처리 방식
+)
+
+// MetaEntry 구조체는 메타데이터 키/값 쌍입니다.
+type MetaEntry struct {
+	Key   string
+	Value string
+}
+
+/*
+Metadata 타입은 META 섹션에 저장되는 프로그램 메타데이터입니다. 키의 순서는 유지됩니다.
+
+ 바이너리 포맷(META 섹션)
+
+ 각 키/값 쌍마다 키의 길이(uvarint), 키, 값의 길이(uvarint), 값을 차례로 기록합니다.
+*/
+type Metadata []MetaEntry
+
+// Get 메서드는 주어진 키의 값을 반환합니다.
+func (m Metadata) Get(key string) (string, bool) {
+	for _, e := range m {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set 메서드는 주어진 키의 값을 설정합니다. 키가 이미 있으면 값을 교체합니다.
+func (m *Metadata) Set(key, value string) {
+	for i, e := range *m {
+		if e.Key == key {
+			(*m)[i].Value = value
+			return
+		}
+	}
+	*m = append(*m, MetaEntry{Key: key, Value: value})
+}
+
+// MarshalBinary 메서드는 encoding.BinaryMarshaler 인터페이스를 구현합니다.
+func (m Metadata) MarshalBinary() ([]byte, error) {
+	var b []byte
+	for _, e := range m {
+		b = binary.AppendUvarint(b, uint64(len(e.Key)))
+		b = append(b, e.Key...)
+		b = binary.AppendUvarint(b, uint64(len(e.Value)))
+		b = append(b, e.Value...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary 메서드는 encoding.BinaryUnmarshaler 인터페이스를 구현합니다.
+func (m *Metadata) UnmarshalBinary(b []byte) error {
+	next := func() (string, error) {
+		l, n := binary.Uvarint(b)
+		if n <= 0 || l > uint64(len(b)-n) {
+			return "", io.ErrUnexpectedEOF
+		}
+		s := string(b[n : n+int(l)])
+		b = b[n+int(l):]
+		return s, nil
+	}
+
+	*m = nil
+	for len(b) > 0 {
+		k, err := next()
+		if err != nil {
+			return fmt.Errorf("메타데이터를 읽는 중 오류: %v", err)
+		}
+		v, err := next()
+		if err != nil {
+			return fmt.Errorf("메타데이터 %q를 읽는 중 오류: %v", k, err)
+		}
+		m.Set(k, v)
+	}
+	return nil
+}
+
+// Metadata 메서드는 META 섹션에 저장된 메타데이터를 반환합니다.
+// 메타데이터가 없으면 nil을 반환합니다.
+func (f *FileData) Metadata() (Metadata, error) {
+	b, ok := f.Section(SectionMeta)
+	if !ok {
+		return nil, nil
+	}
+	var m Metadata
+	if err := m.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SetMetadata 메서드는 메타데이터를 META 섹션에 기록합니다.
+func (f *FileData) SetMetadata(m Metadata) {
+	b, _ := m.MarshalBinary()
+	f.setSection(SectionMeta, b)
+}
@@ -0,0 +1,43 @@
+package mf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	var m Metadata
+	m.Set(MetaName, "hello")
+	m.Set(MetaAuthor, "cr0sh")
+	m.Set(MetaCellWidth, "32")
+	m.Set(MetaName, "hello world")
+	if v, ok := m.Get(MetaName); !ok || v != "hello world" {
+		t.Errorf("Get(%q) = %q, %v", MetaName, v, ok)
+	}
+	if _, ok := m.Get(MetaEOF); ok {
+		t.Errorf("Get(%q) should fail", MetaEOF)
+	}
+
+	fd, _ := FromBfCodeOpts(hwBfCode, BfOptions{Mem: 64, Meta: m})
+	rfd, err := ReadFile(bytes.NewBufferString(fd.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm, err := rfd.Metadata()
+	if err != nil {
+		t.Fatalf("Metadata failed: %v", err)
+	}
+	expect := Metadata{{MetaName, "hello world"}, {MetaAuthor, "cr0sh"}, {MetaCellWidth, "32"}}
+	if !reflect.DeepEqual(rm, expect) {
+		t.Errorf("metadata mismatch:\ngot      %v\nexpected %v", rm, expect)
+	}
+
+	b, _ := expect.MarshalBinary()
+	for i := 1; i < len(b); i++ {
+		var tm Metadata
+		if err := tm.UnmarshalBinary(b[:i]); err == nil && len(tm) == len(expect) {
+			t.Errorf("truncated metadata (%d bytes) should not decode fully", i)
+		}
+	}
+}
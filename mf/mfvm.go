@@ -2,7 +2,7 @@ package mf
 
 import (
 	"bytes"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +10,15 @@ import (
 
 const mfMagic = "\xff\x6d\x66\xfd"
 
+// mfMagicSection은 섹션 포맷을 사용하는 MinFuck 파일의 Magic Byte입니다.
+const mfMagicSection = "\xff\x6d\x66\xfe"
+
+// 섹션 포맷에서 사용하는 섹션 ID입니다.
+const (
+	SectionCode      = "CODE" // MinFuck 코드
+	SectionSourceMap = "SMAP" // Brainfuck 소스맵
+)
+
 /*
 FileData 구조체는 MinFuck 소스 코드의 메타데이터를 정의합니다.
 
@@ -17,48 +26,180 @@ FileData 구조체는 MinFuck 소스 코드의 메타데이터를 정의합니
 
  .mf 파일의 첫 4바이트는 Magic Byte(\xff\x6d\x66\xfd)입니다.
  다음 4바이트에 부호 없는 32비트 정수형으로 MinFuck VM에서 접근 가능한 최대 메모리 번지를 지정합니다.
- (단, 실제 OS에서는 최소 해당 값 * 32 + 24바이트 이상을 할당합니다.)
+ (단, 실제 OS에서는 최소 해당 값 * 8 + 32바이트 이상을 할당합니다.)
  다음 4바이트에는 부호 없는 32비트 정수형으로 코드의 크기를 명시합니다.
+
+ 섹션 포맷
+
+ Magic Byte가 \xff\x6d\x66\xfe인 경우, 메모리 번지 다음에는 섹션이 파일 끝까지 이어집니다.
+ 각 섹션은 4바이트 ASCII ID, 부호 없는 32비트 정수형 길이, 그리고 데이터로 이루어집니다.
+ 코드는 CODE 섹션 또는 DEFLATE로 압축된 CODZ 섹션에 저장되며, 추가 섹션이 없는 파일은 기존 포맷으로 기록됩니다.
 */
 type FileData struct {
-	memsize uint32
-	code    []byte
+	memsize  uint32
+	code     []byte
+	src      *io.SectionReader // code 대신 사용되는 코드 원천 (OpenFile)
+	compress bool              // 코드를 CODZ 섹션에 압축하여 기록할지 여부
+	zsize    int64             // 압축된 코드의 크기
+	sections []section
+}
+
+type section struct {
+	id   string
+	data []byte
+}
+
+// NewFileData 함수는 주어진 메모리 번지 제한과 코드로 FileData를 생성합니다.
+func NewFileData(memsize uint32, code []byte) FileData {
+	return FileData{memsize: memsize, code: code}
+}
+
+// MemSize 메서드는 VM에서 접근 가능한 최대 메모리 번지를 반환합니다.
+func (f *FileData) MemSize() uint32 {
+	return f.memsize
 }
 
-// ReadFile 함수는 주어진 파일로부터 정보를 읽어 MinFuck 파일 메타데이터로 변환합니다.
-func ReadFile(f io.Reader) (FileData, error) {
-	magic := make([]byte, 4)
-	if _, err := f.Read(magic); err != nil {
-		return FileData{}, err
+// Code 메서드는 MinFuck 코드를 반환합니다.
+// OpenFile로 연 파일이면 코드 전체를 메모리로 읽어들이므로, 큰 프로그램에는 CodeSource를 사용해야 합니다.
+// 코드를 읽는 중 오류가 발생하면 오류를 반환합니다.
+func (f *FileData) Code() ([]byte, error) {
+	if f.code == nil && f.src != nil {
+		b := make([]byte, f.src.Size())
+		if _, err := io.ReadFull(io.NewSectionReader(f.src, 0, f.src.Size()), b); err != nil {
+			return nil, err
+		}
+		return b, nil
 	}
-	if !bytes.Equal(magic, []byte(mfMagic)) {
-		return FileData{}, fmt.Errorf("잘못된 MinFuck Magic: 0x" + hex.EncodeToString(magic))
+	return f.code, nil
+}
+
+// CodeSize 메서드는 코드의 크기를 바이트 단위로 반환합니다.
+func (f *FileData) CodeSize() int64 {
+	if f.code == nil && f.src != nil {
+		return f.src.Size()
 	}
+	return int64(len(f.code))
+}
 
-	membuf := make([]byte, 4)
-	if _, err := f.Read(membuf); err != nil {
-		return FileData{}, err
+// CodeSource 메서드는 코드를 복사하지 않고 읽을 수 있는 CodeSource를 반환합니다.
+func (f *FileData) CodeSource() CodeSource {
+	if f.code == nil && f.src != nil {
+		return f.src
 	}
+	return io.NewSectionReader(bytes.NewReader(f.code), 0, int64(len(f.code)))
+}
 
-	buf := new(bytes.Buffer)
-	if _, err := io.Copy(buf, f); err != nil {
-		return FileData{}, err
+// Section 메서드는 주어진 ID의 섹션 데이터를 반환합니다.
+func (f *FileData) Section(id string) ([]byte, bool) {
+	for _, s := range f.sections {
+		if s.id == id {
+			return s.data, true
+		}
 	}
+	return nil, false
+}
 
-	return FileData{
-		memsize: uint32(BytesU32(membuf)),
-		code:    buf.Bytes(),
-	}, nil
+// Sections 메서드는 CODE 섹션을 제외한 섹션 ID를 파일에 기록되는 순서대로 반환합니다.
+func (f *FileData) Sections() []string {
+	ids := make([]string, len(f.sections))
+	for i, s := range f.sections {
+		ids[i] = s.id
+	}
+	return ids
+}
+
+// SetSection 메서드는 주어진 ID의 섹션을 추가하거나 교체합니다.
+// ID는 4바이트여야 하며, 그렇지 않으면 섹션을 추가하지 않고 오류를 반환합니다.
+func (f *FileData) SetSection(id string, data []byte) error {
+	if len(id) != 4 {
+		return fmt.Errorf("섹션 ID는 4바이트여야 합니다: %q", id)
+	}
+	f.setSection(id, data)
+	return nil
+}
+
+// setSection 메서드는 ID를 검사하지 않고 섹션을 추가하거나 교체합니다.
+func (f *FileData) setSection(id string, data []byte) {
+	for i, s := range f.sections {
+		if s.id == id {
+			f.sections[i].data = data
+			return
+		}
+	}
+	f.sections = append(f.sections, section{id: id, data: data})
+}
+
+// RemoveSection 메서드는 주어진 ID의 섹션을 제거합니다.
+func (f *FileData) RemoveSection(id string) {
+	for i, s := range f.sections {
+		if s.id == id {
+			f.sections = append(f.sections[:i:i], f.sections[i+1:]...)
+			return
+		}
+	}
+}
+
+// SourceMap 메서드는 SMAP 섹션에 저장된 소스맵을 반환합니다.
+// 소스맵이 없으면 nil을 반환합니다.
+func (f *FileData) SourceMap() (*SourceMap, error) {
+	b, ok := f.Section(SectionSourceMap)
+	if !ok {
+		return nil, nil
+	}
+	sm := new(SourceMap)
+	if err := sm.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return sm, nil
 }
 
 // String 메서드는 FileData를 string으로 변환합니다.
+// 코드를 읽을 수 없으면 빈 문자열을 반환하므로, OpenFile로 연 파일은 WriteTo로 기록하는 편이 좋습니다.
 func (f *FileData) String() string {
-	buf := bytes.NewBuffer([]byte(mfMagic))
-	buf.Write(U32Bytes(f.memsize))
-	buf.Write(f.code)
+	buf := new(bytes.Buffer)
+	if _, err := f.WriteTo(buf); err != nil {
+		return ""
+	}
 	return buf.String()
 }
 
+// WriteTo 메서드는 io.WriterTo 인터페이스를 구현합니다. 코드를 읽는 중 발생한 오류도 반환합니다.
+func (f *FileData) WriteTo(w io.Writer) (int64, error) {
+	code, err := f.Code()
+	if err != nil {
+		return 0, err
+	}
+	if len(f.sections) == 0 && !f.compress {
+		buf := bytes.NewBuffer([]byte(mfMagic))
+		buf.Write(U32Bytes(f.memsize))
+		buf.Write(code)
+		return buf.WriteTo(w)
+	}
+
+	if int64(len(code)) > maxCodeSize {
+		return 0, fmt.Errorf("코드가 너무 커서 섹션 포맷으로 기록할 수 없습니다: %d바이트", len(code))
+	}
+	buf := bytes.NewBuffer([]byte(mfMagicSection))
+	buf.Write(U32Bytes(f.memsize))
+	if f.compress {
+		z := deflateCode(code)
+		f.zsize = int64(len(z))
+		writeSection(buf, SectionCompressedCode, z)
+	} else {
+		writeSection(buf, SectionCode, code)
+	}
+	for _, s := range f.sections {
+		writeSection(buf, s.id, s.data)
+	}
+	return buf.WriteTo(w)
+}
+
+func writeSection(buf *bytes.Buffer, id string, data []byte) {
+	buf.WriteString(id)
+	buf.Write(U32Bytes(uint32(len(data))))
+	buf.Write(data)
+}
+
 /*
 MinFuckVM 구조체는 MinFuck 코드를 구동하기 위한 가상 머신(VM) 환경을 정의합니다.
 
@@ -79,22 +220,32 @@ MinFuckVM 구조체는 MinFuck 코드를 구동하기 위한 가상 머신(VM) 
  7: Brainfuck의 ,
 
  니블코드의 첫 비트가 1인 경우, 다음의 8니블(4바이트)은 해당 코드를 반복하는 횟수를 표시합니다.
- 타입은 부호 없는 32비트 정수형입니다.
+ 타입은 부호 없는 32비트 정수형입니다. (EncodingV2의 가변 길이 반복 횟수는 Encoding을 참고하십시오.)
+ 단, 압축된 [ ]는 다음의 16니블에 빅 엔디언으로 점프할 니블 오프셋을 기록합니다.
+ [는 현재 셀이 0이면, ]는 현재 셀이 0이 아니면 해당 오프셋으로 점프합니다.
 
 TODO: 테스트 케이스 추가(HelloWorld)
 */
 type MinFuckVM struct {
-	Code []byte
-	Mem  []uint32
-	pc   uint32 // Program counter, 'nibble' offset
-	pcc  uint32 // Program counter('compressed')
-	inc  bool   // In Compressed area (should increment/decrement pcc instead of pc)
-	mp   uint32 // Memory offset
-	bs   uint32 // Braces stack
-	bt   byte   // Braces status; 0: nothing, 1: searching ']', 2: searching '['
-	m32  bool   // Use 32-bit value for [] operations (false = BF compatiable)
-	In   io.Reader
-	Out  io.Writer
+	Code         []byte
+	Src          CodeSource // Code가 nil일 때 사용할 코드 원천
+	Encoding     Encoding   // 코드 인코딩 버전 (0이면 EncodingV1)
+	Backend      Backend    // Run이 코드를 실행하는 방식 (0이면 BackendInterp)
+	MaxCallDepth int        // pbrain 프로시저 호출 스택의 최대 깊이 (0이면 DefaultCallDepth)
+	Mem          []uint32
+	Scheduler    Scheduler  // fork로 만든 스레드를 실행하는 방식 (0이면 SchedRoundRobin)
+	Quantum      int        // 라운드 로빈에서 스레드를 바꾸기 전에 실행하는 명령어 수 (0이면 1)
+	thread                  // 실행 중인 스레드의 레지스터
+	threads      []thread   // 실행을 기다리는 다른 스레드 (라운드 로빈)
+	slice        int        // 실행 중인 스레드에 남은 명령어 수
+	group        *forkGroup // 고루틴으로 실행하는 스레드 그룹
+	m32          bool       // Use 32-bit value for [] operations (false = BF compatiable)
+	In           io.Reader
+	Out          io.Writer
+	Debug        io.Writer // # 디버그 덤프를 기록할 곳 (nil이면 os.Stderr)
+
+	page    []byte // Src에서 읽어들인 코드 페이지
+	pageOff int64  // page의 시작 오프셋
 }
 
 // VMFile 함수는 주어진 MinFuck 소스 스트림으로부터 VM을 생성해 반환합니다.
@@ -103,23 +254,48 @@ func VMFile(f io.Reader) (*MinFuckVM, error) {
 	if err != nil {
 		return nil, err
 	}
+	return NewVM(meta), nil
+}
 
+// NewVM 함수는 읽어들인 MinFuck 파일로부터 VM을 생성해 반환합니다.
+func NewVM(meta FileData) *MinFuckVM {
 	vm := new(MinFuckVM)
-	vm.Mem = make([]uint32, meta.memsize*2+3)
+	vm.Mem = make([]uint32, tapeSize(meta.memsize))
 	for i := uint32(0); i < meta.memsize; i++ {
 		vm.Mem[8+i*2] = i + 1 // Memory init
 	}
 
-	vm.Code = meta.code
-	vm.Out, vm.In, vm.m32 = os.Stdout, os.Stdin, true
+	if meta.code == nil && meta.src != nil {
+		vm.Src = meta.src
+	} else {
+		vm.Code = meta.code
+	}
+	vm.Encoding = meta.Encoding()
+	vm.Out, vm.In, vm.m32 = os.Stdout, withInput(meta.Input(), os.Stdin), true
 
-	return vm, nil
+	return vm
+}
+
+// PC 메서드는 현재 프로그램 카운터(니블 오프셋)를 반환합니다.
+// SourceMap과 함께 사용하면 오류가 발생한 원본 위치를 찾을 수 있습니다.
+func (vm *MinFuckVM) PC() uint64 {
+	return vm.pc
 }
 
 // Run 메서드는 VM이 종료될 때까지 구동합니다.
 // VM을 강제로 멈추려면 stop 채널에 신호를 보냅니다. 이 경우 VM 종료는 에러로 간주됩니다.
 // VM이 실행을 마치면 에러 여부를 report 채널에 보고합니다.
+// Backend가 BackendClosure이면 코드를 클로저로 컴파일하여 처음부터 실행합니다.
+// fork로 만든 스레드가 있으면 모든 스레드가 끝날 때 VM이 종료됩니다. (Scheduler 참고)
 func (vm *MinFuckVM) Run(stop <-chan struct{}, report chan<- error) {
+	if vm.Backend == BackendClosure {
+		vm.runClosure(stop, report)
+		return
+	}
+	if vm.Scheduler == SchedGoroutine {
+		vm.runGoroutines(stop, report)
+		return
+	}
 	for {
 		select {
 		case <-stop:
@@ -128,18 +304,36 @@ func (vm *MinFuckVM) Run(stop <-chan struct{}, report chan<- error) {
 		default:
 			err := vm.Process()
 			if err == io.EOF {
+				if vm.exitThread() {
+					continue
+				}
 				report <- nil
 				return
 			} else if err != nil {
 				report <- err
 				return
 			}
+			if len(vm.threads) > 0 {
+				vm.schedule()
+			}
 		}
 	}
 }
 
 // Process 메서드는 단일 MinFuck operation을 처리합니다.
+// 오류가 발생하면 프로그램 카운터는 오류가 발생한 명령어의 시작을 가리킵니다.
 func (vm *MinFuckVM) Process() error {
+	pc := vm.pc
+	err := vm.process()
+	if err != nil && err != io.EOF {
+		vm.pc = pc
+	}
+	return err
+}
+
+// process 메서드는 Process의 구현입니다.
+func (vm *MinFuckVM) process() error {
+	pc := vm.pc
 	c, err := vm.nibble()
 	if err != nil {
 		return err
@@ -147,69 +341,105 @@ func (vm *MinFuckVM) Process() error {
 	if (c>>3)&1 == 1 {
 		switch c & 7 {
 		case 0, 1, 2, 3:
-			nn, err := vm.nibbleN(8)
-			if err != nil {
-				return err
+			cnt, ok := vm.Encoding.readCount(vm.nextNibble)
+			if !ok {
+				return truncatedInstr(pc)
+			}
+			if err := vm.RunCodeN(c&7, cnt); err != nil {
+				return rangeError(vm, pc, vm.mp)
 			}
-			vm.RunCodeN(c&7, NibblesU32(nn))
-			// cnt := NibblesU32(nn)
-			// for i := uint32(0); i < cnt; i++ {
-			// 		vm.RunCode(c & 7)
-			// }
 		case 4, 5:
-			if (vm.Mem[vm.mp] == 0 && c&7 == 5) ||
-				(vm.Mem[vm.mp] != 0 && c&7 == 4) {
-				return nil
+			nn, err := vm.nibbleN(jumpNibbles)
+			if err == io.EOF {
+				return truncatedInstr(pc)
+			} else if err != nil {
+				return err
+			}
+			var target uint64
+			for _, nb := range nn {
+				target = target<<4 | uint64(nb)
 			}
-			nn, err := vm.nibbleN(16)
-			if err != nil {
+			if err := vm.checkCell(pc); err != nil {
 				return err
 			}
-			var jmp uint32
-			for i := 0; i < 8; i++ {
-				jmp |= uint32(nn[i*2])<<4 | uint32(nn[i*2+1])
-				jmp <<= 8
+			// [는 현재 셀이 0일 때, ]는 0이 아닐 때 점프합니다.
+			if (c&7 == 4) == vm.zero() {
+				vm.pc = target
 			}
-			vm.pc = jmp
 			return nil
+		case 6: // v2: NOP
+		case 7:
+			if vm.Encoding >= EncodingV2 {
+				return vm.extended()
+			}
 		}
-	} else {
-		vm.RunCode(c & 7)
+	} else if c == 4 || c == 5 {
+		if err := vm.checkCell(pc); err != nil {
+			return err
+		}
+		return vm.bracket(c)
+	} else if err := vm.RunCode(c & 7); err != nil {
+		return rangeError(vm, pc, vm.mp)
 	}
 	return nil
 }
 
+// ErrOutOfRange는 메모리 포인터가 범위를 벗어난 셀에 접근하려 할 때 반환됩니다.
+var ErrOutOfRange = errors.New("메모리 범위를 벗어났습니다")
+
+// rangeError 함수는 프로그램 카운터를 pc로 되돌리고 메모리 범위를 벗어났을 때의 오류를 반환합니다.
+func rangeError(vm *MinFuckVM, pc uint64, mp uint32) error {
+	vm.pc = pc
+	return fmt.Errorf("%w: 니블 오프셋 %d, 메모리 번지 %d", ErrOutOfRange, pc, int32(mp))
+}
+
+// checkCell 메서드는 현재 셀이 메모리 범위를 벗어났으면 pc에서 시작하는 명령어의 범위 오류를 반환합니다.
+func (vm *MinFuckVM) checkCell(pc uint64) error {
+	if vm.mp >= uint32(len(vm.Mem)) {
+		return rangeError(vm, pc, vm.mp)
+	}
+	return nil
+}
+
+// truncatedInstr 함수는 pc에서 시작하는 명령어가 코드 끝에서 잘렸을 때의 오류를 반환합니다.
+func truncatedInstr(pc uint64) error {
+	return fmt.Errorf("명령어가 잘렸습니다: 니블 오프셋 %d: %w", pc, io.ErrUnexpectedEOF)
+}
+
 // RunCode 함수는 한 개의 니블코드를 VM에서 실행합니다
-func (vm *MinFuckVM) RunCode(nc byte) {
-	if vm.bracketCheck(nc) {
-		switch nc {
-		case 0: // +
-			vm.Mem[vm.mp]++
-		case 1: // -
-			vm.Mem[vm.mp]--
-		case 2: // >
-			vm.mp = vm.mp + 1
-		case 3: // <
-			vm.mp = vm.mp - 1
-		case 6: // .
-			vm.Out.Write([]byte{byte(vm.Mem[vm.mp])})
-		case 7: // ,
-			b := make([]byte, 1)
-			vm.In.Read(b)
-			vm.Mem[vm.mp] = uint32(b[0])
-		}
+// [ ]는 프로그램 카운터를 옮겨야 하므로 Process에서 처리합니다.
+// 범위를 벗어난 셀에 접근하려 하면 ErrOutOfRange를 반환합니다.
+func (vm *MinFuckVM) RunCode(nc byte) error {
+	if nc != 2 && nc != 3 && vm.mp >= uint32(len(vm.Mem)) {
+		return ErrOutOfRange
 	}
+	switch nc {
+	case 0: // +
+		vm.Mem[vm.mp]++
+	case 1: // -
+		vm.Mem[vm.mp]--
+	case 2: // >
+		vm.mp = vm.mp + 1
+	case 3: // <
+		vm.mp = vm.mp - 1
+	case 6: // .
+		vm.Out.Write([]byte{byte(vm.Mem[vm.mp])})
+	case 7: // ,
+		b := make([]byte, 1)
+		vm.In.Read(b)
+		vm.Mem[vm.mp] = uint32(b[0])
+	}
+	return nil
 }
 
 // RunCodeN 함수는 한 개의 니블코드를 N회 VM에서 실행합니다
-func (vm *MinFuckVM) RunCodeN(nc byte, n uint32) {
+// 범위를 벗어난 셀에 접근하려 하면 ErrOutOfRange를 반환합니다.
+func (vm *MinFuckVM) RunCodeN(nc byte, n uint32) error {
 	if nc == 4 || nc == 5 {
 		panic("[ and ] must NOT be compressed")
 	}
-	for i := uint32(0); i < n; i++ {
-		if !vm.bracketCheck(nc) {
-			return
-		}
+	if nc != 2 && nc != 3 && vm.mp >= uint32(len(vm.Mem)) {
+		return ErrOutOfRange
 	}
 	switch nc {
 	case 0: // +
@@ -219,7 +449,7 @@ func (vm *MinFuckVM) RunCodeN(nc byte, n uint32) {
 	case 2: // >
 		vm.mp += n
 	case 3: // <
-		vm.mp += n
+		vm.mp -= n
 	case 6: // .
 		vm.Out.Write([]byte{byte(vm.Mem[vm.mp])})
 	case 7: // ,
@@ -227,83 +457,182 @@ func (vm *MinFuckVM) RunCodeN(nc byte, n uint32) {
 		vm.In.Read(b)
 		vm.Mem[vm.mp] = uint32(b[0])
 	}
+	return nil
 }
 
-// bracketCheck 메서드는 VM이 맞는 대괄호 짝을 찾는 중일 때 코드를 실행하지 않도록 합니다.
-func (vm *MinFuckVM) bracketCheck(nc byte) bool {
-	if vm.bt == 0 {
-		if nc == 4 {
-			if vm.m32 {
-				if vm.Mem[vm.mp] == 0 {
-					goto bkOpen
-				}
-			} else if byte(vm.Mem[vm.mp]) == 0 {
-				goto bkOpen
+// extended 메서드는 EncodingV2의 확장 명령어를 실행합니다.
+func (vm *MinFuckVM) extended() error {
+	pc := vm.pc - 1
+	in, err := vm.Encoding.decode(vm.fetch, pc)
+	if err == io.ErrUnexpectedEOF {
+		return truncatedInstr(pc)
+	} else if err != nil {
+		return fmt.Errorf("%v: 니블 오프셋 %d", err, pc)
+	}
+	vm.pc = pc + in.Len
+	// ret과 #을 제외한 확장 명령어는 모두 현재 셀에 접근합니다. Extended Brainfuck 명령어는 ebf에서 검사합니다.
+	if in.Ext != ExtRet && in.Ext != ExtDebug && in.Ext != ExtEBF {
+		if err := vm.checkCell(pc); err != nil {
+			return err
+		}
+	}
+
+	switch in.Ext {
+	case ExtSet:
+		vm.Mem[vm.mp] = vm.Mem[vm.mp] - vm.loopCount() + uint32(in.Args[0])
+	case ExtMul:
+		// 루프를 한 번도 돌지 않으면 대상 셀에 접근하지 않습니다.
+		if n := vm.loopCount(); n != 0 {
+			t := vm.mp + uint32(in.Args[0])
+			if t >= uint32(len(vm.Mem)) {
+				return rangeError(vm, pc, t)
 			}
-		} else if nc == 5 {
-			if vm.m32 {
-				if vm.Mem[vm.mp] != 0 {
-					goto bkClose
-				}
-			} else if byte(vm.Mem[vm.mp]) != 0 {
-				goto bkClose
+			vm.Mem[t] += uint32(in.Args[1]) * n
+		}
+	case ExtScan:
+		step := uint32(in.Args[0])
+		for !vm.zero() {
+			if vm.mp += step; vm.mp >= uint32(len(vm.Mem)) {
+				return rangeError(vm, pc, vm.mp)
 			}
 		}
-		return true
+	case ExtProc:
+		vm.defineProc(vm.pc)
+		vm.pc += uint64(in.Args[0])
+	case ExtRet:
+		return vm.ret(pc)
+	case ExtCall:
+		return vm.call(pc)
+	case ExtFork:
+		return vm.fork(pc, uint32(in.Args[0]))
+	case ExtEBF:
+		return vm.ebf(pc, EBFOp(in.Args[0]))
+	case ExtDebug:
+		vm.dump()
+	}
+	return nil
+}
+
+// loopCount 메서드는 현재 셀이 0이 될 때까지 -를 반복하는 루프의 반복 횟수를 반환합니다.
+func (vm *MinFuckVM) loopCount() uint32 {
+	if vm.m32 {
+		return vm.Mem[vm.mp]
+	}
+	return vm.Mem[vm.mp] & 0xff
+}
+
+// zero 메서드는 [ ] 비교를 위해 현재 셀이 0인지 확인합니다.
+func (vm *MinFuckVM) zero() bool {
+	if vm.m32 {
+		return vm.Mem[vm.mp] == 0
+	}
+	return byte(vm.Mem[vm.mp]) == 0
+}
+
+// bracket 메서드는 압축되지 않은 [ ]를 실행합니다.
+// 루프에 들어갈 때 본문의 시작 오프셋을 대괄호 스택에 넣어 두고, ]에서 그 위치로 돌아갑니다.
+// 셀이 0인 [는 짝이 맞는 ]를 찾을 때까지 명령어 단위로 코드를 건너뜁니다.
+func (vm *MinFuckVM) bracket(nc byte) error {
+	if nc == 4 {
+		if !vm.zero() {
+			vm.bs = append(vm.bs, vm.pc)
+			return nil
+		}
+		return vm.skipLoop()
+	}
+	if len(vm.bs) == 0 {
+		return fmt.Errorf("짝이 맞지 않는 ]: 니블 오프셋 %d", vm.pc-1)
+	}
+	if vm.zero() {
+		vm.bs = vm.bs[:len(vm.bs)-1]
 	} else {
-		vm.bracketStack()
-		return false
-	}
-bkOpen:
-	vm.bt = 1
-	return false
-bkClose:
-	vm.bt = 2
-	vm.pc--
-	return false
-}
-
-// bracketStack 메서드는 대괄호 스택을 조정합니다
-func (vm *MinFuckVM) bracketStack() {
-	nc, _ := vm.nibbleRaw(vm.pc)
-	if nc == 4 { // [
-		if vm.bs == 0 && vm.bt == 2 {
-			vm.bt = 0
-			return
+		vm.pc = vm.bs[len(vm.bs)-1]
+	}
+	return nil
+}
+
+// skipLoop 메서드는 짝이 맞는 ] 다음으로 프로그램 카운터를 옮깁니다.
+func (vm *MinFuckVM) skipLoop() error {
+	start, depth := vm.pc-1, 0
+	for {
+		in, err := vm.Encoding.decode(vm.fetch, vm.pc)
+		if err == io.EOF {
+			return fmt.Errorf("짝이 맞지 않는 [: 니블 오프셋 %d", start)
+		} else if err != nil {
+			return err
 		}
-		vm.bs++
-	} else if nc == 5 { // ]
-		if vm.bs == 0 && vm.bt == 1 {
-			vm.bt = 0
-			return
+		vm.pc += in.Len
+		if in.Op == 4 {
+			depth++
+		} else if in.Op == 5 {
+			if depth == 0 {
+				return nil
+			}
+			depth--
 		}
-		vm.bs--
-	} else {
-		return
 	}
 }
 
-func (vm *MinFuckVM) nibbleRaw(pc uint32) (byte, error) {
-	if pc>>1 >= uint32(len(vm.Code)) {
-		return 0, io.EOF
+func (vm *MinFuckVM) nibbleRaw(pc uint64) (byte, error) {
+	off := int64(pc >> 1)
+	var b byte
+	if vm.Code != nil || vm.Src == nil {
+		if off >= int64(len(vm.Code)) {
+			return 0, io.EOF
+		}
+		b = vm.Code[off]
+	} else {
+		if off < vm.pageOff || off >= vm.pageOff+int64(len(vm.page)) {
+			if err := vm.loadPage(off); err != nil {
+				return 0, err
+			}
+		}
+		b = vm.page[off-vm.pageOff]
 	}
-	n := (vm.Code[pc>>1] >> (((pc & 1) ^ 1) << 2)) & 0xf
+	n := (b >> (((pc & 1) ^ 1) << 2)) & 0xf
 	return n, nil
 }
 
+// loadPage 메서드는 주어진 오프셋을 포함하는 코드 페이지를 Src에서 읽어들입니다.
+func (vm *MinFuckVM) loadPage(off int64) error {
+	if off >= vm.Src.Size() {
+		return io.EOF
+	}
+	if vm.page == nil {
+		vm.page = make([]byte, codePageSize)
+	}
+	start := off &^ (codePageSize - 1)
+	n, err := vm.Src.ReadAt(vm.page[:cap(vm.page)], start)
+	if int64(n) <= off-start {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		vm.page = vm.page[:0]
+		return err
+	}
+	vm.page, vm.pageOff = vm.page[:n], start
+	return nil
+}
+
+func (vm *MinFuckVM) fetch(pc uint64) (byte, bool) {
+	n, err := vm.nibbleRaw(pc)
+	return n, err == nil
+}
+
 func (vm *MinFuckVM) nibble() (byte, error) {
 	n, err := vm.nibbleRaw(vm.pc)
 	if err != nil {
 		return 0, err
 	}
-	if vm.bt == 2 {
-		vm.pc--
-	} else {
-		vm.pc++
-	}
+	vm.pc++
 	return n, nil
 }
 
+func (vm *MinFuckVM) nextNibble() (byte, bool) {
+	n, err := vm.nibble()
+	return n, err == nil
+}
+
 func (vm *MinFuckVM) nibbleN(n uint32) ([]byte, error) {
 	b := make([]byte, n)
 	var err error
@@ -316,12 +645,34 @@ func (vm *MinFuckVM) nibbleN(n uint32) ([]byte, error) {
 	return b, nil
 }
 
+// dump 메서드는 레지스터와 현재 셀 앞뒤 dumpWindow개의 메모리를 디버그 출력에 기록합니다. (Brainfuck의 #)
+// 현재 셀은 [ ]로 표시합니다.
 func (vm *MinFuckVM) dump() {
-	fmt.Printf(`VM Status Dump
+	w := vm.debugWriter()
+	fmt.Fprintf(w, `VM Status Dump
     PC: %d
     MP: %d
-    BS: %d
-    BT: %d
+    BS: %v
+    CS: %v
+    ST: %d
+`, vm.pc, vm.mp, vm.bs, vm.cs, vm.st)
 
-`, vm.pc, vm.mp, vm.bs, vm.bt)
+	lo, hi := uint64(0), uint64(vm.mp)+dumpWindow+1
+	if vm.mp > dumpWindow {
+		lo = uint64(vm.mp) - dumpWindow
+	}
+	if hi > uint64(len(vm.Mem)) {
+		hi = uint64(len(vm.Mem))
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "    Mem[%d:%d]:", lo, hi)
+	for i := lo; i < hi; i++ {
+		if i == uint64(vm.mp) {
+			fmt.Fprintf(&buf, " [%d]", vm.Mem[i])
+		} else {
+			fmt.Fprintf(&buf, " %d", vm.Mem[i])
+		}
+	}
+	buf.WriteString("\n\n")
+	w.Write(buf.Bytes())
 }
@@ -3,6 +3,8 @@ package mf
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -72,6 +74,30 @@ func TestFileParse(t *testing.T) {
 	}
 }
 
+func TestSetSection(t *testing.T) {
+	fd := NewFileData(16, []byte{0x01})
+	for _, id := range []string{"", "ABC", "ABCDE"} {
+		if err := fd.SetSection(id, []byte{1}); err == nil {
+			t.Errorf("%q: expected invalid section ID error", id)
+		}
+	}
+	if err := fd.SetSection("NOTE", []byte{1}); err != nil {
+		t.Errorf("NOTE: %v", err)
+	}
+	if ids := fd.Sections(); len(ids) != 1 || ids[0] != "NOTE" {
+		t.Errorf("got sections %v, expected [NOTE]", ids)
+	}
+}
+
+// codeOf 함수는 fd의 코드를 반환합니다. 코드를 읽을 수 없으면 테스트를 중단합니다.
+func codeOf(t *testing.T, fd *FileData) []byte {
+	code, err := fd.Code()
+	if err != nil {
+		t.Fatalf("Code failed: %v", err)
+	}
+	return code
+}
+
 var nTestEntries = []struct {
 	code []byte
 	read int
@@ -177,8 +203,129 @@ func TestNibbleN(t *testing.T) {
 	}
 }
 
+var rcnTestEntries = []struct {
+	nc byte
+	n  uint32
+	mp uint32
+}{
+	{2, 5, 15},
+	{3, 5, 5},
+	{3, 10, 0},
+}
+
+func TestRunCodeN(t *testing.T) {
+	for n, test := range rcnTestEntries {
+		vm := &MinFuckVM{Mem: make([]uint32, 32)}
+		vm.mp = 10
+		vm.RunCodeN(test.nc, test.n)
+		if vm.mp != test.mp {
+			t.Errorf("Test #%d failed: got mp %d, expected %d", n+1, vm.mp, test.mp)
+		}
+	}
+}
+
 const hwBfCode = ">++++++++[-<+++++++++>]<.>>+>-[+]++>++>+++[>[->+++<<+++>]<<]>-----.>->+++..+++.>-.<<+[>[+>+]>>]<--------------.>>.+++.------.--------.>+.>+."
 
+var bracketErrTestEntries = []string{"[-][", "]", "+[-]]"}
+
+func TestBracketError(t *testing.T) {
+	for n, bf := range bracketErrTestEntries {
+		fd, _ := FromBfCodeOpts(bf, BfOptions{Mem: 16})
+		if _, err := runBackend(NewVM(fd), BackendInterp, ""); err == nil {
+			t.Errorf("Test #%d failed: expected bracket error, got %v", n+1, err)
+		}
+	}
+}
+
+var decodeErrTestEntries = []struct {
+	code []byte
+	enc  Encoding
+	err  string
+}{
+	{code: []byte{0x80, 0x00}, err: "명령어가 잘렸습니다"},                              // 반복 횟수가 잘린 +
+	{code: []byte{0x0c, 0x00, 0x00}, err: "명령어가 잘렸습니다"},                        // 점프 대상이 잘린 [
+	{code: []byte{0xf2, 0x02}, enc: EncodingV2, err: "명령어가 잘렸습니다"},             // 인자가 잘린 곱셈
+	{code: []byte{0xf3, 0x00}, enc: EncodingV2, err: ErrUnknownNibble.Error()}, // 보폭이 0인 스캔
+}
+
+func TestDecodeError(t *testing.T) {
+	for n, test := range decodeErrTestEntries {
+		vm := &MinFuckVM{Code: test.code, Encoding: test.enc, Mem: make([]uint32, 16)}
+		vm.Mem[0] = 1
+		if _, err := runBackend(vm, BackendInterp, ""); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Test #%d failed: got %v, expected %q", n+1, err, test.err)
+		}
+	}
+}
+
+func TestMulZeroCell(t *testing.T) {
+	// 현재 셀이 0이면 범위를 벗어난 곱셈 대상에 접근하지 않습니다.
+	fd, _ := FromBfCodeOpts("[->>>>>>+<<<<<<]+.", BfOptions{Mem: 4, Optimize: true})
+	for _, b := range []Backend{BackendInterp, BackendClosure} {
+		if out, err := runBackend(NewVM(fd), b, ""); err != nil || out != "\x01" {
+			t.Errorf("backend %v: got %q (%v), expected %q", b, out, err, "\x01")
+		}
+	}
+}
+
+func TestRangeError(t *testing.T) {
+	// 클로저 백엔드와 같은 출력을 내고 범위 오류를 보고합니다.
+	for n, test := range closureErrorEntries {
+		if test.err != "" && !strings.HasPrefix(test.err, "메모리") {
+			continue
+		}
+		for _, opt := range []bool{false, true} {
+			fd, _ := FromBfCodeOpts(test.bf, BfOptions{Mem: 4, Optimize: opt})
+			vm := NewVM(fd)
+			out, err := runBackend(vm, BackendInterp, "")
+			if out != test.out || (err == nil) != (test.err == "") || (err != nil && !errors.Is(err, ErrOutOfRange)) {
+				t.Errorf("Test #%d failed (optimize=%v): got %q (%v), expected %q (%s)", n+1, opt, out, err, test.out, test.err)
+				continue
+			}
+			// 범위 오류의 PC는 범위를 벗어난 셀에 접근한 명령어를 가리킵니다.
+			if in, _ := vm.Encoding.Decode(vm.Code, vm.PC()); err != nil && in.Ext == 0 && (in.Op == 2 || in.Op == 3) {
+				t.Errorf("Test #%d failed (optimize=%v): PC %d points to %v", n+1, opt, vm.PC(), in)
+			}
+		}
+	}
+}
+
+var errorPCTestEntries = []struct {
+	code []byte
+	enc  Encoding
+	pc   uint64
+}{
+	{code: []byte{0x05}, pc: 1},                        // 짝이 맞지 않는 ]
+	{code: []byte{0x0c, 0x00, 0x00}, pc: 1},            // 점프 대상이 잘린 [
+	{code: []byte{0x0f, 0x20}, enc: EncodingV2, pc: 1}, // 인자가 잘린 곱셈
+}
+
+func TestErrorPC(t *testing.T) {
+	// 오류가 발생하면 PC는 오류가 발생한 명령어의 시작을 가리킵니다.
+	for n, test := range errorPCTestEntries {
+		vm := &MinFuckVM{Code: test.code, Encoding: test.enc, Mem: make([]uint32, 16)}
+		vm.Mem[0] = 1
+		if _, err := runBackend(vm, BackendInterp, ""); err == nil || vm.PC() != test.pc {
+			t.Errorf("Test #%d failed: got PC %d (%v), expected %d", n+1, vm.PC(), err, test.pc)
+		}
+	}
+
+	// 확장 명령어의 범위 오류는 두 백엔드가 같은 소스 위치를 가리킵니다.
+	fd, _ := FromBfCodeOpts("+\n++\n[<<]\n+", BfOptions{Mem: 4, Optimize: true, SourceMap: true})
+	sm, _ := fd.SourceMap()
+	var pos [2]SourceRange
+	for i, b := range []Backend{BackendInterp, BackendClosure} {
+		vm := NewVM(fd)
+		if _, err := runBackend(vm, b, ""); !errors.Is(err, ErrOutOfRange) {
+			t.Fatalf("backend %v: expected range error, got %v", b, err)
+		}
+		pos[i], _ = sm.Lookup(vm.PC())
+	}
+	if pos[0] != pos[1] || pos[0].Start.Line != 3 {
+		t.Errorf("got source position %v (interp), %v (closure), expected line 3", pos[0], pos[1])
+	}
+}
+
 type dummyIO struct{}
 
 func (d *dummyIO) Read(b []byte) (n int, err error) {
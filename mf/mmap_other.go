@@ -0,0 +1,29 @@
+//go:build !unix
+
+package mf
+
+import (
+	"io"
+	"os"
+)
+
+// MapFile 함수는 MinFuck 파일을 엽니다.
+// 메모리 매핑을 지원하지 않는 플랫폼에서는 OpenFile과 같이 필요한 부분만 파일에서 읽어들이며,
+// 반환된 io.Closer를 닫은 뒤에는 FileData와 그로부터 생성한 VM을 사용하면 안 됩니다.
+func MapFile(name string) (FileData, io.Closer, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return FileData{}, nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return FileData{}, nil, err
+	}
+	fd, err := OpenFile(f, st.Size())
+	if err != nil {
+		f.Close()
+		return FileData{}, nil, err
+	}
+	return fd, f, nil
+}
@@ -0,0 +1,62 @@
+//go:build unix
+
+package mf
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// MapFile 함수는 MinFuck 파일을 메모리에 매핑하여 엽니다.
+// 코드는 복사되지 않고 매핑된 메모리를 직접 참조하므로, 반환된 io.Closer를 닫은 뒤에는
+// FileData와 그로부터 생성한 VM을 사용하면 안 됩니다.
+func MapFile(name string) (FileData, io.Closer, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return FileData{}, nil, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return FileData{}, nil, err
+	}
+	if st.Size() == 0 {
+		_, err := OpenFile(f, 0)
+		return FileData{}, nil, err
+	}
+
+	b, err := syscall.Mmap(int(f.Fd()), 0, int(st.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return FileData{}, nil, err
+	}
+	m := mapping(b)
+	fd, err := OpenFile(m, int64(len(m)))
+	if err != nil {
+		m.Close()
+		return FileData{}, nil, err
+	}
+	return fd, m, nil
+}
+
+// mapping 타입은 메모리에 매핑된 파일입니다.
+type mapping []byte
+
+func (m mapping) ReadAt(b []byte, off int64) (int, error) {
+	if off >= int64(len(m)) {
+		return 0, io.EOF
+	}
+	n := copy(b, m[off:])
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (m mapping) slice(off, n int64) []byte {
+	return m[off : off+n : off+n]
+}
+
+func (m mapping) Close() error {
+	return syscall.Munmap(m)
+}
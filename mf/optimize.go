@@ -0,0 +1,95 @@
+package mf
+
+// bfCellStride는 Brainfuck 셀 하나가 차지하는 MinFuck 메모리 셀의 수입니다.
+const bfCellStride = 8
+
+// bfCellBase는 Brainfuck의 0번 셀에 해당하는 MinFuck 메모리 번지입니다.
+// VMFile이 초기화하는 짝수 번지와 겹치지 않도록 홀수 번지에서 시작합니다.
+const bfCellBase = 9
+
+// bfTokens 함수는 Brainfuck 코드에서 명령어가 아닌 문자를 제외한 명령어 목록을 만듭니다.
+func bfTokens(bf string) []Token {
+	return scanTokens(bf, func(r rune) byte { return FromBf(string(r)) })
+}
+
+// scanTokens 함수는 문자 하나가 명령어 하나인 소스 코드를 명령어 목록으로 나눕니다.
+// op는 문자에 해당하는 명령어를 반환하며, 명령어가 아니면 255를 반환합니다.
+func scanTokens(src string, op func(r rune) byte) []Token {
+	var toks []Token
+	pos := SourcePos{Line: 1}
+	for i, b := range src {
+		pos.Col++
+		if b == '\n' {
+			pos.Line, pos.Col = pos.Line+1, 0
+		}
+		if c := op(b); c != 255 {
+			toks = append(toks, Token{Op: c, Off: i, Pos: pos})
+		}
+	}
+	return toks
+}
+
+// 확장 명령어의 부호 있는 인자로 표현할 수 있는 범위입니다.
+const maxExtArg = maxCountV2 >> 1
+
+/*
+optimizeLoop 함수는 toks[0]에서 시작하는 루프가 관용구이면 확장 명령어로 작성하고,
+변환한 명령어의 수를 반환합니다. 관용구가 아니면 아무것도 작성하지 않고 0을 반환합니다.
+
+ [>], [<<] 등 포인터만 옮기는 루프는 스캔으로,
+ [-], [->+<], [->++>+++<<] 등 포인터가 제자리로 돌아오고 현재 셀을 1씩 줄이는 루프는
+ 곱셈과 셋 0으로 변환합니다. 셋 바로 뒤의 +는 셋의 값으로 합칩니다.
+*/
+func optimizeLoop(nw *NibbleWriterOptimized, toks []Token) int {
+	var body []irOp
+	end := -1
+	for i := 1; i < len(toks) && end < 0; i++ {
+		switch toks[i].Op {
+		case 0, 1:
+			body = append(body, irOp{kind: irAdd, n: 1 - 2*int64(toks[i].Op)})
+		case 2, 3:
+			body = append(body, irOp{kind: irMove, n: 5 - 2*int64(toks[i].Op)})
+		case 5:
+			end = i
+		default:
+			return 0
+		}
+	}
+	if end < 0 {
+		return 0
+	}
+	id, ok := matchIdiom(body)
+	if !ok || !id.fits() {
+		return 0
+	}
+	src := SourceRange{Start: toks[0].Pos, End: toks[end].Pos}
+
+	if id.scan != 0 {
+		nw.putExt(ExtScan, src, zigzag(int32(id.scan*bfCellStride)))
+		return end + 1
+	}
+	for _, m := range id.muls {
+		nw.putExt(ExtMul, src, zigzag(int32(m.off*bfCellStride)), zigzag(int32(m.k)))
+	}
+	n, v := end+1, uint32(0)
+	for ; n < len(toks) && toks[n].Op == 0 && v < maxCountV2; n++ {
+		v++
+		src.End = toks[n].Pos
+	}
+	nw.putExt(ExtSet, src, v)
+	return n
+}
+
+// fits 메서드는 Brainfuck 루프의 관용구를 확장 명령어의 인자로 표현할 수 있는지 확인합니다.
+func (id loopIdiom) fits() bool {
+	in := func(v int64) bool { return v <= maxExtArg && -v <= maxExtArg }
+	if !in(id.scan * bfCellStride) {
+		return false
+	}
+	for _, m := range id.muls {
+		if !in(m.off*bfCellStride) || !in(m.k) {
+			return false
+		}
+	}
+	return true
+}
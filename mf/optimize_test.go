@@ -0,0 +1,119 @@
+package mf
+
+import (
+	"strings"
+	"testing"
+)
+
+var optTestEntries = []struct {
+	bf  string
+	in  string
+	ext uint64 // 확장 명령어 수
+	out string
+}{
+	{ // Test #1: multiply loop
+		bf:  "++++++++[>++++++++<-]>+.",
+		ext: 2, out: "A",
+	},
+	{ // Test #2: clear and set
+		bf:  "+++++[-]" + strings.Repeat("+", 66) + ".",
+		ext: 1, out: "B",
+	},
+	{ // Test #3: multiple targets, negative offset
+		bf:  ">+++++[-<+++++++++++++>>++++++++++++++<]<.>>.",
+		ext: 3, out: "AF",
+	},
+	{ // Test #4: scan right and left
+		bf:  "+>+>+>+<<<[>]" + strings.Repeat("+", 67) + ".<[<]>.",
+		ext: 2, out: "C\x01",
+	},
+	{ // Test #5: scan with stride
+		bf:  "+>>+>>+>+<<<<<<[>>]" + strings.Repeat("+", 68) + ".",
+		ext: 1, out: "D",
+	},
+	{ // Test #6: non-idiom loops are kept
+		bf: ",[.,]+[->[-],<]",
+		in: "echo", ext: 1, out: "echo",
+	},
+	{ // Test #7: loop that does not decrement the current cell
+		bf:  "++[>+<--]>" + strings.Repeat("+", 68) + ".",
+		ext: 0, out: "E",
+	},
+	{ // Test #8: hello world
+		bf:  hwBfCode,
+		ext: 5, out: "Hello World!\n",
+	},
+}
+
+// runBfFile 함수는 FromBfCodeOpts로 변환한 코드를 실행하고, 출력과 처리한 명령어 수를 반환합니다.
+func runBfFile(t *testing.T, bf string, opts BfOptions, in string, m32 bool) (string, int) {
+	fd, _ := FromBfCodeOpts(bf, opts)
+	vm := NewVM(fd)
+	io := &IOStream{Stdin: in}
+	vm.In, vm.Out, vm.m32 = io, io, m32
+	steps := 0
+	for {
+		err := vm.Process()
+		if err != nil {
+			if err.Error() != "EOF" {
+				t.Errorf("VM returned error: %v", err)
+			}
+			return io.Stdout, steps
+		}
+		steps++
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	for n, test := range optTestEntries {
+		fd, _ := FromBfCodeOpts(test.bf, BfOptions{Mem: 256, Optimize: true})
+		if fd.Encoding() != EncodingV2 {
+			t.Errorf("Test #%d failed: optimized code should use EncodingV2", n+1)
+		}
+		st, err := Stats(codeOf(t, &fd), fd.Encoding())
+		if err != nil || st.Ext != test.ext {
+			t.Errorf("Test #%d failed: %d extended instructions (%v), expected %d", n+1, st.Ext, err, test.ext)
+		}
+		for _, m32 := range []bool{true, false} {
+			out, steps := runBfFile(t, test.bf, BfOptions{Mem: 256, Optimize: true}, test.in, m32)
+			ref, refSteps := runBfFile(t, test.bf, BfOptions{Mem: 256}, test.in, m32)
+			if out != test.out || ref != test.out {
+				t.Errorf("Test #%d failed (m32=%v): got %q, unoptimized %q, expected %q", n+1, m32, out, ref, test.out)
+			}
+			if test.ext > 0 && steps >= refSteps {
+				t.Errorf("Test #%d failed: optimized code took %d steps, unoptimized %d", n+1, steps, refSteps)
+			}
+		}
+	}
+}
+
+func TestOptimizeByteCells(t *testing.T) {
+	// 8비트 비교에서는 [-]와 곱셈 루프가 하위 8비트만큼만 반복합니다.
+	bf := strings.Repeat("+", 300) + "[->+<]>."
+	for _, m32 := range []bool{true, false} {
+		out, _ := runBfFile(t, bf, BfOptions{Mem: 16, Optimize: true}, "", m32)
+		ref, _ := runBfFile(t, bf, BfOptions{Mem: 16}, "", m32)
+		if out != ref {
+			t.Errorf("m32=%v: got %q, unoptimized %q", m32, out, ref)
+		}
+	}
+}
+
+func TestOptimizeSourceMap(t *testing.T) {
+	fd, sm := FromBfCodeOpts("+[-]++", BfOptions{Mem: 16, Optimize: true, SourceMap: true})
+	pc, _, ok := sm.Nibbles(SourcePos{1, 3})
+	if !ok {
+		t.Fatalf("source map does not cover the loop")
+	}
+	in, err := fd.Encoding().Decode(codeOf(t, &fd), pc)
+	if err != nil || in.Ext != ExtSet || in.Args[0] != 2 {
+		t.Fatalf("unexpected instruction %v (%v)", in, err)
+	}
+	if in.String() != "set 2" {
+		t.Errorf("unexpected disassembly %q", in.String())
+	}
+	r, ok := sm.Lookup(in.PC)
+	if !ok || r.Start != (SourcePos{1, 2}) || r.End != (SourcePos{1, 6}) {
+		t.Errorf("unexpected source range %v", r)
+	}
+}
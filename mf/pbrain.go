@@ -0,0 +1,215 @@
+package mf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// pbrain 명령어의 토큰입니다. Token.Op에서 니블코드 0~7 다음의 값을 사용합니다.
+const (
+	OpProc byte = 8 + iota // ( 프로시저 정의의 시작
+	OpRet                  // ) 프로시저 정의의 끝
+	OpCall                 // : 프로시저 호출
+)
+
+// DefaultCallDepth는 MinFuckVM.MaxCallDepth가 0일 때 사용하는 호출 스택의 최대 깊이입니다.
+const DefaultCallDepth = 1 << 16
+
+// 프로시저 호출에 실패한 이유입니다. CallError.Err에 기록됩니다.
+var (
+	ErrUndefinedProc = errors.New("정의되지 않은 프로시저")
+	ErrCallDepth     = errors.New("호출 스택이 너무 깊습니다")
+)
+
+// CallError 구조체는 pbrain 프로시저를 호출할 수 없을 때 반환됩니다.
+type CallError struct {
+	Err   error  // ErrUndefinedProc 또는 ErrCallDepth
+	PC    uint64 // 호출 명령어의 니블 오프셋
+	Proc  uint32 // 호출한 프로시저 번호
+	Depth int    // 호출할 때의 호출 스택 깊이
+}
+
+func (e *CallError) Error() string {
+	return fmt.Sprintf("%v: 니블 오프셋 %d, 프로시저 %d, 호출 깊이 %d", e.Err, e.PC, e.Proc, e.Depth)
+}
+
+// Unwrap 메서드는 errors.Is(err, ErrCallDepth) 등이 성립하도록 합니다.
+func (e *CallError) Unwrap() error {
+	return e.Err
+}
+
+// callError 메서드는 프로그램 카운터를 호출 명령어로 되돌리고 CallError를 반환합니다.
+func (vm *MinFuckVM) callError(err error, pc uint64, id uint32, depth int) error {
+	vm.pc = pc
+	return &CallError{Err: err, PC: pc, Proc: id, Depth: depth}
+}
+
+// callDepth 메서드는 호출 스택의 최대 깊이를 반환합니다.
+func (vm *MinFuckVM) callDepth() int {
+	if vm.MaxCallDepth > 0 {
+		return vm.MaxCallDepth
+	}
+	return DefaultCallDepth
+}
+
+// defineProc 메서드는 현재 셀의 값을 번호로, body에서 시작하는 프로시저를 정의합니다.
+func (vm *MinFuckVM) defineProc(body uint64) {
+	if vm.procs == nil {
+		vm.procs = make(map[uint32]uint64)
+	}
+	vm.procs[vm.loopCount()] = body
+}
+
+// call 메서드는 현재 셀의 값을 번호로 하는 프로시저를 호출합니다. pc는 호출 명령어의 니블 오프셋입니다.
+func (vm *MinFuckVM) call(pc uint64) error {
+	id := vm.loopCount()
+	body, ok := vm.procs[id]
+	if !ok {
+		return vm.callError(ErrUndefinedProc, pc, id, len(vm.cs))
+	}
+	if len(vm.cs) >= vm.callDepth() {
+		return vm.callError(ErrCallDepth, pc, id, len(vm.cs))
+	}
+	vm.cs = append(vm.cs, vm.pc)
+	vm.pc = body
+	return nil
+}
+
+// ret 메서드는 프로시저를 호출한 명령어 다음으로 돌아갑니다.
+func (vm *MinFuckVM) ret(pc uint64) error {
+	if len(vm.cs) == 0 {
+		return fmt.Errorf("호출되지 않은 프로시저에서 반환합니다: 니블 오프셋 %d", pc)
+	}
+	vm.pc, vm.cs = vm.cs[len(vm.cs)-1], vm.cs[:len(vm.cs)-1]
+	return nil
+}
+
+/*
+pbrain 타입은 Brainfuck에 프로시저를 더한 pbrain입니다.
+
+ ( ... )는 현재 셀의 값을 번호로 하는 프로시저를 정의하고, :는 현재 셀의 값을 번호로 하는 프로시저를 호출합니다.
+ 프로시저는 ( 를 실행할 때 정의되며, 같은 번호로 다시 정의하면 이전 정의를 덮어씁니다.
+ ( )는 서로, 그리고 [ ]와 올바르게 중첩되어야 합니다.
+*/
+type pbrain struct{}
+
+func (pbrain) Name() string { return "pbrain" }
+
+func (d pbrain) Tokens(src string) ([]Token, error) {
+	toks := scanTokens(src, d.op)
+	return toks, checkProcs(toks)
+}
+
+func (d pbrain) Emit(ops []byte) string {
+	return emitChars(d, ops)
+}
+
+func (pbrain) op(r rune) byte {
+	switch r {
+	case '(':
+		return OpProc
+	case ')':
+		return OpRet
+	case ':':
+		return OpCall
+	}
+	return FromBf(string(r))
+}
+
+func (pbrain) char(op byte) string {
+	switch op {
+	case OpProc:
+		return "("
+	case OpRet:
+		return ")"
+	case OpCall:
+		return ":"
+	}
+	return ToBf(op)
+}
+
+// checkProcs 함수는 프로시저 정의가 서로, 그리고 대괄호와 올바르게 중첩되는지 확인합니다.
+// 프로시저 밖의 짝이 맞지 않는 대괄호는 Brainfuck과 같이 실행할 때 오류가 됩니다.
+func checkProcs(toks []Token) error {
+	var open []Token
+	for _, t := range toks {
+		switch t.Op {
+		case 4, OpProc:
+			open = append(open, t)
+		case 5:
+			if len(open) > 0 && open[len(open)-1].Op == OpProc {
+				return &SourceError{Pos: t.Pos, Msg: "프로시저 안에서 짝이 맞지 않는 ]"}
+			}
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		case OpRet:
+			if len(open) == 0 || open[len(open)-1].Op != OpProc {
+				return &SourceError{Pos: t.Pos, Msg: "짝이 맞지 않는 )"}
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	for _, t := range open {
+		if t.Op == OpProc {
+			return &SourceError{Pos: t.Pos, Msg: "짝이 맞지 않는 ("}
+		}
+	}
+	return nil
+}
+
+// hasExtTokens 함수는 명령어 목록에 확장 명령어로 작성해야 하는 명령어가 있는지 확인합니다.
+func hasExtTokens(toks []Token) bool {
+	for _, t := range toks {
+		if t.Op >= OpProc {
+			return true
+		}
+	}
+	return false
+}
+
+// putToken 메서드는 pbrain, Brainfork, Extended Brainfuck, # 명령어를 확장 명령어로 작성합니다. 그 밖의 명령어이면 false를 반환합니다.
+// stride는 Brainfuck 셀 하나가 차지하는 메모리 셀의 수입니다.
+func (n *NibbleWriterOptimized) putToken(t Token, stride int32) bool {
+	switch t.Op {
+	case OpProc:
+		n.putProc(t.Pos)
+	case OpRet:
+		n.putRet(t.Pos)
+	case OpCall:
+		n.putExt(ExtCall, SourceRange{Start: t.Pos, End: t.Pos})
+	case OpFork:
+		n.putExt(ExtFork, SourceRange{Start: t.Pos, End: t.Pos}, zigzag(stride))
+	case OpDebug:
+		n.putExt(ExtDebug, SourceRange{Start: t.Pos, End: t.Pos})
+	default:
+		if t.Op < OpEBF || int(t.Op-OpEBF) >= len(ebfChars) {
+			return false
+		}
+		n.putExt(ExtEBF, SourceRange{Start: t.Pos, End: t.Pos}, uint32(t.Op-OpEBF))
+	}
+	return true
+}
+
+/*
+TokenCode 함수는 명령어 목록을 압축하지 않고 1:1로 니블코드로 변환합니다. (minfuck bfr)
+
+ pbrain, Brainfork, Extended Brainfuck, # 명령어가 있으면 확장 명령어로 작성하며, 이때 인코딩은 EncodingV2가 됩니다.
+ 프로시저 정의가 올바르게 중첩되지 않으면 오류를 반환합니다.
+*/
+func TokenCode(toks []Token) ([]byte, Encoding, error) {
+	if err := checkProcs(toks); err != nil {
+		return nil, 0, err
+	}
+	nw := &NibbleWriterOptimized{NibbleWriter: new(NibbleWriter), Encoding: EncodingV1}
+	if hasExtTokens(toks) {
+		nw.Encoding = EncodingV2
+	}
+	for _, t := range toks {
+		if !nw.putToken(t, 1) {
+			nw.NibbleWriter.Put(t.Op)
+		}
+	}
+	nw.Align()
+	return nw.Nibbles, nw.Encoding, nil
+}
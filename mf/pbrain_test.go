@@ -0,0 +1,156 @@
+package mf
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var pbrainTestEntries = []struct {
+	src, in, out string
+}{
+	{src: "(>" + strings.Repeat("+", 65) + ".[-]<):::", out: "AAA"},
+	// 재귀 호출: 1번 프로시저가 셀 1이 0이 될 때까지 셀 2를 출력하고 1 증가시킵니다.
+	{src: "+(>>.+<-[<:>]<)>+++>" + strings.Repeat("+", 97) + "<<:", out: "abc"},
+	// 재정의와 실행되지 않은 정의
+	{src: "(>+++[>++++++++++++++++++++++<-]>-.[-]<<)(>+++[>++++++++++++++++++++++<-]>.[-]<<)[(>,.<)]:", out: "B"},
+	// 프로시저 안에서 정의한 프로시저
+	{src: "+(-(>,.<)+)-+:-::", in: "xy", out: "xy"},
+	{src: ">,[<(>.<):>,]", in: "pbrain", out: "pbrain"},
+}
+
+func TestPbrainTokens(t *testing.T) {
+	toks, err := Pbrain.Tokens("a(+)\n:b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []byte
+	for _, tok := range toks {
+		got = append(got, tok.Op)
+	}
+	if string(got) != string([]byte{OpProc, 0, OpRet, OpCall}) || toks[3].Pos != (SourcePos{2, 1}) {
+		t.Errorf("unexpected tokens: %v", toks)
+	}
+	if src := Pbrain.Emit(got); src != "(+):" {
+		t.Errorf("unexpected emit: %q", src)
+	}
+	for _, src := range []string{"]()[", "[()]", "(())", "([])"} {
+		if _, err := Pbrain.Tokens(src); err != nil {
+			t.Errorf("%q: %v", src, err)
+		}
+	}
+	for _, src := range []string{"(", ")", "([)", "(])", "[(])", "(()"} {
+		if _, err := Pbrain.Tokens(src); err == nil {
+			t.Errorf("%q should be rejected", src)
+		}
+		if _, _, err := TokenCode(scanTokens(src, func(r rune) byte {
+			return map[rune]byte{'(': OpProc, ')': OpRet, '[': 4, ']': 5}[r]
+		})); err == nil {
+			t.Errorf("TokenCode(%q) should fail", src)
+		}
+	}
+}
+
+func TestPbrainRun(t *testing.T) {
+	for n, test := range pbrainTestEntries {
+		for _, opts := range rtOptions {
+			fd, _, err := FromSource(test.src, Pbrain, opts)
+			if err != nil {
+				t.Fatalf("Test #%d failed: %v", n+1, err)
+			}
+			if fd.Encoding() != EncodingV2 {
+				t.Errorf("Test #%d failed: encoding %v", n+1, fd.Encoding())
+			}
+			for _, b := range []Backend{BackendInterp, BackendClosure} {
+				out, err := runBackend(NewVM(fd), b, test.in)
+				if err != nil || out != test.out {
+					t.Errorf("Test #%d failed (%+v, %v): got %q (%v), expected %q", n+1, opts, b, out, err, test.out)
+				}
+			}
+		}
+
+		// minfuck bfr
+		toks, _ := Pbrain.Tokens(test.src)
+		code, enc, err := TokenCode(toks)
+		if err != nil {
+			t.Fatalf("Test #%d failed: %v", n+1, err)
+		}
+		vm := &MinFuckVM{Code: code, Encoding: enc, Mem: make([]uint32, 64)}
+		if out, err := runBackend(vm, BackendInterp, test.in); err != nil || out != test.out {
+			t.Errorf("Test #%d failed (bfr): got %q (%v), expected %q", n+1, out, err, test.out)
+		}
+	}
+}
+
+func TestPbrainCallError(t *testing.T) {
+	for _, test := range []struct {
+		src   string
+		depth int
+		err   error
+		proc  uint32
+	}{
+		{src: "+:", err: ErrUndefinedProc, proc: 1},
+		{src: "+(-+):-:", err: ErrUndefinedProc},
+		{src: "(:):", depth: 100, err: ErrCallDepth},
+		{src: "+++(:):", err: ErrCallDepth, proc: 3},
+	} {
+		fd, _, err := FromSource(test.src, Pbrain, BfOptions{Mem: 16})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range []Backend{BackendInterp, BackendClosure} {
+			vm := NewVM(fd)
+			vm.MaxCallDepth = test.depth
+			_, err := runBackend(vm, b, "")
+			var ce *CallError
+			if !errors.As(err, &ce) || !errors.Is(err, test.err) {
+				t.Errorf("%q (%v): unexpected error %v", test.src, b, err)
+				continue
+			}
+			depth := test.depth
+			if test.err != ErrCallDepth {
+				depth = 0
+			} else if depth == 0 {
+				depth = DefaultCallDepth
+			}
+			if ce.Proc != test.proc || ce.Depth != depth || ce.PC != vm.PC() {
+				t.Errorf("%q (%v): unexpected error %+v", test.src, b, ce)
+			}
+			if in, _ := EncodingV2.Decode(codeOf(t, &fd), ce.PC); in.Ext != ExtCall {
+				t.Errorf("%q (%v): error PC %d points to %v", test.src, b, ce.PC, in)
+			}
+		}
+	}
+}
+
+func TestPbrainEncoding(t *testing.T) {
+	fd, _, _ := FromSource(":(.)", Pbrain, BfOptions{Mem: 16, Encoding: EncodingV1})
+	var ins []string
+	for pc := uint64(0); ; {
+		in, err := fd.Encoding().Decode(codeOf(t, &fd), pc)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		ins = append(ins, in.String())
+		pc += in.Len
+	}
+	if got := strings.Join(ins, "; "); got != "> x9; call; proc 3; .; ret" {
+		t.Errorf("unexpected code: %s", got)
+	}
+
+	// 코드 생성기는 프로시저를 지원하지 않습니다.
+	if _, err := ToLLVM(fd); err == nil || !strings.Contains(err.Error(), "call") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// 본문의 길이가 맞지 않는 프로시저 정의
+	code := append([]byte(nil), codeOf(t, &fd)...)
+	code[len(code)-3]++
+	vm := &MinFuckVM{Code: code, Encoding: EncodingV2, Mem: make([]uint32, 16)}
+	if _, err := runBackend(vm, BackendClosure, ""); err == nil || !strings.Contains(err.Error(), "구조화되지 않은 프로시저") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package pg
+
+import (
+	"github.com/cr0sh/minfuck/mf"
+	"github.com/cr0sh/minfuck/mf/build"
+)
+
+// Options 구조체는 Compile의 설정입니다.
+type Options struct {
+	SourceMap bool        // 소스맵을 생성하여 SMAP 섹션에 기록할지 여부
+	Meta      mf.Metadata // META 섹션에 기록할 메타데이터
+}
+
+// Compile 함수는 소스 코드를 MinFuck 파일로 컴파일합니다. 소스맵을 생성하지 않으면 sm은 nil입니다.
+// 구문이나 변수 선언에 오류가 있으면 위치를 포함한 *Error를 반환합니다.
+func Compile(src string, opts Options) (fd mf.FileData, sm *mf.SourceMap, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*Error)
+			if !ok {
+				panic(r)
+			}
+			err = e
+		}
+	}()
+	prog, vars := parse(src)
+
+	b := build.New()
+	if opts.SourceMap {
+		sm = new(mf.SourceMap)
+		b.SetSourceMap(sm)
+	}
+	g := &gen{b: b, vars: b.AllocN(vars)}
+	g.stmts(prog)
+
+	fd = b.FileData()
+	if len(opts.Meta) > 0 {
+		fd.SetMetadata(opts.Meta)
+	}
+	if sm != nil {
+		// SMAP 섹션은 소스의 줄이 줄어들지 않아야 하므로, while의 조건처럼 앞의 줄로 돌아가는 엔트리는 버립니다.
+		kept := sm.Entries[:0]
+		for _, e := range sm.Entries {
+			if len(kept) == 0 || e.Src.Start.Line >= kept[len(kept)-1].Src.Start.Line && e.Src.End.Line >= e.Src.Start.Line {
+				kept = append(kept, e)
+			}
+		}
+		sm.Entries = kept
+		data, _ := sm.MarshalBinary()
+		fd.SetSection(mf.SectionSourceMap, data)
+	}
+	return fd, sm, nil
+}
+
+/*
+gen 구조체는 구문 트리를 build.Builder로 MinFuck 코드로 작성하는 상태입니다.
+
+ 변수는 선언된 순서대로 셀 하나씩을 차지하며, 식은 값을 계산하여 새로 할당한 셀에 기록합니다.
+ 곱셈과 비교, 나눗셈, 십진수 입출력은 build의 루틴을 사용합니다.
+*/
+type gen struct {
+	b    *build.Builder
+	vars []build.Cell // 각 변수의 셀
+}
+
+// expr 메서드는 식의 값을 계산하여 새로 할당한 셀에 기록하고 그 셀을 반환합니다.
+func (g *gen) expr(e *expr) build.Cell {
+	b := g.b
+	b.SetPos(e.pos)
+	switch e.op {
+	case "num":
+		c := b.Alloc()
+		b.Set(c, e.num)
+		return c
+	case "var":
+		c := b.Alloc()
+		b.Copy(g.vars[e.cell], c)
+		return c
+	case "neg":
+		x := g.expr(e.x)
+		c := b.Alloc()
+		b.MulAdd(x, c, ^uint32(0))
+		b.Free(x)
+		return c
+	case "!":
+		x := g.expr(e.x)
+		b.Not(x, x)
+		return x
+	}
+
+	// 한쪽이 상수인 곱셈은 비트를 구하지 않고 확장 명령어로 계산합니다.
+	if e.op == "*" && e.x.op == "num" {
+		e = &expr{op: e.op, pos: e.pos, x: e.y, y: e.x}
+	}
+	x := g.expr(e.x)
+	b.SetPos(e.pos)
+	if e.op == "*" && e.y.op == "num" {
+		t := b.Alloc()
+		b.MulAdd(x, t, e.y.num)
+		b.Set(x, 0)
+		b.Copy(t, x)
+		b.Free(t)
+		return x
+	}
+
+	y := g.expr(e.y)
+	b.SetPos(e.pos)
+	switch e.op {
+	case "+":
+		b.Copy(y, x)
+	case "-":
+		b.MulAdd(y, x, ^uint32(0))
+	case "*":
+		b.Mul(x, y, x)
+	case "/":
+		b.DivMod(x, y, x, y)
+	case "%":
+		b.DivMod(x, y, y, x)
+	case "==", "!=":
+		b.Equal(x, y, x)
+		if e.op == "!=" {
+			b.Not(x, x)
+		}
+	case "<", ">=":
+		b.Less(x, y, x)
+		if e.op == ">=" {
+			b.Not(x, x)
+		}
+	case ">", "<=":
+		b.Greater(x, y, x)
+		if e.op == "<=" {
+			b.Not(x, x)
+		}
+	case "&&":
+		b.And(x, y, x)
+	case "||":
+		b.Or(x, y, x)
+	}
+	b.Free(y)
+	return x
+}
+
+func (g *gen) stmts(prog []stmt) {
+	for _, s := range prog {
+		g.stmt(s)
+	}
+}
+
+// assign 메서드는 c번 셀의 값을 새로 계산한 x번 셀의 값으로 바꾸고 x번 셀을 해제합니다.
+func (g *gen) assign(c, x build.Cell) {
+	g.b.Set(c, 0)
+	g.b.Copy(x, c)
+	g.b.Free(x)
+}
+
+func (g *gen) stmt(s stmt) {
+	b := g.b
+	switch s := s.(type) {
+	case *assign:
+		b.SetPos(s.pos)
+		if s.x == nil {
+			b.Set(g.vars[s.cell], 0)
+			return
+		}
+		g.assign(g.vars[s.cell], g.expr(s.x))
+	case *ifStmt:
+		b.SetPos(s.pos)
+		c := g.expr(s.cond)
+		then := func() {
+			g.stmts(s.then)
+			b.SetPos(s.pos)
+		}
+		if len(s.els) > 0 {
+			b.IfElse(c, then, func() { g.stmts(s.els) })
+		} else {
+			b.If(c, then)
+		}
+		b.Free(c)
+	case *whileStmt:
+		b.SetPos(s.pos)
+		c := g.expr(s.cond)
+		b.Loop(c, func() {
+			g.stmts(s.body)
+			b.SetPos(s.pos)
+			g.assign(c, g.expr(s.cond))
+		})
+		b.Free(c)
+	case *printStmt:
+		for _, it := range s.items {
+			b.SetPos(s.pos)
+			if it.x == nil {
+				b.PrintString(it.str)
+			} else {
+				x := g.expr(it.x)
+				b.PrintNum(x)
+				b.Free(x)
+			}
+		}
+	case *readStmt:
+		for _, c := range s.cells {
+			b.SetPos(s.pos)
+			b.ReadNum(g.vars[c])
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Package pg는 Polygon 스타일의 간단한 구조적 언어를 MinFuck 코드로 컴파일합니다. (minfuck compile)
+//
+// 모든 값은 MinFuck의 셀과 같은 32비트 부호 없는 정수이며, 덧셈, 뺄셈, 곱셈은 2^32로 나눈 나머지를 계산합니다.
+// 변수는 var로 선언한 뒤에 사용할 수 있고, 초기값이 없으면 0입니다. 블록은 새로운 범위를 만들지 않습니다.
+//
+//	// 주석은 줄 끝까지입니다
+//	var n, i = 1;
+//	read n;
+//	while i <= n {
+//		if i % 15 == 0 {
+//			print "FizzBuzz\n";
+//		} else if i % 3 == 0 {
+//			print "Fizz\n";
+//		} else {
+//			print i, "\n";
+//		}
+//		i = i + 1;
+//	}
+//
+// 문장은 다음과 같습니다. print는 식을 십진수로 출력하고, read는 입력에서 십진수를 읽습니다.
+//
+//	var 이름 [= 식] {, 이름 [= 식]};
+//	이름 = 식;
+//	if 식 { ... } [else if 식 { ... }] [else { ... }]
+//	while 식 { ... }
+//	print 문자열 또는 식 {, 문자열 또는 식};
+//	read 이름 {, 이름};
+//
+// 식의 연산자는 우선순위가 높은 것부터 다음과 같습니다. 비교 연산자는 이어 쓸 수 없습니다.
+// 조건은 0이 아니면 참이며, 비교와 논리 연산의 결과는 0 또는 1입니다. 0으로 나누면 몫은 4294967295, 나머지는 나누어지는 수입니다.
+//
+//	단항 - !
+//	* / %
+//	+ -
+//	== != < <= > >=
+//	&&
+//	||
+//
+// 상수는 십진수나 'a', '\n' 같은 한 바이트 문자이며, 문자열은 Go의 이스케이프 문법을 따릅니다.
+//
+// 모든 연산은 값과 관계없이 일정한 수의 명령어로 실행됩니다. 곱셈과 비교, 나눗셈, 십진수 출력은 mf/build의 루틴으로 셀을 비트로 나누어
+// 계산하므로 다른 연산보다 코드가 길고, 특히 나눗셈과 십진수 출력은 수천 개의 명령어를 실행합니다.
+package pg
@@ -0,0 +1,147 @@
+package pg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cr0sh/minfuck/mf"
+)
+
+// Error 구조체는 소스 코드의 위치와 함께 컴파일 오류를 나타냅니다.
+type Error struct {
+	Pos mf.SourcePos
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// errorf 함수는 pos에서 발생한 컴파일 오류로 panic합니다. Compile이 recover하여 반환합니다.
+func errorf(pos mf.SourcePos, format string, args ...interface{}) {
+	panic(&Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+// tokKind는 토큰의 종류입니다.
+type tokKind int
+
+const (
+	tokEOF  tokKind = iota
+	tokName         // 변수 이름과 키워드
+	tokNum          // 숫자와 문자 상수
+	tokStr          // 문자열 상수
+	tokOp           // 연산자와 구두점
+)
+
+// token 구조체는 소스 코드의 토큰 하나입니다.
+type token struct {
+	kind tokKind
+	text string // 이름, 연산자 또는 문자열의 값
+	num  uint32
+	pos  mf.SourcePos
+}
+
+// keywords는 변수 이름으로 쓸 수 없는 키워드입니다.
+var keywords = map[string]bool{
+	"var": true, "if": true, "else": true, "while": true, "print": true, "read": true,
+}
+
+// operators는 연산자와 구두점입니다. 두 글자 연산자를 먼저 확인합니다.
+var operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"+", "-", "*", "/", "%", "<", ">", "!", "=", "(", ")", "{", "}", ",", ";",
+}
+
+// lex 함수는 소스 코드를 토큰으로 나눕니다. 마지막 토큰은 tokEOF입니다.
+func lex(src string) []token {
+	var toks []token
+	line, lineStart := 1, 0
+	for i := 0; i < len(src); {
+		pos := mf.SourcePos{Line: line, Col: i - lineStart + 1}
+		c := src[i]
+		switch {
+		case c == '\n':
+			i++
+			line, lineStart = line+1, i
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case isLetter(c):
+			j := i
+			for j < len(src) && (isLetter(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			toks = append(toks, token{kind: tokName, text: src[i:j], pos: pos})
+			i = j
+		case isDigit(c):
+			j := i
+			for j < len(src) && (isLetter(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			n, err := strconv.ParseUint(src[i:j], 10, 32)
+			if err != nil {
+				errorf(pos, "잘못된 숫자 %s (0 ~ 4294967295)", src[i:j])
+			}
+			toks = append(toks, token{kind: tokNum, num: uint32(n), pos: pos})
+			i = j
+		case c == '"' || c == '\'':
+			j := quoteEnd(src, i)
+			if j < 0 {
+				errorf(pos, "닫히지 않은 %c", c)
+			}
+			if c == '"' {
+				s, err := strconv.Unquote(src[i : j+1])
+				if err != nil {
+					errorf(pos, "잘못된 문자열 %s", src[i:j+1])
+				}
+				toks = append(toks, token{kind: tokStr, text: s, pos: pos})
+			} else {
+				v, mb, tail, err := strconv.UnquoteChar(src[i+1:j], '\'')
+				if err != nil || tail != "" || mb && v >= 0x80 {
+					errorf(pos, "문자 상수는 한 바이트여야 합니다: %s", src[i:j+1])
+				}
+				toks = append(toks, token{kind: tokNum, num: uint32(v), pos: pos})
+			}
+			i = j + 1
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				errorf(pos, "알 수 없는 문자 %q", c)
+			}
+			toks = append(toks, token{kind: tokOp, text: op, pos: pos})
+			i += len(op)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: mf.SourcePos{Line: line, Col: len(src) - lineStart + 1}})
+}
+
+// quoteEnd 함수는 src[i]의 따옴표와 짝이 맞는 따옴표의 오프셋을 반환합니다. 줄이 끝날 때까지 없으면 -1을 반환합니다.
+func quoteEnd(src string, i int) int {
+	for j := i + 1; j < len(src) && src[j] != '\n'; j++ {
+		switch src[j] {
+		case '\\':
+			j++
+		case src[i]:
+			return j
+		}
+	}
+	return -1
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,284 @@
+package pg
+
+import "github.com/cr0sh/minfuck/mf"
+
+// expr 구조체는 식의 구문 트리입니다.
+// op는 "num"(상수), "var"(변수), "neg"(단항 -), "!" 또는 이항 연산자입니다.
+type expr struct {
+	op   string
+	pos  mf.SourcePos
+	num  uint32 // op가 "num"일 때의 값
+	cell int    // op가 "var"일 때 변수의 셀
+	x, y *expr  // 피연산자 (단항 연산자는 x만 사용합니다)
+}
+
+// stmt는 문장의 구문 트리입니다. *assign, *ifStmt, *whileStmt, *printStmt, *readStmt 중 하나입니다.
+type stmt interface{}
+
+// assign 구조체는 대입문입니다. 초기값이 없는 변수 선언은 x가 nil입니다.
+type assign struct {
+	pos  mf.SourcePos
+	cell int
+	x    *expr
+}
+
+type ifStmt struct {
+	pos       mf.SourcePos
+	cond      *expr
+	then, els []stmt
+}
+
+type whileStmt struct {
+	pos  mf.SourcePos
+	cond *expr
+	body []stmt
+}
+
+// printStmt 구조체는 print 문입니다. 각 항목은 문자열(str)이거나 숫자로 출력할 식(x)입니다.
+type printStmt struct {
+	pos   mf.SourcePos
+	items []printItem
+}
+
+type printItem struct {
+	str string
+	x   *expr
+}
+
+type readStmt struct {
+	pos   mf.SourcePos
+	cells []int
+}
+
+// parser 구조체는 토큰을 읽어 구문 트리를 만드는 상태입니다.
+type parser struct {
+	toks []token
+	i    int
+	vars map[string]int // 선언된 변수의 셀
+}
+
+// parse 함수는 소스 코드의 구문 트리와 선언된 변수의 수를 반환합니다.
+func parse(src string) ([]stmt, int) {
+	p := &parser{toks: lex(src), vars: make(map[string]int)}
+	var prog []stmt
+	for p.peek().kind != tokEOF {
+		prog = append(prog, p.stmt()...)
+	}
+	return prog, len(p.vars)
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// is 메서드는 다음 토큰이 text인 연산자나 키워드인지 확인합니다.
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == tokOp || t.kind == tokName) && t.text == text
+}
+
+// accept 메서드는 다음 토큰이 text이면 읽고 true를 반환합니다.
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) token {
+	if !p.is(text) {
+		p.unexpected(text)
+	}
+	return p.next()
+}
+
+// unexpected 메서드는 다음 토큰이 want가 아니라는 오류로 panic합니다.
+func (p *parser) unexpected(want string) {
+	t := p.peek()
+	got := t.text
+	switch t.kind {
+	case tokEOF:
+		got = "파일의 끝"
+	case tokNum:
+		got = "숫자"
+	case tokStr:
+		got = "문자열"
+	}
+	errorf(t.pos, "%s이(가) 필요하지만 %s이(가) 있습니다", want, got)
+}
+
+// name 메서드는 변수 이름을 읽습니다.
+func (p *parser) name() token {
+	t := p.peek()
+	if t.kind != tokName || keywords[t.text] {
+		p.unexpected("변수 이름")
+	}
+	return p.next()
+}
+
+// variable 메서드는 선언된 변수의 이름을 읽고 그 셀을 반환합니다.
+func (p *parser) variable() int {
+	t := p.name()
+	c, ok := p.vars[t.text]
+	if !ok {
+		errorf(t.pos, "선언되지 않은 변수 %s", t.text)
+	}
+	return c
+}
+
+// stmt 메서드는 문장 하나를 읽습니다. 변수 선언은 변수마다 대입문 하나가 됩니다.
+func (p *parser) stmt() []stmt {
+	t := p.peek()
+	switch {
+	case p.accept("var"):
+		var decl []stmt
+		for {
+			n := p.name()
+			if _, ok := p.vars[n.text]; ok {
+				errorf(n.pos, "이미 선언된 변수 %s", n.text)
+			}
+			a := &assign{pos: n.pos}
+			if p.accept("=") {
+				a.x = p.expr()
+			}
+			// 초기값 안에서는 아직 선언되지 않은 변수입니다.
+			a.cell = len(p.vars)
+			p.vars[n.text] = a.cell
+			decl = append(decl, a)
+			if !p.accept(",") {
+				break
+			}
+		}
+		p.expect(";")
+		return decl
+	case p.accept("if"):
+		return []stmt{p.ifStmt(t)}
+	case p.accept("while"):
+		return []stmt{&whileStmt{pos: t.pos, cond: p.expr(), body: p.block()}}
+	case p.accept("print"):
+		s := &printStmt{pos: t.pos}
+		for {
+			if p.peek().kind == tokStr {
+				s.items = append(s.items, printItem{str: p.next().text})
+			} else {
+				s.items = append(s.items, printItem{x: p.expr()})
+			}
+			if !p.accept(",") {
+				break
+			}
+		}
+		p.expect(";")
+		return []stmt{s}
+	case p.accept("read"):
+		s := &readStmt{pos: t.pos}
+		for {
+			s.cells = append(s.cells, p.variable())
+			if !p.accept(",") {
+				break
+			}
+		}
+		p.expect(";")
+		return []stmt{s}
+	case t.kind == tokName && !keywords[t.text]:
+		a := &assign{pos: t.pos, cell: p.variable()}
+		p.expect("=")
+		a.x = p.expr()
+		p.expect(";")
+		return []stmt{a}
+	}
+	p.unexpected("문장")
+	return nil
+}
+
+// ifStmt 메서드는 if 키워드 뒤의 조건과 블록을 읽습니다. else if는 else 블록 안의 if 문이 됩니다.
+func (p *parser) ifStmt(t token) stmt {
+	s := &ifStmt{pos: t.pos, cond: p.expr(), then: p.block()}
+	if p.accept("else") {
+		if t := p.peek(); p.accept("if") {
+			s.els = []stmt{p.ifStmt(t)}
+		} else {
+			s.els = p.block()
+		}
+	}
+	return s
+}
+
+func (p *parser) block() []stmt {
+	p.expect("{")
+	var body []stmt
+	for !p.accept("}") {
+		if p.peek().kind == tokEOF {
+			p.unexpected("}")
+		}
+		body = append(body, p.stmt()...)
+	}
+	return body
+}
+
+// binaryOps는 우선순위가 낮은 것부터 나열한 이항 연산자입니다.
+var binaryOps = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) expr() *expr {
+	return p.binary(0)
+}
+
+// binary 메서드는 우선순위가 level 이상인 연산자로 이루어진 식을 읽습니다. 비교 연산자는 이어 쓸 수 없습니다.
+func (p *parser) binary(level int) *expr {
+	if level == len(binaryOps) {
+		return p.unary()
+	}
+	x := p.binary(level + 1)
+	for {
+		t := p.peek()
+		op := ""
+		for _, o := range binaryOps[level] {
+			if t.kind == tokOp && t.text == o {
+				op = o
+			}
+		}
+		if op == "" {
+			return x
+		}
+		p.next()
+		x = &expr{op: op, pos: t.pos, x: x, y: p.binary(level + 1)}
+		if level == 2 {
+			return x
+		}
+	}
+}
+
+func (p *parser) unary() *expr {
+	t := p.peek()
+	switch {
+	case p.accept("-"):
+		return &expr{op: "neg", pos: t.pos, x: p.unary()}
+	case p.accept("!"):
+		return &expr{op: "!", pos: t.pos, x: p.unary()}
+	case p.accept("("):
+		x := p.expr()
+		p.expect(")")
+		return x
+	case t.kind == tokNum:
+		p.next()
+		return &expr{op: "num", pos: t.pos, num: t.num}
+	case t.kind == tokName && !keywords[t.text]:
+		return &expr{op: "var", pos: t.pos, cell: p.variable()}
+	}
+	p.unexpected("식")
+	return nil
+}
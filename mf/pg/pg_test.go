@@ -0,0 +1,128 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/cr0sh/minfuck/mf"
+	"github.com/cr0sh/minfuck/mf/internal/vmtest"
+)
+
+var compileTestEntries = []struct {
+	src string
+	in  string
+	out string
+}{
+	{`print "Hello, World!\n";`, "", "Hello, World!\n"},
+	{`var a = 7, b = 3; print a + b, " ", a - b, " ", a * b, " ", a / b, " ", a % b;`, "", "10 4 21 2 1"},
+	{`var a = 2 + 3 * 4 - (1 + 1) * 2; print a;`, "", "10"},
+	{`var x = 0; print x, ",", 4294967295, ",", 0 - 1, ",", -5 + 6;`, "", "0,4294967295,4294967295,1"},
+	{`var x = 100000; print x * 1000, " ", x * x;`, "", "100000000 1410065408"},
+	{`var z = 0; print 7 / 0, " ", 7 % z, " ", 0 / 3, " ", 7 / z;`, "", "4294967295 7 0 4294967295"},
+	{`var a = 4294967295, b = 3000000000, c = 65536; print a / b, " ", a % b, " ", a / c, " ", a % c, " ", b / 7, " ", b % 7;`, "", "1 1294967295 65535 65535 428571428 4"},
+	{`var a = 123456789, b = 1000; print a * b, " ", b * a, " ", a / b, " ", a % b, " ", (a - 1) / (b + 1);`, "", "3197704712 3197704712 123456 789 123333"},
+	{`var a = 4294967295, b = 4294967294; print a < b, a > b, a <= a, b >= a, b < 7, 7 < b, a > 2147483648, 2147483648 > a;`, "", "01100110"},
+	{`print 3 < 5, 5 < 3, 3 < 3, 3 <= 3, 4 <= 3, 5 > 3, 3 > 5, 3 >= 3, 2 >= 3;`, "", "100101010"},
+	{`print 0 < 4294967295, 4294967295 < 0, 1 == 1, 1 == 2, 1 != 2, 2 != 2;`, "", "101010"},
+	{`print 1 && 2, 0 && 1, 1 || 0, 0 || 0, !0, !7, !(1 < 2);`, "", "1010100"},
+	{`var c = 'A'; print c, " ", '\n', " ", 'z' - 'a';`, "", "65 10 25"},
+	{`var i = 0, s = 0; while i < 10 { i = i + 1; s = s + i; } print s;`, "", "55"},
+	{`var x; x = 5; if x > 3 { print "big"; } else { print "small"; }`, "", "big"},
+	{`var x = 2; if x > 3 { print "big"; } else if x == 2 { print "two"; } else { print "small"; }`, "", "two"},
+	{`var x = 0; if x { print "yes"; } print "end";`, "", "end"},
+	{`var a, b; read a, b; print a + b, "\n";`, "12 30\n", "42\n"},
+	{`var a; read a; print a;`, "  x-4096!", "4096"},
+	{`var a = 5; read a; print a;`, "", "0"},
+	{`var n, i = 1; read n;
+while i <= n {
+	if i % 15 == 0 { print "FizzBuzz\n"; }
+	else if i % 3 == 0 { print "Fizz\n"; }
+	else if i % 5 == 0 { print "Buzz\n"; }
+	else { print i, "\n"; }
+	i = i + 1;
+}`, "15", "1\n2\nFizz\n4\nBuzz\nFizz\n7\n8\nFizz\nBuzz\n11\nFizz\n13\n14\nFizzBuzz\n"},
+	{`// 소수 출력
+var n = 2;
+while n < 30 {
+	var d = 2, prime = 1;
+	while d * d <= n && prime {
+		if n % d == 0 { prime = 0; }
+		d = d + 1;
+	}
+	if prime { print n, " "; }
+	n = n + 1;
+}`, "", "2 3 5 7 11 13 17 19 23 29 "},
+}
+
+func TestCompile(t *testing.T) {
+	for n, test := range compileTestEntries {
+		fd, _, err := Compile(test.src, Options{})
+		if err != nil {
+			t.Fatalf("Test #%d failed: %v", n+1, err)
+		}
+		for _, b := range []mf.Backend{mf.BackendInterp, mf.BackendClosure} {
+			if out, err := vmtest.Run(fd, b, test.in); err != nil || out != test.out {
+				t.Errorf("Test #%d failed (backend %v): got %q (%v), expected %q", n+1, b, out, err, test.out)
+			}
+		}
+	}
+}
+
+var compileErrorTestEntries = []struct {
+	src string
+	err string
+}{
+	{`x = 1;`, "1:1: 선언되지 않은 변수 x"},
+	{`var x; var x;`, "1:12: 이미 선언된 변수 x"},
+	{`var x = x;`, "1:9: 선언되지 않은 변수 x"},
+	{`var if;`, "1:5: 변수 이름이(가) 필요하지만 if이(가) 있습니다"},
+	{"var x\nprint x;", "2:1: ;이(가) 필요하지만 print이(가) 있습니다"},
+	{`var x = 1 < 2 < 3;`, "1:15: ;이(가) 필요하지만 <이(가) 있습니다"},
+	{`while 1 { print 1;`, "1:19: }이(가) 필요하지만 파일의 끝이(가) 있습니다"},
+	{`print "abc;`, "1:7: 닫히지 않은 \""},
+	{`print 'ab';`, "1:7: 문자 상수는 한 바이트여야 합니다: 'ab'"},
+	{`print 4294967296;`, "1:7: 잘못된 숫자 4294967296 (0 ~ 4294967295)"},
+	{`print 1 $ 2;`, "1:9: 알 수 없는 문자 '$'"},
+	{`print;`, "1:6: 식이(가) 필요하지만 ;이(가) 있습니다"},
+}
+
+func TestCompileErrors(t *testing.T) {
+	for n, test := range compileErrorTestEntries {
+		if _, _, err := Compile(test.src, Options{}); err == nil || err.Error() != test.err {
+			t.Errorf("Test #%d failed: got %v, expected %q", n+1, err, test.err)
+		}
+	}
+}
+
+func TestCompileSourceMap(t *testing.T) {
+	src := "var a = 1;\nwhile a < 3 {\n\ta = a + 1;\n}\nprint a;\n"
+	fd, sm, err := Compile(src, Options{SourceMap: true, Meta: mf.Metadata{{Key: "name", Value: "test"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm == nil {
+		t.Fatal("소스맵이 없습니다")
+	}
+	code, err := fd.Code()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make(map[int]bool)
+	for pc := uint64(0); pc < uint64(len(code)*2); pc++ {
+		if r, ok := sm.Lookup(pc); ok {
+			lines[r.Start.Line] = true
+		}
+	}
+	if len(lines) != 4 || lines[4] {
+		t.Errorf("got lines %v, expected 1, 2, 3 and 5", lines)
+	}
+	b, _ := fd.Section(mf.SectionSourceMap)
+	var loaded mf.SourceMap
+	if err := loaded.UnmarshalBinary(b); err != nil || len(loaded.Entries) != len(sm.Entries) {
+		t.Errorf("got %d entries (%v), expected %d", len(loaded.Entries), err, len(sm.Entries))
+	}
+	if m, err := fd.Metadata(); err != nil || fd.MemSize() < 2 {
+		t.Errorf("got memsize %d, metadata %v (%v)", fd.MemSize(), m, err)
+	} else if v, _ := m.Get("name"); v != "test" {
+		t.Errorf("got name %q, expected %q", v, "test")
+	}
+}
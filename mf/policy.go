@@ -0,0 +1,79 @@
+package mf
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// EOF 처리 방식입니다. MinFuckVM은 입력이 끝나면 셀을 0으로 설정합니다.
+const (
+	EOFZero      = "0"         // 셀을 0으로 설정합니다
+	EOFMinusOne  = "-1"        // 셀을 -1(모든 비트가 1)로 설정합니다
+	EOFUnchanged = "unchanged" // 셀을 바꾸지 않습니다
+)
+
+// 테이프 처리 방식입니다. MinFuckVM은 범위 밖의 셀에 접근하면 ErrOutOfRange 오류를 반환합니다.
+const (
+	TapeFixed = "fixed" // 범위 밖으로 이동하면 오류 메시지를 출력하고 종료 코드 2로 종료합니다
+	TapeWrap  = "wrap"  // 메모리 포인터가 테이프의 반대쪽 끝으로 이어집니다
+	TapeGrow  = "grow"  // 오른쪽 끝을 넘어가면 테이프를 늘립니다
+)
+
+// Policy 구조체는 코드를 생성할 때의 셀 크기, EOF와 테이프 처리 방식을 정의합니다.
+// 비어 있는 값은 META 섹션의 cell-width, eof, tape 값을, 그마저 없으면 MinFuckVM과 같은 기본값을 사용합니다.
+type Policy struct {
+	CellWidth int    // 셀 크기 (8, 16, 32비트, 기본값 32)
+	EOF       string // EOF 처리 방식 (기본값 EOFZero)
+	Tape      string // 테이프 처리 방식 (기본값 TapeFixed)
+}
+
+// resolve 메서드는 비어 있는 값을 메타데이터와 기본값으로 채우고, 값이 올바른지 확인합니다.
+func (p Policy) resolve(fd *FileData) (Policy, error) {
+	meta, err := fd.Metadata()
+	if err != nil {
+		return p, err
+	}
+	if p.CellWidth == 0 {
+		if v, ok := meta.Get(MetaCellWidth); ok {
+			if p.CellWidth, err = strconv.Atoi(v); err != nil {
+				return p, fmt.Errorf("잘못된 셀 크기: %q", v)
+			}
+		} else {
+			p.CellWidth = 32
+		}
+	}
+	if p.EOF == "" {
+		p.EOF, _ = meta.Get(MetaEOF)
+	}
+	if p.Tape == "" {
+		p.Tape, _ = meta.Get(MetaTape)
+	}
+	if p.EOF == "" {
+		p.EOF = EOFZero
+	}
+	if p.Tape == "" {
+		p.Tape = TapeFixed
+	}
+
+	switch p.CellWidth {
+	case 8, 16, 32:
+	default:
+		return p, fmt.Errorf("지원하지 않는 셀 크기: %d", p.CellWidth)
+	}
+	switch p.EOF {
+	case EOFZero, EOFMinusOne, EOFUnchanged:
+	default:
+		return p, fmt.Errorf("지원하지 않는 EOF 처리 방식: %q", p.EOF)
+	}
+	switch p.Tape {
+	case TapeFixed, TapeWrap, TapeGrow:
+	default:
+		return p, fmt.Errorf("지원하지 않는 테이프 처리 방식: %q", p.Tape)
+	}
+	return p, nil
+}
+
+// tapeSize 함수는 VMFile과 같은 방식으로 계산한 메모리 셀의 수를 반환합니다.
+func tapeSize(memsize uint32) uint64 {
+	return 8 + uint64(memsize)*2
+}
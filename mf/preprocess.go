@@ -0,0 +1,598 @@
+package mf
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxRepeat는 반복 축약(+*10, (>)*3)으로 지정할 수 있는 반복 횟수의 최댓값입니다.
+const maxRepeat = 1 << 20
+
+// Origin 구조체는 전처리하기 전 원본 파일의 위치입니다.
+type Origin struct {
+	File string
+	Pos  SourcePos
+}
+
+// String 메서드는 위치를 "파일:줄:열" 형식으로 변환합니다.
+func (o Origin) String() string {
+	return o.File + ":" + o.Pos.String()
+}
+
+// PPError 구조체는 전처리에 실패하거나, 전처리한 소스 코드를 해석하는 데 실패한 원본 파일의 위치와 이유입니다.
+type PPError struct {
+	Origin
+	Msg string
+}
+
+func (e *PPError) Error() string {
+	return e.Origin.String() + ": " + e.Msg
+}
+
+// ExpansionEntry 구조체는 전처리 결과의 한 줄 안의 구간과, 그 구간이 생성된 원본 파일의 위치를 나타냅니다.
+// 구간의 문자들은 원본의 한 줄에서 연속된 문자들입니다.
+type ExpansionEntry struct {
+	Out  SourcePos // 결과에서 구간이 시작하는 위치
+	Len  int       // 구간의 문자 수
+	File int       // 원본 파일의 번호 (ExpansionMap.Files)
+	Src  SourcePos // 원본에서 구간이 시작하는 위치
+}
+
+/*
+ExpansionMap 구조체는 전처리 결과의 위치를 원본 파일의 위치에 대응시킵니다.
+
+ 매크로 본문에서 온 문자는 #define이 있는 위치에, 인자에서 온 문자는 매크로를 사용한 위치에 대응됩니다.
+ 반복 축약으로 생성된 문자는 모두 반복한 문자의 위치에 대응됩니다.
+ 엔트리는 결과 위치의 오름차순으로 정렬되어 있습니다.
+*/
+type ExpansionMap struct {
+	Files   []string // 원본 파일 이름 (Files[0]은 전처리한 파일입니다)
+	Entries []ExpansionEntry
+}
+
+// Lookup 메서드는 전처리 결과의 위치를 생성한 원본 파일의 위치를 반환합니다.
+func (m *ExpansionMap) Lookup(pos SourcePos) (Origin, bool) {
+	i := sort.Search(len(m.Entries), func(i int) bool {
+		return pos.before(m.Entries[i].Out)
+	}) - 1
+	if i < 0 {
+		return Origin{}, false
+	}
+	e := m.Entries[i]
+	if e.Out.Line != pos.Line || pos.Col >= e.Out.Col+e.Len {
+		return Origin{}, false
+	}
+	return Origin{File: m.Files[e.File], Pos: SourcePos{Line: e.Src.Line, Col: e.Src.Col + pos.Col - e.Out.Col}}, true
+}
+
+// Translate 메서드는 전처리 결과를 해석하다 발생한 SourceError를 원본 파일의 위치를 가리키는 PPError로 바꿉니다.
+// m이 nil이거나, err가 SourceError가 아니거나, 위치를 원본에 대응시킬 수 없으면 err를 그대로 반환합니다.
+func (m *ExpansionMap) Translate(err error) error {
+	var se *SourceError
+	if m == nil || !errors.As(err, &se) {
+		return err
+	}
+	o, ok := m.Lookup(se.Pos)
+	if !ok {
+		return err
+	}
+	return &PPError{Origin: o, Msg: se.Msg}
+}
+
+/*
+Remap 메서드는 전처리 결과에 대한 소스맵을 전처리한 파일(Files[0])에 대한 소스맵으로 바꿉니다.
+
+ 소스맵은 파일 하나의 위치만 담을 수 있으므로, #include로 포함한 파일에서 온 구간은 소스맵에서 제외됩니다.
+ 소스맵의 엔트리는 원본 위치의 줄 순서대로 기록되어야 하므로, 원본 위치의 줄이 앞의 엔트리보다 앞서는 구간도 제외됩니다.
+ 앞에서 #define한 매크로의 본문에서 온 구간이 대표적이며, 이런 명령어는 소스맵으로 원본 위치를 찾을 수 없습니다.
+*/
+func (m *ExpansionMap) Remap(sm *SourceMap) {
+	entries := sm.Entries[:0]
+	var last SourcePos
+	for _, e := range sm.Entries {
+		start, ok1 := m.Lookup(e.Src.Start)
+		end, ok2 := m.Lookup(e.Src.End)
+		if !ok1 || !ok2 || start.File != m.Files[0] || end.File != m.Files[0] ||
+			end.Pos.before(start.Pos) || start.Pos.Line < last.Line {
+			continue
+		}
+		e.Src = SourceRange{Start: start.Pos, End: end.Pos}
+		entries = append(entries, e)
+		last = start.Pos
+	}
+	sm.Entries = entries
+}
+
+// pchar 구조체는 전처리 중인 문자와 그 문자가 생성된 원본 파일의 위치입니다.
+type pchar struct {
+	r    rune
+	file int
+	pos  SourcePos
+}
+
+// macro 구조체는 #define으로 정의한 매크로입니다.
+type macro struct {
+	params []string // 인자의 이름 (인자가 없는 매크로이면 nil)
+	body   []pchar
+	def    pchar // #define의 위치
+}
+
+// ppCond 구조체는 처리 중인 #ifdef, #ifndef 블록입니다.
+type ppCond struct {
+	active bool  // 블록 안의 줄을 사용하는지 여부
+	outer  bool  // 블록을 둘러싼 블록의 줄을 사용하는지 여부
+	inElse bool  // #else 다음인지 여부
+	at     pchar // #ifdef, #ifndef의 위치
+}
+
+/*
+Preprocessor 구조체는 Brainfuck 소스 코드의 매크로 전처리기입니다. (minfuck b2m --pp, bfr --pp)
+
+ 줄의 첫 문자(공백 제외)가 # 이고 바로 뒤에 이름이 오는 줄은 지시문입니다. # 뒤에 공백이 오면 지시문이 아닙니다.
+
+ #define NAME 본문
+ #define NAME(a, b) 본문
+ 매크로를 정의합니다. 줄 끝의 \ 는 다음 줄로 본문을 이어 갑니다.
+ 이후 소스 코드에서 NAME과 같은 이름(영문자, 숫자, _)은 본문으로 바뀌며, 본문 안의 매크로도 차례로 바뀝니다.
+ 인자가 있는 매크로는 NAME(인자1; 인자2)로 사용하며, 본문의 인자 이름은 인자로 바뀝니다.
+ , 는 Brainfuck의 입력 명령어이므로 인자는 ; 로 구분합니다.
+
+ #undef NAME
+ 매크로의 정의를 지웁니다.
+
+ #include "file.bf"
+ 포함하는 파일이 있는 디렉터리를 기준으로 파일을 읽어 그 자리에 넣습니다. 순환하는 포함은 오류입니다.
+
+ #ifdef NAME, #ifndef NAME, #else, #endif
+ NAME이 정의되어 있는지에 따라 블록의 줄을 사용하거나 버립니다.
+
+ 매크로를 모두 바꾼 뒤, 문자 바로 뒤의 *N은 그 문자를 N번 반복하며(+*10), (...)*N은 괄호 안을 N번 반복합니다.
+ *N이 붙지 않은 괄호는 그대로 남습니다.
+*/
+type Preprocessor struct {
+	Defines  map[string]string                 // 미리 정의할 인자 없는 매크로 (minfuck --define)
+	ReadFile func(name string) ([]byte, error) // #include로 포함할 파일을 읽는 함수 (nil이면 ioutil.ReadFile)
+
+	files  []string
+	macros map[string]*macro
+	out    []pchar
+}
+
+// origin 메서드는 c가 생성된 원본 파일의 위치를 반환합니다.
+func (p *Preprocessor) origin(c pchar) Origin {
+	if c.file < 0 {
+		return Origin{File: "<define>", Pos: c.pos}
+	}
+	return Origin{File: p.files[c.file], Pos: c.pos}
+}
+
+// errorf 메서드는 c의 위치에서 발생한 PPError를 반환합니다.
+func (p *Preprocessor) errorf(c pchar, format string, a ...interface{}) error {
+	return &PPError{Origin: p.origin(c), Msg: fmt.Sprintf(format, a...)}
+}
+
+// Preprocess 메서드는 파일 name의 소스 코드 src를 전처리한 결과와, 결과를 원본 파일에 대응시키는 ExpansionMap을 반환합니다.
+// 오류의 위치는 원본 파일을 기준으로 하는 PPError로 반환합니다.
+func (p *Preprocessor) Preprocess(name, src string) (string, *ExpansionMap, error) {
+	p.files, p.macros, p.out = nil, make(map[string]*macro), nil
+	for k, v := range p.Defines {
+		p.macros[k] = &macro{body: charsAt(-1, SourcePos{Line: 1, Col: 1}, v), def: pchar{file: -1}}
+	}
+	if err := p.file(name, src, nil); err != nil {
+		return "", nil, err
+	}
+	text, err := p.expand(p.out, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	if text, _, _, err = p.repeat(text, 0, false); err != nil {
+		return "", nil, err
+	}
+
+	var sb strings.Builder
+	m := &ExpansionMap{Files: p.files}
+	out := SourcePos{Line: 1, Col: 1}
+	for i, c := range text {
+		sb.WriteRune(c.r)
+		if c.r == '\n' {
+			out.Line, out.Col = out.Line+1, 1
+			continue
+		}
+		if l := len(m.Entries) - 1; c.file >= 0 && i > 0 && l >= 0 {
+			e, prev := &m.Entries[l], text[i-1]
+			if e.Out.Line == out.Line && e.Out.Col+e.Len == out.Col && prev.file == c.file &&
+				prev.pos.Line == c.pos.Line && prev.pos.Col+1 == c.pos.Col {
+				e.Len++
+				out.Col++
+				continue
+			}
+		}
+		if c.file >= 0 {
+			m.Entries = append(m.Entries, ExpansionEntry{Out: out, Len: 1, File: c.file, Src: c.pos})
+		}
+		out.Col++
+	}
+	return sb.String(), m, nil
+}
+
+// PreprocessFile 함수는 파일을 읽어 전처리합니다.
+func PreprocessFile(name string) (string, *ExpansionMap, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return "", nil, err
+	}
+	return new(Preprocessor).Preprocess(name, string(b))
+}
+
+// charsAt 함수는 file 파일의 pos에서 시작하는 s를 원본 위치가 붙은 문자로 바꿉니다.
+// file이 음수이면 원본 위치가 없는 문자입니다. (Preprocessor.Defines)
+func charsAt(file int, pos SourcePos, s string) []pchar {
+	cs := make([]pchar, 0, len(s))
+	for _, r := range s {
+		cs = append(cs, pchar{r: r, file: file, pos: pos})
+		pos.Col++
+	}
+	return cs
+}
+
+// file 메서드는 파일 하나의 지시문을 처리하고 나머지 줄을 p.out에 더합니다. stack은 포함 중인 파일의 목록입니다.
+func (p *Preprocessor) file(name, src string, stack []string) error {
+	fi := len(p.files)
+	p.files = append(p.files, name)
+	stack = append(stack, name)
+
+	var conds []ppCond
+	active := func() bool {
+		return len(conds) == 0 || conds[len(conds)-1].active
+	}
+	lines := strings.Split(src, "\n")
+	for n := 0; n < len(lines); n++ {
+		line := charsAt(fi, SourcePos{Line: n + 1, Col: 1}, strings.TrimRight(lines[n], "\r"))
+		i := skipSpace(line, 0)
+		if i+1 >= len(line) || line[i].r != '#' || !isIdentStart(line[i+1].r) {
+			if active() {
+				p.out = append(p.out, line...)
+				p.out = append(p.out, pchar{r: '\n', file: fi, pos: SourcePos{Line: n + 1, Col: len(line) + 1}})
+			}
+			continue
+		}
+		at := line[i]
+		word, j := ident(line, i+1)
+		rest := line[skipSpace(line, j):]
+
+		switch word {
+		case "ifdef", "ifndef":
+			name, _ := ident(rest, 0)
+			if active() && name == "" {
+				return p.errorf(at, "#%s에 매크로 이름이 필요합니다", word)
+			}
+			_, defined := p.macros[name]
+			conds = append(conds, ppCond{active: active() && defined == (word == "ifdef"), outer: active(), at: at})
+			continue
+		case "else":
+			if len(conds) == 0 || conds[len(conds)-1].inElse {
+				return p.errorf(at, "짝이 맞지 않는 #else")
+			}
+			c := &conds[len(conds)-1]
+			c.active, c.inElse = c.outer && !c.active, true
+			continue
+		case "endif":
+			if len(conds) == 0 {
+				return p.errorf(at, "짝이 맞지 않는 #endif")
+			}
+			conds = conds[:len(conds)-1]
+			continue
+		}
+		if !active() {
+			continue
+		}
+
+		switch word {
+		case "define":
+			// 줄 끝의 \ 는 다음 줄로 본문을 이어 갑니다.
+			for len(rest) > 0 && rest[len(rest)-1].r == '\\' && n+1 < len(lines) {
+				n++
+				next := charsAt(fi, SourcePos{Line: n + 1, Col: 1}, strings.TrimRight(lines[n], "\r"))
+				rest = append(append(rest[:len(rest)-1:len(rest)-1], pchar{r: '\n', file: fi, pos: rest[len(rest)-1].pos}), next...)
+			}
+			if err := p.define(at, rest); err != nil {
+				return err
+			}
+		case "undef":
+			name, _ := ident(rest, 0)
+			if name == "" {
+				return p.errorf(at, "#undef에 매크로 이름이 필요합니다")
+			}
+			delete(p.macros, name)
+		case "include":
+			if len(rest) < 2 || rest[0].r != '"' {
+				return p.errorf(at, "#include에 \"파일 이름\"이 필요합니다")
+			}
+			var sb strings.Builder
+			k := 1
+			for ; k < len(rest) && rest[k].r != '"'; k++ {
+				sb.WriteRune(rest[k].r)
+			}
+			if k == len(rest) {
+				return p.errorf(at, "#include의 파일 이름이 \"로 끝나지 않습니다")
+			}
+			inc := filepath.Join(filepath.Dir(name), sb.String())
+			for s, f := range stack {
+				if filepath.Clean(f) == inc {
+					return p.errorf(at, "순환하는 #include: %s -> %s", strings.Join(stack[s:], " -> "), inc)
+				}
+			}
+			read := p.ReadFile
+			if read == nil {
+				read = ioutil.ReadFile
+			}
+			b, err := read(inc)
+			if err != nil {
+				return p.errorf(at, "%v", err)
+			}
+			if err := p.file(inc, string(b), stack); err != nil {
+				return err
+			}
+		default:
+			return p.errorf(at, "알 수 없는 지시문 #%s", word)
+		}
+	}
+	if len(conds) > 0 {
+		return p.errorf(conds[len(conds)-1].at, "#endif가 없습니다")
+	}
+	return nil
+}
+
+// define 메서드는 #define 다음의 이름, 인자 목록, 본문을 읽어 매크로를 정의합니다.
+func (p *Preprocessor) define(at pchar, s []pchar) error {
+	name, i := ident(s, 0)
+	if name == "" {
+		return p.errorf(at, "#define에 매크로 이름이 필요합니다")
+	}
+	if m, ok := p.macros[name]; ok && m.def.file >= 0 {
+		return p.errorf(at, "이미 %s에서 정의된 매크로 %s", p.origin(m.def), name)
+	}
+	m := &macro{def: at}
+	if i < len(s) && s[i].r == '(' {
+		m.params = []string{}
+		for i++; ; {
+			i = skipSpace(s, i)
+			if i < len(s) && s[i].r == ')' && len(m.params) == 0 {
+				i++
+				break
+			}
+			param, j := ident(s, i)
+			if param == "" {
+				return p.errorf(at, "매크로 %s의 인자 목록이 잘못되었습니다", name)
+			}
+			m.params = append(m.params, param)
+			if i = skipSpace(s, j); i < len(s) && s[i].r == ',' {
+				i++
+				continue
+			} else if i < len(s) && s[i].r == ')' {
+				i++
+				break
+			}
+			return p.errorf(at, "매크로 %s의 인자 목록이 잘못되었습니다", name)
+		}
+	}
+	m.body = trimSpace(s[i:])
+	p.macros[name] = m
+	return nil
+}
+
+// expand 메서드는 text의 매크로를 모두 바꿉니다. active는 본문을 바꾸고 있는 매크로의 목록입니다.
+func (p *Preprocessor) expand(text []pchar, active []string) ([]pchar, error) {
+	var out []pchar
+	for i := 0; i < len(text); {
+		if !isIdentStart(text[i].r) || (i > 0 && isIdentChar(text[i-1].r)) {
+			out = append(out, text[i])
+			i++
+			continue
+		}
+		name, j := ident(text, i)
+		m, ok := p.macros[name]
+		if !ok {
+			out = append(out, text[i:j]...)
+			i = j
+			continue
+		}
+		for _, a := range active {
+			if a == name {
+				return nil, p.errorf(text[i], "재귀하는 매크로 %s", name)
+			}
+		}
+		body := m.body
+		if m.params != nil {
+			args, k, err := p.args(text, i, j, name)
+			if err != nil {
+				return nil, err
+			}
+			if len(args) != len(m.params) {
+				return nil, p.errorf(text[i], "매크로 %s의 인자는 %d개이지만 %d개가 주어졌습니다", name, len(m.params), len(args))
+			}
+			for a := range args {
+				if args[a], err = p.expand(args[a], active); err != nil {
+					return nil, err
+				}
+			}
+			body = substitute(body, m.params, args)
+			j = k
+		}
+		exp, err := p.expand(body, append(active[:len(active):len(active)], name))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, exp...)
+		i = j
+	}
+	return out, nil
+}
+
+// args 메서드는 text[j]에서 시작하는 (인자1; 인자2) 를 읽어 인자 목록과 ) 다음의 위치를 반환합니다.
+func (p *Preprocessor) args(text []pchar, i, j int, name string) ([][]pchar, int, error) {
+	if j >= len(text) || text[j].r != '(' {
+		return nil, 0, p.errorf(text[i], "매크로 %s에 인자가 필요합니다", name)
+	}
+	var args [][]pchar
+	depth, start := 0, j+1
+	for k := j + 1; k < len(text); k++ {
+		switch text[k].r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			args = append(args, trimSpace(text[start:k]))
+			if len(args) == 1 && len(args[0]) == 0 {
+				args = nil
+			}
+			return args, k + 1, nil
+		case ';':
+			if depth == 0 {
+				args = append(args, trimSpace(text[start:k]))
+				start = k + 1
+			}
+		}
+	}
+	return nil, 0, p.errorf(text[j], "매크로 %s의 인자 목록이 )로 끝나지 않습니다", name)
+}
+
+// substitute 함수는 body의 인자 이름을 인자로 바꿉니다.
+func substitute(body []pchar, params []string, args [][]pchar) []pchar {
+	var out []pchar
+	for i := 0; i < len(body); {
+		if !isIdentStart(body[i].r) || (i > 0 && isIdentChar(body[i-1].r)) {
+			out = append(out, body[i])
+			i++
+			continue
+		}
+		name, j := ident(body, i)
+		k := 0
+		for k < len(params) && params[k] != name {
+			k++
+		}
+		if k < len(params) {
+			out = append(out, args[k]...)
+		} else {
+			out = append(out, body[i:j]...)
+		}
+		i = j
+	}
+	return out
+}
+
+/*
+repeat 메서드는 text[i]부터 반복 축약을 풀어 쓴 결과와 다음 위치를 반환합니다.
+
+ group이면 짝이 맞는 ) 까지 읽으며, 이때 closed는 ) 를 찾았는지 여부입니다.
+*/
+func (p *Preprocessor) repeat(text []pchar, i int, group bool) (out []pchar, next int, closed bool, err error) {
+	for i < len(text) {
+		c := text[i]
+		switch {
+		case c.r == '(':
+			sub, j, ok, err := p.repeat(text, i+1, true)
+			if err != nil {
+				return nil, 0, false, err
+			}
+			if !ok {
+				return append(append(out, c), sub...), j, false, nil
+			}
+			if n, k, ok, err := p.count(text, j); err != nil {
+				return nil, 0, false, err
+			} else if ok {
+				for ; n > 0; n-- {
+					out = append(out, sub...)
+				}
+				i = k
+				continue
+			}
+			out = append(append(append(out, c), sub...), text[j-1])
+			i = j
+		case c.r == ')' && group:
+			return out, i + 1, true, nil
+		case c.r == '*' && len(out) > 0 && !unicode.IsSpace(out[len(out)-1].r):
+			n, k, ok, err := p.count(text, i)
+			if err != nil {
+				return nil, 0, false, err
+			}
+			if !ok {
+				out = append(out, c)
+				i++
+				continue
+			}
+			last := out[len(out)-1]
+			for out = out[:len(out)-1]; n > 0; n-- {
+				out = append(out, last)
+			}
+			i = k
+		default:
+			out = append(out, c)
+			i++
+		}
+	}
+	return out, i, false, nil
+}
+
+// count 메서드는 text[i]에서 시작하는 *N을 읽어 N과 다음 위치를 반환합니다. *N이 아니면 ok는 false입니다.
+func (p *Preprocessor) count(text []pchar, i int) (n, next int, ok bool, err error) {
+	if i+1 >= len(text) || text[i].r != '*' || text[i+1].r < '0' || text[i+1].r > '9' {
+		return 0, 0, false, nil
+	}
+	j := i + 1
+	var sb strings.Builder
+	for ; j < len(text) && text[j].r >= '0' && text[j].r <= '9'; j++ {
+		sb.WriteRune(text[j].r)
+	}
+	v, err := strconv.Atoi(sb.String())
+	if err != nil || v > maxRepeat {
+		return 0, 0, false, p.errorf(text[i], "반복 횟수가 너무 큽니다: %s (최대 %d)", sb.String(), maxRepeat)
+	}
+	return v, j, true, nil
+}
+
+// ident 함수는 s[i]에서 시작하는 이름(영문자, 숫자, _)과 그 다음 위치를 반환합니다. 이름이 아니면 빈 문자열을 반환합니다.
+func ident(s []pchar, i int) (string, int) {
+	if i >= len(s) || !isIdentStart(s[i].r) {
+		return "", i
+	}
+	var sb strings.Builder
+	for ; i < len(s) && isIdentChar(s[i].r); i++ {
+		sb.WriteRune(s[i].r)
+	}
+	return sb.String(), i
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isIdentChar(r rune) bool {
+	return isIdentStart(r) || (r >= '0' && r <= '9')
+}
+
+// skipSpace 함수는 s[i]부터 공백을 건너뛴 위치를 반환합니다.
+func skipSpace(s []pchar, i int) int {
+	for i < len(s) && unicode.IsSpace(s[i].r) {
+		i++
+	}
+	return i
+}
+
+// trimSpace 함수는 s의 앞뒤 공백을 지웁니다.
+func trimSpace(s []pchar) []pchar {
+	s = s[skipSpace(s, 0):]
+	for len(s) > 0 && unicode.IsSpace(s[len(s)-1].r) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
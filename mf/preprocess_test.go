@@ -0,0 +1,171 @@
+package mf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ppFiles는 전처리기 테스트에서 #include로 읽을 수 있는 파일입니다.
+var ppFiles = map[string]string{
+	"io.bf":       "#define NL ++++++++++.[-]\n#define PUT(c) c.[-]\n",
+	"lib/a.bf":    "#include \"b.bf\"\nA",
+	"lib/b.bf":    "B",
+	"cycle/x.bf":  "#include \"y.bf\"\n",
+	"cycle/y.bf":  "\n#include \"x.bf\"\n",
+	"guard.bf":    "#ifndef GUARD\n#define GUARD\nG\n#endif\n",
+	"badline.bf":  "+\n  #bogus\n",
+	"unclosed.bf": "#ifdef X\n",
+}
+
+func ppReadFile(name string) ([]byte, error) {
+	if s, ok := ppFiles[filepath.ToSlash(name)]; ok {
+		return []byte(s), nil
+	}
+	return nil, os.ErrNotExist
+}
+
+var ppTestEntries = []struct {
+	src, out string
+	defs     map[string]string
+}{
+	{src: "+++.", out: "+++.\n"},
+	{src: "+*5>*3<*0.", out: "+++++>>>.\n"},
+	{src: "(>+)*3 (see (note)) x*y", out: ">+>+>+ (see (note)) x*y\n"},
+	{src: "((+)*2>)*2", out: "++>++>\n"},
+	{src: "#define INC +\nINC INC*3 INCx", out: "+ +++ INCx\n"},
+	{src: "#define MOVE(a, b) a[-b+a]\nMOVE(<; >)", out: "<[->+<]\n"},
+	{src: "#define TWICE(x) (x)*2\n#define ADD(n) +*n\nTWICE(ADD(3)>)", out: "+++>+++>\n"},
+	{src: "#define LONG +\\\n-\\\n.\nLONG", out: "+\n-\n.\n"},
+	{src: "#define A +\n#undef A\n#define A -\nA", out: "-\n"},
+	{src: "#include \"io.bf\"\nPUT(+*65)NL", out: "+++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++.[-]++++++++++.[-]\n"},
+	{src: "#include \"lib/a.bf\"\n", out: "B\nA\n\n"},
+	{src: "#include \"guard.bf\"\n#include \"guard.bf\"\n", out: "G\n\n"},
+	{src: "#ifdef X\nx\n#else\ny\n#ifdef Y\nz\n#endif\n#endif\n", out: "y\n"},
+	{src: "#ifdef X\nx\n#ifndef Y\nz\n#endif\n#endif\n", out: "xz\n", defs: map[string]string{"X": ""}},
+	{src: "#ifndef X\nx\n#else\ny\n#endif\nX", out: "y\n+++\n", defs: map[string]string{"X": "+++"}},
+	{src: "# not a directive\n", out: "# not a directive\n"},
+}
+
+func TestPreprocess(t *testing.T) {
+	for n, test := range ppTestEntries {
+		p := &Preprocessor{ReadFile: ppReadFile, Defines: test.defs}
+		out, _, err := p.Preprocess("main.bf", test.src)
+		// 결과 끝의 빈 줄은 비교하지 않습니다.
+		if err != nil || strings.Replace(out, "\n", "", -1) != strings.Replace(test.out, "\n", "", -1) {
+			t.Errorf("Test #%d failed: got %q (%v), expected %q", n+1, out, err, test.out)
+		}
+	}
+}
+
+var ppErrorTestEntries = []struct {
+	name, src, err string
+}{
+	{src: "#include \"cycle/x.bf\"", err: "cycle/y.bf:2:1: 순환하는 #include"},
+	{src: "#include \"none.bf\"", err: "main.bf:1:1: "},
+	{src: "\n  #include \"badline.bf\"", err: "badline.bf:2:3: 알 수 없는 지시문 #bogus"},
+	{src: "#include \"unclosed.bf\"", err: "unclosed.bf:1:1: #endif가 없습니다"},
+	{src: "+\n#endif", err: "main.bf:2:1: 짝이 맞지 않는 #endif"},
+	{src: "#ifdef A\n#else\n#else\n#endif", err: "main.bf:3:1: 짝이 맞지 않는 #else"},
+	{src: "#define A B\n#define B A\n+ A", err: "main.bf:2:11: 재귀하는 매크로 A"},
+	{src: "#define F(a) a\n\n  F", err: "main.bf:3:3: 매크로 F에 인자가 필요합니다"},
+	{src: "#define F(a, b) a\nF(+)", err: "main.bf:2:1: 매크로 F의 인자는 2개이지만 1개가 주어졌습니다"},
+	{src: "#define F(a, b a\n", err: "main.bf:1:1: 매크로 F의 인자 목록이 잘못되었습니다"},
+	{src: "#define A +\n#define A -", err: "main.bf:2:1: 이미 main.bf:1:1에서 정의된 매크로 A"},
+	{src: "+*99999999999", err: "main.bf:1:2: 반복 횟수가 너무 큽니다"},
+}
+
+func TestPreprocessErrors(t *testing.T) {
+	for n, test := range ppErrorTestEntries {
+		p := &Preprocessor{ReadFile: ppReadFile}
+		_, _, err := p.Preprocess("main.bf", test.src)
+		var pe *PPError
+		if !errors.As(err, &pe) || !strings.HasPrefix(filepath.ToSlash(err.Error()), test.err) {
+			t.Errorf("Test #%d failed: got %v, expected %q", n+1, err, test.err)
+		}
+	}
+}
+
+func TestExpansionMap(t *testing.T) {
+	src := "#include \"io.bf\"\n#define GO >>\n  +GO(-)*2 NL\n"
+	p := &Preprocessor{ReadFile: ppReadFile}
+	out, em, err := p.Preprocess("main.bf", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\n  +>>-- ++++++++++.[-]\n\n"; out != want {
+		t.Fatalf("unexpected output: %q, expected %q", out, want)
+	}
+	lookupTests := []struct {
+		pos  SourcePos
+		want string
+	}{
+		{SourcePos{Line: 2, Col: 3}, "main.bf:3:3"},  // +
+		{SourcePos{Line: 2, Col: 4}, "main.bf:2:12"}, // GO의 본문
+		{SourcePos{Line: 2, Col: 6}, "main.bf:3:7"},  // (-)*2
+		{SourcePos{Line: 2, Col: 7}, "main.bf:3:7"},
+		{SourcePos{Line: 2, Col: 9}, "io.bf:1:12"}, // NL의 본문
+		{SourcePos{Line: 2, Col: 22}, "io.bf:1:25"},
+	}
+	for _, test := range lookupTests {
+		if o, ok := em.Lookup(test.pos); !ok || o.String() != test.want {
+			t.Errorf("Lookup(%v) = %v, %v, expected %s", test.pos, o, ok, test.want)
+		}
+	}
+	if _, ok := em.Lookup(SourcePos{Line: 2, Col: 30}); ok {
+		t.Errorf("Lookup should fail past the end of a line")
+	}
+
+	// 포함한 파일과 앞에서 정의한 매크로의 본문은 소스맵에서 제외됩니다.
+	_, sm, _ := FromSource(out, Brainfuck, BfOptions{Mem: 16, SourceMap: true})
+	em.Remap(sm)
+	for _, e := range sm.Entries {
+		if e.Src.Start.Line != 3 {
+			t.Errorf("unexpected source map entry: %v", e)
+		}
+	}
+	if _, err := sm.MarshalBinary(); err != nil {
+		t.Error(err)
+	}
+}
+
+var ppTranslateTestEntries = []struct {
+	d    Dialect
+	src  string
+	want string
+}{
+	{ // Test #1: checkProcs error in a macro body
+		d:    Pbrain,
+		src:  "#define BODY +)\n\n  BODY\n",
+		want: "main.bf:1:15: 짝이 맞지 않는 )",
+	},
+	{ // Test #2: tokenizer error after macro expansion
+		d:    Ook,
+		src:  "#define X Ook. Ook.\nX Ook!\n",
+		want: "main.bf:2:3: 짝이 맞지 않는 Ook!",
+	},
+}
+
+func TestTranslateError(t *testing.T) {
+	for n, test := range ppTranslateTestEntries {
+		out, em, err := (&Preprocessor{}).Preprocess("main.bf", test.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = FromSource(out, test.d, BfOptions{Mem: 16})
+		var se *SourceError
+		if !errors.As(err, &se) {
+			t.Errorf("Test #%d failed: expected SourceError, got %v", n+1, err)
+			continue
+		}
+		if got := em.Translate(err).Error(); got != test.want {
+			t.Errorf("Test #%d failed: got %q, expected %q", n+1, got, test.want)
+		}
+		var nilMap *ExpansionMap
+		if nilMap.Translate(err) != err {
+			t.Errorf("Test #%d failed: nil ExpansionMap should return the error unchanged", n+1)
+		}
+	}
+}
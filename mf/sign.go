@@ -0,0 +1,166 @@
+package mf
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// SectionSignature는 서명 섹션의 ID입니다.
+//
+// 서명 섹션은 서명자의 ed25519 공개키(32바이트)와 서명(64바이트)으로 이루어집니다.
+// 서명은 SIGN 섹션을 제외한 파일 전체를 섹션 포맷으로 기록한 내용의 SHA-512 해시에 대한
+// Ed25519ph 서명이므로, 코드를 메모리에 올리지 않고도 검증할 수 있습니다.
+const SectionSignature = "SIGN"
+
+const signatureSize = ed25519.PublicKeySize + ed25519.SignatureSize
+
+var signatureOptions = &ed25519.Options{Hash: crypto.SHA512}
+
+// 서명 검증 시 반환되는 에러입니다.
+var (
+	ErrUnsigned      = errors.New("서명되지 않은 프로그램입니다")
+	ErrUnknownSigner = errors.New("신뢰할 수 없는 서명자입니다")
+	ErrBadSignature  = errors.New("서명이 올바르지 않습니다")
+	errMalformedSign = errors.New("서명 섹션의 형식이 잘못되었습니다")
+)
+
+// digest 메서드는 SIGN 섹션을 제외한 파일 내용의 SHA-512 해시를 계산합니다.
+func (f *FileData) digest() ([]byte, error) {
+	h := sha512.New()
+	h.Write([]byte(mfMagicSection))
+	h.Write(U32Bytes(f.memsize))
+
+	src := f.CodeSource()
+	h.Write([]byte(SectionCode))
+	h.Write(U32Bytes(uint32(src.Size())))
+	if _, err := io.Copy(h, io.NewSectionReader(src, 0, src.Size())); err != nil {
+		return nil, err
+	}
+	for _, s := range f.sections {
+		if s.id == SectionSignature {
+			continue
+		}
+		h.Write([]byte(s.id))
+		h.Write(U32Bytes(uint32(len(s.data))))
+		h.Write(s.data)
+	}
+	return h.Sum(nil), nil
+}
+
+// Sign 메서드는 주어진 개인키로 파일에 서명하여 SIGN 섹션을 추가합니다.
+// 기존 서명은 교체됩니다. 서명한 뒤 섹션을 변경하면 서명이 무효가 됩니다.
+func (f *FileData) Sign(priv ed25519.PrivateKey) error {
+	d, err := f.digest()
+	if err != nil {
+		return err
+	}
+	sig, err := priv.Sign(nil, d, signatureOptions)
+	if err != nil {
+		return err
+	}
+	pub := priv.Public().(ed25519.PublicKey)
+	f.setSection(SectionSignature, append(append([]byte{}, pub...), sig...))
+	return nil
+}
+
+// Signer 메서드는 서명자의 공개키를 반환합니다. 서명을 검증하지는 않습니다.
+func (f *FileData) Signer() (ed25519.PublicKey, error) {
+	b, ok := f.Section(SectionSignature)
+	if !ok {
+		return nil, ErrUnsigned
+	}
+	if len(b) != signatureSize {
+		return nil, errMalformedSign
+	}
+	return ed25519.PublicKey(b[:ed25519.PublicKeySize]), nil
+}
+
+// Verify 메서드는 파일이 신뢰하는 키 중 하나로 올바르게 서명되었는지 확인하고, 서명자의 공개키를 반환합니다.
+// 서명이 없으면 ErrUnsigned, 서명자가 trusted에 없으면 ErrUnknownSigner,
+// 서명이 맞지 않으면 ErrBadSignature를 반환합니다.
+// 임베더는 NewVM을 호출하기 전에 이 메서드로 프로그램을 검사해야 합니다.
+func (f *FileData) Verify(trusted []ed25519.PublicKey) (ed25519.PublicKey, error) {
+	pub, err := f.Signer()
+	if err != nil {
+		return nil, err
+	}
+	known := false
+	for _, k := range trusted {
+		if k.Equal(pub) {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, ErrUnknownSigner
+	}
+
+	b, _ := f.Section(SectionSignature)
+	d, err := f.digest()
+	if err != nil {
+		return nil, err
+	}
+	if err := ed25519.VerifyWithOptions(pub, d, b[ed25519.PublicKeySize:], signatureOptions); err != nil {
+		return nil, ErrBadSignature
+	}
+	return pub, nil
+}
+
+// ParsePublicKey 함수는 16진수로 기록된 ed25519 공개키를 읽어들입니다.
+func ParsePublicKey(b []byte) (ed25519.PublicKey, error) {
+	k, err := hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, err
+	}
+	if len(k) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("공개키의 길이가 잘못되었습니다: %d바이트", len(k))
+	}
+	return ed25519.PublicKey(k), nil
+}
+
+// ParsePrivateKey 함수는 16진수로 기록된 ed25519 개인키(64바이트) 또는 시드(32바이트)를 읽어들입니다.
+func ParsePrivateKey(b []byte) (ed25519.PrivateKey, error) {
+	k, err := hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, err
+	}
+	switch len(k) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(k), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(k), nil
+	}
+	return nil, fmt.Errorf("개인키의 길이가 잘못되었습니다: %d바이트", len(k))
+}
+
+// LoadTrustedKeys 함수는 디렉터리 안의 모든 .pub 파일을 신뢰하는 공개키로 읽어들입니다.
+func LoadTrustedKeys(dir string) ([]ed25519.PublicKey, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []ed25519.PublicKey
+	for _, fi := range files {
+		if fi.IsDir() || filepath.Ext(fi.Name()) != ".pub" {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+		k, err := ParsePublicKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fi.Name(), err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
@@ -0,0 +1,90 @@
+package mf
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(seed byte) ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+}
+
+func TestSignVerify(t *testing.T) {
+	ours, theirs := testKey(1), testKey(2)
+	trusted := []ed25519.PublicKey{ours.Public().(ed25519.PublicKey)}
+
+	fd, _ := FromBfCodeOpts(hwBfCode, BfOptions{Mem: 64, SourceMap: true})
+	if _, err := fd.Verify(trusted); err != ErrUnsigned {
+		t.Errorf("unsigned program: expected ErrUnsigned, got %v", err)
+	}
+	if err := fd.Sign(ours); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if _, err := fd.Verify(trusted); err != nil {
+		t.Errorf("signed program should verify: %v", err)
+	}
+
+	// 파일로 기록한 뒤 ReadFile과 OpenFile로 읽어도 서명이 유지되어야 합니다.
+	file := []byte(fd.String())
+	rfd, err := ReadFile(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ofd, err := OpenFile(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []FileData{rfd, ofd} {
+		if pub, err := f.Verify(trusted); err != nil || !pub.Equal(trusted[0]) {
+			t.Errorf("round-tripped program should verify: %v", err)
+		}
+	}
+
+	other := fd
+	other.sections = append([]section{}, fd.sections...)
+	other.Sign(theirs)
+	if _, err := other.Verify(trusted); err != ErrUnknownSigner {
+		t.Errorf("program signed by unknown key: expected ErrUnknownSigner, got %v", err)
+	}
+
+	tampered := append([]byte{}, file...)
+	tampered[20] ^= 1 // CODE 섹션 안의 한 바이트
+	tfd, _ := ReadFile(bytes.NewReader(tampered))
+	if _, err := tfd.Verify(trusted); err != ErrBadSignature {
+		t.Errorf("tampered program: expected ErrBadSignature, got %v", err)
+	}
+
+	rfd.SetSection("NOTE", []byte("added after signing"))
+	if _, err := rfd.Verify(trusted); err != ErrBadSignature {
+		t.Errorf("section added after signing: expected ErrBadSignature, got %v", err)
+	}
+}
+
+func TestLoadTrustedKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mfkeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	priv, err := ParsePrivateKey([]byte(hex.EncodeToString(bytes.Repeat([]byte{1}, ed25519.SeedSize))))
+	if err != nil || !priv.Equal(testKey(1)) {
+		t.Errorf("ParsePrivateKey should accept a seed: %v", err)
+	}
+	pub := testKey(1).Public().(ed25519.PublicKey)
+	ioutil.WriteFile(filepath.Join(dir, "ours.pub"), []byte(hex.EncodeToString(pub)+"\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0644)
+
+	keys, err := LoadTrustedKeys(dir)
+	if err != nil {
+		t.Fatalf("LoadTrustedKeys failed: %v", err)
+	}
+	if len(keys) != 1 || !keys[0].Equal(pub) {
+		t.Errorf("unexpected keys: %x", keys)
+	}
+}
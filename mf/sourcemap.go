@@ -0,0 +1,167 @@
+package mf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// SourcePos 구조체는 Brainfuck 소스 코드의 위치를 나타냅니다.
+// Line과 Col은 모두 1부터 시작하며, Col은 문자(rune) 단위입니다.
+type SourcePos struct {
+	Line int
+	Col  int
+}
+
+// String 메서드는 위치를 "줄:열" 형식으로 변환합니다.
+func (p SourcePos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// SourceError 구조체는 소스 코드의 위치와 함께 보고하는 해석 오류입니다.
+type SourceError struct {
+	Pos SourcePos
+	Msg string
+}
+
+func (e *SourceError) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+// before 메서드는 p가 q보다 앞선 위치인지 확인합니다.
+func (p SourcePos) before(q SourcePos) bool {
+	return p.Line < q.Line || (p.Line == q.Line && p.Col < q.Col)
+}
+
+// SourceRange 구조체는 Brainfuck 소스 코드의 범위를 나타냅니다. End도 범위에 포함됩니다.
+type SourceRange struct {
+	Start SourcePos
+	End   SourcePos
+}
+
+// String 메서드는 범위를 "줄:열-줄:열" 형식으로 변환합니다.
+func (r SourceRange) String() string {
+	if r.Start == r.End {
+		return r.Start.String()
+	}
+	return r.Start.String() + "-" + r.End.String()
+}
+
+// Contains 메서드는 주어진 위치가 범위 안에 있는지 확인합니다.
+func (r SourceRange) Contains(p SourcePos) bool {
+	return !p.before(r.Start) && !r.End.before(p)
+}
+
+// SourceMapEntry 구조체는 니블 구간 [Start, End)와 그 구간이 생성된 소스 범위를 나타냅니다.
+type SourceMapEntry struct {
+	Start uint64
+	End   uint64
+	Src   SourceRange
+}
+
+/*
+SourceMap 구조체는 MinFuck 니블 오프셋과 Brainfuck 소스 위치를 양방향으로 대응시킵니다.
+
+ 반복 압축된 니블코드는 압축 전 코드들이 차지하던 소스 범위 전체에 대응됩니다.
+ 엔트리는 니블 오프셋과 소스 위치 모두에 대해 오름차순으로 정렬되어 있어야 합니다.
+
+ 바이너리 포맷(SMAP 섹션)
+
+ 엔트리 수를 uvarint로 기록한 뒤, 각 엔트리마다 직전 엔트리와의 차이를 uvarint로 기록합니다:
+ Start - 직전 End, End - Start, 시작 줄 차이, 시작 열, 끝 줄 - 시작 줄, 끝 열
+*/
+type SourceMap struct {
+	Entries []SourceMapEntry
+}
+
+// add 메서드는 니블 구간과 소스 범위의 대응을 추가합니다.
+func (m *SourceMap) add(start, end uint64, src SourceRange) {
+	if start == end {
+		return
+	}
+	m.Entries = append(m.Entries, SourceMapEntry{Start: start, End: end, Src: src})
+}
+
+// Lookup 메서드는 주어진 니블 오프셋을 생성한 소스 범위를 반환합니다.
+func (m *SourceMap) Lookup(pc uint64) (SourceRange, bool) {
+	i := sort.Search(len(m.Entries), func(i int) bool {
+		return m.Entries[i].End > pc
+	})
+	if i == len(m.Entries) || m.Entries[i].Start > pc {
+		return SourceRange{}, false
+	}
+	return m.Entries[i].Src, true
+}
+
+// Nibbles 메서드는 주어진 소스 위치에서 생성된 니블 구간 [start, end)를 반환합니다.
+func (m *SourceMap) Nibbles(pos SourcePos) (start, end uint64, ok bool) {
+	i := sort.Search(len(m.Entries), func(i int) bool {
+		return !m.Entries[i].Src.End.before(pos)
+	})
+	if i == len(m.Entries) || !m.Entries[i].Src.Contains(pos) {
+		return 0, 0, false
+	}
+	return m.Entries[i].Start, m.Entries[i].End, true
+}
+
+// MarshalBinary 메서드는 encoding.BinaryMarshaler 인터페이스를 구현합니다.
+func (m *SourceMap) MarshalBinary() ([]byte, error) {
+	b := binary.AppendUvarint(nil, uint64(len(m.Entries)))
+	var last SourceMapEntry
+	for _, e := range m.Entries {
+		if e.Start < last.End || e.End < e.Start || e.Src.Start.Line < last.Src.Start.Line ||
+			e.Src.End.Line < e.Src.Start.Line {
+			return nil, fmt.Errorf("정렬되지 않은 소스맵 엔트리: %v", e)
+		}
+		b = binary.AppendUvarint(b, e.Start-last.End)
+		b = binary.AppendUvarint(b, e.End-e.Start)
+		b = binary.AppendUvarint(b, uint64(e.Src.Start.Line-last.Src.Start.Line))
+		b = binary.AppendUvarint(b, uint64(e.Src.Start.Col))
+		b = binary.AppendUvarint(b, uint64(e.Src.End.Line-e.Src.Start.Line))
+		b = binary.AppendUvarint(b, uint64(e.Src.End.Col))
+		last = e
+	}
+	return b, nil
+}
+
+// UnmarshalBinary 메서드는 encoding.BinaryUnmarshaler 인터페이스를 구현합니다.
+func (m *SourceMap) UnmarshalBinary(b []byte) error {
+	next := func() (uint64, error) {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+		b = b[n:]
+		return v, nil
+	}
+
+	cnt, err := next()
+	if err != nil {
+		return err
+	}
+	if cnt > uint64(len(b)) {
+		return io.ErrUnexpectedEOF
+	}
+	m.Entries = make([]SourceMapEntry, 0, cnt)
+	var last SourceMapEntry
+	for i := uint64(0); i < cnt; i++ {
+		var v [6]uint64
+		for j := range v {
+			if v[j], err = next(); err != nil {
+				return err
+			}
+		}
+		var e SourceMapEntry
+		e.Start = last.End + v[0]
+		e.End = e.Start + v[1]
+		e.Src.Start = SourcePos{Line: last.Src.Start.Line + int(v[2]), Col: int(v[3])}
+		e.Src.End = SourcePos{Line: e.Src.Start.Line + int(v[4]), Col: int(v[5])}
+		m.Entries = append(m.Entries, e)
+		last = e
+	}
+	if len(b) != 0 {
+		return fmt.Errorf("소스맵 끝에 %d바이트의 알 수 없는 데이터가 있습니다", len(b))
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package mf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSourceMapFromBf(t *testing.T) {
+	fd, sm := FromBfCodeOpts("+\n-->\n [.]", BfOptions{Mem: 16, SourceMap: true})
+	// 처음 9니블은 0번 셀로 이동하는 압축된 >이며 소스 위치가 없습니다.
+	// [ ]는 점프 대상을 포함한 17니블의 압축된 명령어입니다.
+	expect := []SourceMapEntry{
+		{9, 10, SourceRange{SourcePos{1, 1}, SourcePos{1, 1}}},
+		{10, 12, SourceRange{SourcePos{2, 1}, SourcePos{2, 2}}},
+		{12, 20, SourceRange{SourcePos{2, 3}, SourcePos{2, 3}}},
+		{20, 37, SourceRange{SourcePos{3, 2}, SourcePos{3, 2}}},
+		{37, 38, SourceRange{SourcePos{3, 3}, SourcePos{3, 3}}},
+		{38, 55, SourceRange{SourcePos{3, 4}, SourcePos{3, 4}}},
+	}
+	if !reflect.DeepEqual(sm.Entries, expect) {
+		t.Fatalf("source map mismatch:\ngot      %v\nexpected %v", sm.Entries, expect)
+	}
+
+	read, err := ReadFile(bytes.NewBufferString(fd.String()))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !bytes.Equal(codeOf(t, &read), codeOf(t, &fd)) {
+		t.Errorf("code mismatch after round trip")
+	}
+	rsm, err := read.SourceMap()
+	if err != nil || rsm == nil {
+		t.Fatalf("SourceMap failed: %v", err)
+	}
+	if !reflect.DeepEqual(rsm.Entries, expect) {
+		t.Errorf("source map mismatch after round trip:\ngot      %v\nexpected %v", rsm.Entries, expect)
+	}
+}
+
+var smLookupEntries = []struct {
+	pc  uint64
+	ok  bool
+	pos SourcePos
+}{
+	{pc: 0, ok: false},
+	{pc: 8, ok: false},
+	{pc: 9, ok: true, pos: SourcePos{1, 1}},
+	{pc: 11, ok: true, pos: SourcePos{2, 1}},
+	{pc: 19, ok: true, pos: SourcePos{2, 3}},
+	{pc: 30, ok: true, pos: SourcePos{3, 2}},
+	{pc: 54, ok: true, pos: SourcePos{3, 4}},
+	{pc: 55, ok: false},
+}
+
+func TestSourceMapLookup(t *testing.T) {
+	_, sm := FromBfCodeOpts("+\n-->\n [.]", BfOptions{Mem: 16, SourceMap: true})
+	for n, test := range smLookupEntries {
+		r, ok := sm.Lookup(test.pc)
+		if ok != test.ok || (ok && r.Start != test.pos) {
+			t.Errorf("Test #%d failed: Lookup(%d) = %v, %v", n+1, test.pc, r, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		start, end, ok := sm.Nibbles(test.pos)
+		if !ok || test.pc < start || test.pc >= end {
+			t.Errorf("Test #%d failed: Nibbles(%v) = [%d, %d), %v", n+1, test.pos, start, end, ok)
+		}
+	}
+	if _, _, ok := sm.Nibbles(SourcePos{3, 1}); ok {
+		t.Errorf("whitespace should not map to any nibble")
+	}
+}
+
+func TestSourceMapUnmarshalError(t *testing.T) {
+	sm := &SourceMap{Entries: []SourceMapEntry{
+		{8, 16, SourceRange{SourcePos{1, 1}, SourcePos{1, 8}}},
+		{20, 21, SourceRange{SourcePos{3, 2}, SourcePos{4, 1}}},
+	}}
+	b, err := sm.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	for i := 0; i < len(b); i++ {
+		if err := new(SourceMap).UnmarshalBinary(b[:i]); err == nil {
+			t.Errorf("truncated source map (%d bytes) should return error", i)
+		}
+	}
+	if err := new(SourceMap).UnmarshalBinary(append(b, 0)); err == nil {
+		t.Errorf("trailing data should return error")
+	}
+}
@@ -2,8 +2,8 @@ package mf
 
 import (
 	"bytes"
+	"fmt"
 	"io"
-	"strings"
 )
 
 // NibbleU32 함수는 8개의 니블 배열을 부호 없는 32비트 정수형으로 변환합니다.
@@ -42,51 +42,108 @@ func U32Bytes(n uint32) []byte {
 // FromBfCode 함수는 Brainfuck 코드를 MinFuck 코드로 변환합니다.
 // Brainfuck에는 사실상 memory address limit이 없기 때문에, 수동으로 지정해야 합니다.
 func FromBfCode(bf string, mem uint32) (mf string) {
-	fd := FileData{memsize: mem}
+	fd, _ := FromBfCodeOpts(bf, BfOptions{Mem: mem})
+	mf = fd.String()
+	return
+}
+
+// BfOptions 구조체는 Brainfuck 코드를 MinFuck 코드로 변환할 때의 설정을 정의합니다.
+type BfOptions struct {
+	Mem       uint32   // 할당할 메모리 주소의 최댓값
+	SourceMap bool     // 소스맵을 생성하여 SMAP 섹션에 기록할지 여부
+	Meta      Metadata // META 섹션에 기록할 메타데이터
+	Encoding  Encoding // 코드 인코딩 버전 (0이면 EncodingV1)
+	Optimize  bool     // 루프 관용구를 확장 명령어로 변환할지 여부 (EncodingV2 이상을 사용합니다)
+}
+
+// FromBfCodeOpts 함수는 주어진 설정에 따라 Brainfuck 코드를 MinFuck 파일로 변환합니다.
+// 소스맵을 생성하지 않으면 sm은 nil입니다.
+func FromBfCodeOpts(bf string, opts BfOptions) (fd FileData, sm *SourceMap) {
+	return fromTokens(bfTokens(bf), opts)
+}
+
+// FromSource 함수는 주어진 방언의 소스 코드를 MinFuck 파일로 변환합니다. d가 nil이면 Brainfuck으로 읽습니다.
+// 소스 코드를 토큰으로 나눌 수 없으면 오류를 반환합니다.
+func FromSource(src string, d Dialect, opts BfOptions) (FileData, *SourceMap, error) {
+	if d == nil {
+		d = Brainfuck
+	}
+	toks, err := d.Tokens(src)
+	if err == nil {
+		err = checkProcs(toks)
+	}
+	if err != nil {
+		return FileData{}, nil, err
+	}
+	fd, sm := fromTokens(toks, opts)
+	return fd, sm, nil
+}
+
+// fromTokens 함수는 명령어 목록을 MinFuck 파일로 변환합니다.
+// pbrain, Brainfork 명령어는 확장 명령어로 변환하므로 EncodingV2 이상을 사용합니다.
+func fromTokens(toks []Token, opts BfOptions) (fd FileData, sm *SourceMap) {
+	fd = FileData{memsize: opts.Mem}
+	if (opts.Optimize || hasExtTokens(toks)) && opts.Encoding < EncodingV2 {
+		opts.Encoding = EncodingV2
+	}
 	nw := new(NibbleWriterOptimized)
 	nw.NibbleWriter = new(NibbleWriter)
-	for i := 0; i < 8; i++ {
+	nw.Encoding = opts.Encoding
+	for i := 0; i < bfCellBase; i++ {
 		nw.Put(2)
 	}
 	nw.Flush()
-	for _, b := range bf {
-		op := FromBf(string(b))
-		if op > 7 {
+	if opts.SourceMap {
+		sm = new(SourceMap)
+		nw.Map = sm
+	}
+	jumps := matchTokens(toks)
+	for i := 0; i < len(toks); i++ {
+		if opts.Optimize && toks[i].Op == 4 {
+			if n := optimizeLoop(nw, toks[i:]); n > 0 {
+				i += n - 1
+				continue
+			}
+		}
+		op, pos := toks[i].Op, toks[i].Pos
+		if nw.putToken(toks[i], bfCellStride) {
 			continue
 		}
-		if op == 2 || op == 3 {
-			for i := 0; i < 8; i++ {
-				nw.Put(op)
+		if (op == 4 || op == 5) && jumps[i] >= 0 {
+			nw.putJump(op, pos)
+		} else if op == 2 || op == 3 {
+			for i := 0; i < bfCellStride; i++ {
+				nw.PutAt(op, pos)
 			}
 		} else {
-			nw.Put(op)
+			nw.PutAt(op, pos)
 		}
 	}
+	nw.Align()
 	fd.code = nw.Nibbles
-	mf = fd.String()
+	fd.SetEncoding(opts.Encoding)
+	if len(opts.Meta) > 0 {
+		fd.SetMetadata(opts.Meta)
+	}
+	if sm != nil {
+		b, _ := sm.MarshalBinary()
+		fd.setSection(SectionSourceMap, b)
+	}
 	return
 }
 
-// ToBfCode 함수는 MinFuck 코드를  Brainfuck 코드로 변환합니다.
+// ToBfCode 함수는 MinFuck 코드를 Brainfuck 코드로 변환합니다.
+// 압축된 명령어와 확장 명령어를 풀어 쓰며, 자세한 변환 방식은 ToSource 함수를 참고하세요.
+// 주의: MinFuck 코드를 읽거나 해석할 수 없을 경우 panic이 발생합니다.
 func ToBfCode(mf string) (bf string) {
-	bf = "MinFuck>>>>>>>>"
-
-	meta, err := ReadFile(bytes.NewBufferString(mf))
+	fd, err := ReadFile(bytes.NewBufferString(mf))
 	if err != nil {
 		panic(err)
 	}
-
-	for i := uint32(0); i < meta.memsize; i++ {
-		bf += strings.Repeat("+", int(i+1)) + ">>>>>>>>\n"
-	}
-	bf += strings.Repeat("<", int(meta.memsize)*8+8) + "\n"
-
-	for _, mb := range meta.code {
-		nb1, nb2 := (mb>>4)&0xf, mb&0xf
-		bf += ToBf(nb1)
-		bf += ToBf(nb2)
+	bf, err = ToSource(fd, Brainfuck)
+	if err != nil {
+		panic(err)
 	}
-
 	return
 }
 
@@ -158,11 +215,35 @@ func (n *NibbleWriter) Put(nb byte) {
 	}
 }
 
+// set 메서드는 이미 작성된 pc번째 니블을 바꿉니다.
+func (n *NibbleWriter) set(pc uint64, nb byte) {
+	if pc&1 == 0 {
+		n.Nibbles[pc>>1] = n.Nibbles[pc>>1]&0x0f | (nb&0xf)<<4
+	} else {
+		n.Nibbles[pc>>1] = n.Nibbles[pc>>1]&0xf0 | nb&0xf
+	}
+}
+
+// Len 메서드는 지금까지 작성된 니블의 수를 반환합니다.
+func (n *NibbleWriter) Len() uint64 {
+	if n.odd {
+		return uint64(len(n.Nibbles))*2 - 1
+	}
+	return uint64(len(n.Nibbles)) * 2
+}
+
 // NibbleWriterOptimized 구조체는 중복 니블코드를 압축해 byte slice에 작성합니다.
+// Encoding에 따라 반복 횟수를 기록하며, 0이면 EncodingV1을 사용합니다.
+// Map이 nil이 아니면 PutAt으로 작성된 니블코드의 원본 위치를 소스맵에 기록합니다.
 type NibbleWriterOptimized struct {
 	*NibbleWriter
-	buf byte
-	cnt uint32
+	Encoding Encoding
+	Map      *SourceMap
+	buf      byte
+	cnt      uint32
+	src      SourceRange
+	loops    []uint64 // 짝이 맞는 ]가 아직 작성되지 않은 압축된 [의 오프셋
+	procs    []uint64 // 반환 명령어가 아직 작성되지 않은 프로시저 정의의 오프셋
 }
 
 // Put 메셔드는 니블코드를 byte slice에 작성합니다.
@@ -174,25 +255,183 @@ func (n *NibbleWriterOptimized) Put(nb byte) {
 	n.cnt++
 }
 
+// PutAt 메서드는 원본 소스 위치와 함께 니블코드를 작성합니다.
+func (n *NibbleWriterOptimized) PutAt(nb byte, pos SourcePos) {
+	if n.buf != nb&0xf || n.cnt == 0 {
+		n.Flush()
+		n.src.Start = pos
+	}
+	n.src.End = pos
+	n.Put(nb)
+}
+
 // Flush 메서드는 버퍼에 있는 데이터를 byte slice에 작성하고 버퍼를 비웁니다.
 func (n *NibbleWriterOptimized) Flush() {
-	switch {
-	case n.cnt == 0:
+	if n.cnt == 0 {
 		return
-	case n.cnt < 9 || n.buf < 4: // no compression
-		for i := uint32(0); i < n.cnt; i++ {
-			n.NibbleWriter.Put(n.buf)
+	}
+	start := n.Len()
+	for cnt := n.cnt; cnt > 0; {
+		c := cnt
+		if n.Encoding >= EncodingV2 && c > maxCountV2 {
+			c = maxCountV2
 		}
-	default:
-		if n.NibbleWriter.odd {
-			n.NibbleWriter.Put(12)
+		if n.buf >= 4 || !n.compressible(c) { // no compression
+			for i := uint32(0); i < c; i++ {
+				n.NibbleWriter.Put(n.buf)
+			}
+		} else {
+			n.NibbleWriter.Put(8 | n.buf)
+			for _, nb := range n.Encoding.appendCount(nil, c) {
+				n.NibbleWriter.Put(nb)
+			}
 		}
-		n.NibbleWriter.Put(8 | n.buf)
-		for _, nb := range U32Nibbles(n.cnt) {
+		cnt -= c
+	}
+	if n.Map != nil && n.src.Start.Line != 0 {
+		n.Map.add(start, n.Len(), n.src)
+	}
+	n.cnt, n.src = 0, SourceRange{}
+}
+
+// putJump 메서드는 압축된 [ 또는 ]를 작성합니다.
+// 점프 대상은 코드의 배치가 정해진 뒤, 짝이 맞는 ]를 작성할 때 두 명령어 모두에 채워집니다.
+// 짝이 맞지 않는 ]를 작성하면 panic이 발생합니다.
+func (n *NibbleWriterOptimized) putJump(op byte, pos SourcePos) {
+	n.Flush()
+	start := n.Len()
+	n.NibbleWriter.Put(8 | op)
+	for i := 0; i < jumpNibbles; i++ {
+		n.NibbleWriter.Put(0)
+	}
+	if op == 4 {
+		n.loops = append(n.loops, start)
+	} else {
+		if len(n.loops) == 0 {
+			panic("짝이 맞지 않는 ]")
+		}
+		open := n.loops[len(n.loops)-1]
+		n.loops = n.loops[:len(n.loops)-1]
+		n.setTarget(open, n.Len())
+		n.setTarget(start, open+1+jumpNibbles)
+	}
+	if n.Map != nil {
+		n.Map.add(start, n.Len(), SourceRange{Start: pos, End: pos})
+	}
+}
+
+// setTarget 메서드는 pc에 작성된 압축된 [ ]의 점프 대상을 채웁니다.
+func (n *NibbleWriterOptimized) setTarget(pc, target uint64) {
+	for i := uint64(0); i < jumpNibbles; i++ {
+		n.NibbleWriter.set(pc+1+i, byte(target>>(4*(jumpNibbles-1-i))))
+	}
+}
+
+// PutJump 메서드는 압축된 [ (4) 또는 ] (5)를 작성합니다. 컴파일러가 루프를 직접 작성할 때 사용합니다.
+// 점프 대상은 짝이 맞는 ]를 작성할 때 채워지며, 짝이 맞지 않는 ]를 작성하면 panic이 발생합니다.
+func (n *NibbleWriterOptimized) PutJump(op byte, pos SourcePos) {
+	n.putJump(op, pos)
+}
+
+// PutExt 메서드는 확장 명령어 x를 작성합니다. Encoding이 EncodingV2 이상이어야 합니다.
+// 부호 있는 인자(곱셈, 스캔, 포크)는 지그재그 인코딩하므로 -2^29 ~ 2^29-1, 그 외의 인자는 0 ~ 2^30-1이어야 하며,
+// 범위를 벗어나면 panic이 발생합니다. 프로시저 정의는 본문의 길이를 나중에 채워야 하므로 작성할 수 없습니다.
+func (n *NibbleWriterOptimized) PutExt(x ExtOp, pos SourcePos, args ...int64) {
+	if x == ExtProc {
+		panic("PutExt로 프로시저 정의를 작성할 수 없습니다")
+	}
+	enc := make([]uint32, len(args))
+	for i, a := range args {
+		min, max := int64(0), int64(maxCountV2)
+		if x.signed() {
+			min, max = -maxCountV2/2-1, maxCountV2/2
+		}
+		if a < min || a > max {
+			panic(fmt.Sprintf("확장 명령어 %s의 인자 %d가 범위를 벗어났습니다", x, a))
+		}
+		if x.signed() {
+			enc[i] = zigzag(int32(a))
+		} else {
+			enc[i] = uint32(a)
+		}
+	}
+	n.putExt(x, SourceRange{Start: pos, End: pos}, enc...)
+}
+
+// putExt 메서드는 확장 명령어를 작성합니다. 인자는 인코딩된 값(부호 있는 인자는 지그재그 인코딩)이어야 합니다.
+func (n *NibbleWriterOptimized) putExt(x ExtOp, src SourceRange, args ...uint32) {
+	n.Flush()
+	start := n.Len()
+	n.NibbleWriter.Put(NibbleExt)
+	n.NibbleWriter.Put(byte(x))
+	for _, a := range args {
+		for _, nb := range n.Encoding.appendCount(nil, a) {
 			n.NibbleWriter.Put(nb)
 		}
 	}
-	n.cnt = 0
+	if n.Map != nil && src.Start.Line != 0 {
+		n.Map.add(start, n.Len(), src)
+	}
+}
+
+// procLenNibbles는 프로시저 정의 명령어에서 본문 길이를 기록하는 니블 수입니다.
+// 본문을 작성한 뒤에 채워야 하므로 값과 관계없이 EncodingV2의 8니블 형식을 사용합니다.
+const procLenNibbles = 8
+
+// putProc 메서드는 프로시저 정의 명령어를 작성합니다. EncodingV2 이상이어야 합니다.
+// 본문의 길이는 짝이 맞는 반환 명령어를 작성할 때 채워집니다.
+func (n *NibbleWriterOptimized) putProc(pos SourcePos) {
+	n.Flush()
+	start := n.Len()
+	n.NibbleWriter.Put(NibbleExt)
+	n.NibbleWriter.Put(byte(ExtProc))
+	n.NibbleWriter.Put(0xc)
+	for i := 1; i < procLenNibbles; i++ {
+		n.NibbleWriter.Put(0)
+	}
+	n.procs = append(n.procs, start)
+	if n.Map != nil {
+		n.Map.add(start, n.Len(), SourceRange{Start: pos, End: pos})
+	}
+}
+
+// putRet 메서드는 반환 명령어를 작성하고, 짝이 맞는 프로시저 정의에 본문의 길이를 채웁니다.
+// 짝이 맞지 않는 반환 명령어를 작성하거나 본문이 maxCountV2 니블보다 길면 panic이 발생합니다.
+func (n *NibbleWriterOptimized) putRet(pos SourcePos) {
+	if len(n.procs) == 0 {
+		panic("짝이 맞지 않는 )")
+	}
+	n.putExt(ExtRet, SourceRange{Start: pos, End: pos})
+	open := n.procs[len(n.procs)-1]
+	n.procs = n.procs[:len(n.procs)-1]
+	body := n.Len() - (open + 2 + procLenNibbles)
+	if body > maxCountV2 {
+		panic("프로시저의 본문이 너무 깁니다")
+	}
+	for i := uint64(0); i < procLenNibbles; i++ {
+		nb := byte(body >> (4 * (procLenNibbles - 1 - i)))
+		if i == 0 {
+			nb |= 0xc
+		}
+		n.NibbleWriter.set(open+2+i, nb)
+	}
+}
+
+// compressible 메서드는 c회 반복을 압축하는 편이 짧거나 같은지 확인합니다.
+func (n *NibbleWriterOptimized) compressible(c uint32) bool {
+	if n.Encoding < EncodingV2 {
+		return c >= 9
+	}
+	return 1+n.Encoding.countNibbles(c) < c
+}
+
+// Align 메서드는 버퍼를 비우고, EncodingV2에서는 NOP으로 바이트 경계를 맞춥니다.
+// EncodingV1에는 NOP이 없으므로 마지막 홀수 니블은 0으로 채워집니다.
+func (n *NibbleWriterOptimized) Align() {
+	n.Flush()
+	if n.Encoding >= EncodingV2 && n.NibbleWriter.odd {
+		n.NibbleWriter.Put(NibbleNOP)
+	}
 }
 
 // IOStream 구조체는 stdin/stdout을 에뮬레이션합니다.
@@ -209,7 +448,8 @@ func (i *IOStream) Read(b []byte) (int, error) {
 	if i.offset >= uint64(len(i.Stdin)) {
 		return 0, io.EOF
 	}
-	n := copy(b, []byte(i.Stdin[i.offset:]))
+	n := copy(b, i.Stdin[i.offset:])
+	i.offset += uint64(n)
 	return n, nil
 }
 
@@ -220,6 +460,42 @@ func (i *IOStream) Write(b []byte) (int, error) {
 	return len(b), nil
 }
 
+// noJump는 populateJump에서 짝이 맞는 대괄호가 없음을 나타냅니다.
+const noJump = ^uint32(0)
+
+// populateJump 함수는 Brainfuck 코드의 각 바이트 오프셋에 대해 짝이 맞는 대괄호의 오프셋을 계산합니다.
+// 대괄호가 아니거나 짝이 맞지 않는 대괄호의 값은 noJump입니다.
 func populateJump(bfcode string) []uint32 {
+	jumps := make([]uint32, len(bfcode))
+	for i := range jumps {
+		jumps[i] = noJump
+	}
+	toks := bfTokens(bfcode)
+	for i, j := range matchTokens(toks) {
+		if j >= 0 {
+			jumps[toks[i].Off] = uint32(toks[j].Off)
+		}
+	}
+	return jumps
+}
 
+// matchTokens 함수는 각 명령어에 대해 짝이 맞는 대괄호의 인덱스를 계산합니다.
+// 대괄호가 아니거나 짝이 맞지 않는 대괄호의 값은 -1입니다.
+func matchTokens(toks []Token) []int {
+	jumps := make([]int, len(toks))
+	var open []int
+	for i, t := range toks {
+		jumps[i] = -1
+		switch t.Op {
+		case 4:
+			open = append(open, i)
+		case 5:
+			if len(open) > 0 {
+				j := open[len(open)-1]
+				open = open[:len(open)-1]
+				jumps[i], jumps[j] = j, i
+			}
+		}
+	}
+	return jumps
 }
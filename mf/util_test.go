@@ -3,6 +3,7 @@ package mf
 import (
 	"bytes"
 	"encoding/hex"
+	"strings"
 	"testing"
 )
 
@@ -172,3 +173,98 @@ func TestU32Nibbles(t *testing.T) {
 		}
 	}
 }
+
+func TestPopulateJump(t *testing.T) {
+	jumps := populateJump("+[a[]é]]")
+	expect := []uint32{noJump, 7, noJump, 4, 3, noJump, noJump, 1, noJump}
+	if len(jumps) != len(expect) {
+		t.Fatalf("got %d entries, expected %d", len(jumps), len(expect))
+	}
+	for i := range expect {
+		if jumps[i] != expect[i] {
+			t.Errorf("offset %d: got %d, expected %d", i, int32(jumps[i]), int32(expect[i]))
+		}
+	}
+}
+
+// runBfr 함수는 minfuck bfr처럼 Brainfuck 코드를 니블코드로 1:1 변환하여 실행합니다.
+func runBfr(bf, in string) string {
+	nw := new(NibbleWriter)
+	for _, b := range bf {
+		if op := FromBf(string(b)); op <= 7 {
+			nw.Put(op)
+		}
+	}
+	out, _ := runBackend(&MinFuckVM{Code: nw.Nibbles, Mem: make([]uint32, 1<<16)}, BackendInterp, in)
+	return out
+}
+
+var rtTestEntries = []struct {
+	bf, in string
+}{
+	{bf: hwBfCode},
+	{bf: "[[.]+.]++[>+++[>++++++++<-]<-]>>+.<<[-]>>>,[<.>,]", in: "abc"},
+	{bf: ">,[>,]<[.<]", in: "reverse"},
+	{bf: "++++[>++++[>++++<-]<-]>>[>+>++>+++<<<-]>>.>.>+.<<<<[>]"},
+	{bf: "+[>+[>+[>+[-]<-]<-]<-]" + strings.Repeat("+", 65) + "."},
+}
+
+var rtOptions = []BfOptions{
+	{Mem: 4096},
+	{Mem: 4096, Encoding: EncodingV2},
+	{Mem: 4096, Optimize: true},
+	{Mem: 4096, SourceMap: true},
+}
+
+func TestBfRoundTrip(t *testing.T) {
+	for n, test := range rtTestEntries {
+		expect := runBfr(test.bf, test.in)
+		for _, opts := range rtOptions {
+			fd, _ := FromBfCodeOpts(test.bf, opts)
+			fd.SetCompressed(opts.SourceMap)
+			rfd, err := ReadFile(bytes.NewBufferString(fd.String()))
+			if err != nil {
+				t.Fatalf("Test #%d failed: %v", n+1, err)
+			}
+			for pc := uint64(0); ; {
+				in, err := rfd.Encoding().Decode(codeOf(t, &rfd), pc)
+				if err != nil {
+					break
+				}
+				if (in.Op == 4 || in.Op == 5) && in.Ext == 0 && !in.Jump {
+					t.Errorf("Test #%d failed: uncompressed bracket at nibble offset %d", n+1, pc)
+				}
+				pc += in.Len
+			}
+
+			out, err := runBackend(NewVM(rfd), BackendInterp, test.in)
+			if err != nil {
+				t.Errorf("Test #%d failed (%+v): %v", n+1, opts, err)
+			}
+			if out != expect {
+				t.Errorf("Test #%d failed (%+v): got %q, bfr %q", n+1, opts, out, expect)
+			}
+		}
+	}
+}
+
+func TestToBfCode(t *testing.T) {
+	// b2m -> m2b -> bfr
+	bf := "++++++++[>++++++++<-]>+.+."
+	for _, opts := range rtOptions[:3] {
+		opts.Mem = 300
+		fd, _ := FromBfCodeOpts(bf, opts)
+		if out := runBfr(ToBfCode(fd.String()), ""); out != "AB" {
+			t.Errorf("%+v: got %q, expected %q", opts, out, "AB")
+		}
+	}
+}
+
+func TestUnmatchedBrackets(t *testing.T) {
+	for _, bf := range []string{"[", "]", "[[-]", "+]"} {
+		fd, _ := FromBfCodeOpts(bf, BfOptions{Mem: 16})
+		if _, err := runBackend(NewVM(fd), BackendInterp, ""); err == nil || !strings.Contains(err.Error(), "짝이 맞지 않는") {
+			t.Errorf("%q: expected unmatched bracket error, got %v", bf, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package mf
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// WebAssembly 바이너리 포맷의 섹션 ID와 명령어입니다.
+const (
+	wasmSecType     = 1
+	wasmSecImport   = 2
+	wasmSecFunction = 3
+	wasmSecMemory   = 5
+	wasmSecExport   = 7
+	wasmSecCode     = 10
+
+	wasmUnreachable = 0x00
+	wasmBlock       = 0x02
+	wasmLoop        = 0x03
+	wasmIf          = 0x04
+	wasmEnd         = 0x0b
+	wasmBr          = 0x0c
+	wasmBrIf        = 0x0d
+	wasmCall        = 0x10
+	wasmSelect      = 0x1b
+	wasmLocalGet    = 0x20
+	wasmLocalSet    = 0x21
+	wasmLocalTee    = 0x22
+	wasmI32Load     = 0x28
+	wasmI32Store    = 0x36
+	wasmI32Const    = 0x41
+	wasmI32Eqz      = 0x45
+	wasmI32GeS      = 0x4e
+	wasmI32GeU      = 0x4f
+	wasmI32Add      = 0x6a
+	wasmI32Mul      = 0x6c
+	wasmI32Shl      = 0x74
+
+	wasmI32       = 0x7f
+	wasmFuncType  = 0x60
+	wasmBlockVoid = 0x40
+	wasmPageSize  = 1 << 16
+)
+
+// wasm 모듈의 함수 인덱스와 지역 변수 인덱스입니다.
+const (
+	wasmFuncOutput = 0 // env.output(i32)
+	wasmFuncInput  = 1 // env.input() i32
+	wasmFuncRun    = 2
+	wasmLocalP     = 0 // 메모리 포인터 (바이트 주소)
+	wasmLocalT     = 1 // 임시 값
+)
+
+/*
+ToWasm 함수는 MinFuck 프로그램을 WebAssembly 모듈로 변환합니다.
+
+ 모듈은 다음 두 함수를 env 모듈에서 가져옵니다:
+ output(i32): 셀의 하위 8비트를 출력합니다.
+ input() i32: 입력 1바이트를 반환합니다. 입력이 끝났으면 음수를 반환하며, 이때 셀은 0이 됩니다.
+
+ run 함수와 memory 메모리를 내보냅니다. 테이프는 선형 메모리의 0번지부터 셀마다 4바이트(리틀 엔디언)를 차지하며,
+ run은 VMFile과 같이 메모리를 초기화한 뒤 프로그램을 실행합니다.
+ 메모리 포인터를 옮기거나 곱셈의 대상 번지를 계산한 결과가 테이프 밖이면 unreachable로 트랩이 발생합니다.
+ 프로그램 끝의 이동은 검사하지 않으며, 곱셈은 현재 셀이 0이면 대상 번지를 계산하지 않습니다.
+*/
+func ToWasm(fd FileData) ([]byte, error) {
+	ops, err := buildIR(&fd)
+	if err != nil {
+		return nil, err
+	}
+	if len(ops) > 0 && ops[len(ops)-1].kind == irMove {
+		ops = ops[:len(ops)-1]
+	}
+	size := tapeSize(fd.memsize) * 4
+	pages := (size + wasmPageSize - 1) / wasmPageSize
+	if pages > 1<<16 {
+		return nil, fmt.Errorf("메모리가 너무 큽니다: %d 페이지", pages)
+	}
+
+	mod := []byte("\x00asm\x01\x00\x00\x00")
+	mod = wasmSection(mod, wasmSecType, wasmVec(
+		[]byte{wasmFuncType, 1, wasmI32, 0},
+		[]byte{wasmFuncType, 0, 1, wasmI32},
+		[]byte{wasmFuncType, 0, 0},
+	))
+	mod = wasmSection(mod, wasmSecImport, wasmVec(
+		append(append(wasmName(nil, "env"), wasmName(nil, "output")...), 0x00, 0),
+		append(append(wasmName(nil, "env"), wasmName(nil, "input")...), 0x00, 1),
+	))
+	mod = wasmSection(mod, wasmSecFunction, wasmVec([]byte{2}))
+	mod = wasmSection(mod, wasmSecMemory, wasmVec(binary.AppendUvarint([]byte{0x00}, pages)))
+	mod = wasmSection(mod, wasmSecExport, wasmVec(
+		append(wasmName(nil, "run"), 0x00, wasmFuncRun),
+		append(wasmName(nil, "memory"), 0x02, 0),
+	))
+
+	body := []byte{1, 2, wasmI32} // 지역 변수: i32 2개
+	body = wasmInit(body, fd.memsize)
+	for _, op := range ops {
+		body = wasmOp(body, op, size)
+	}
+	body = append(body, wasmEnd)
+	code := binary.AppendUvarint(nil, uint64(len(body)))
+	mod = wasmSection(mod, wasmSecCode, wasmVec(append(code, body...)))
+	return mod, nil
+}
+
+// wasmInit 함수는 VMFile과 같이 8+2i번째 셀을 i+1로 초기화하는 코드를 작성합니다.
+func wasmInit(b []byte, memsize uint32) []byte {
+	b = wasmConst(b, 0)
+	b = append(b, wasmLocalSet, wasmLocalT,
+		wasmBlock, wasmBlockVoid, wasmLoop, wasmBlockVoid,
+		wasmLocalGet, wasmLocalT)
+	b = wasmConst(b, int32(memsize))
+	b = append(b, wasmI32GeU, wasmBrIf, 1,
+		wasmLocalGet, wasmLocalT)
+	b = wasmConst(b, 3)
+	b = append(b, wasmI32Shl, wasmLocalGet, wasmLocalT)
+	b = wasmConst(b, 1)
+	b = append(b, wasmI32Add, wasmI32Store, 2, 32, // offset: 8번째 셀
+		wasmLocalGet, wasmLocalT)
+	b = wasmConst(b, 1)
+	return append(b, wasmI32Add, wasmLocalSet, wasmLocalT,
+		wasmBr, 0, wasmEnd, wasmEnd)
+}
+
+// wasmOp 함수는 중간 표현 명령어 하나를 wasm 명령어로 변환합니다. size는 테이프의 바이트 크기입니다.
+func wasmOp(b []byte, op irOp, size uint64) []byte {
+	load := func(b []byte) []byte { return append(b, wasmLocalGet, wasmLocalP, wasmI32Load, 2, 0) }
+	addP := func(b []byte, n int64) []byte {
+		b = append(b, wasmLocalGet, wasmLocalP)
+		b = wasmConst(b, int32(n*4))
+		return append(b, wasmI32Add)
+	}
+	// check 함수는 지역 변수 l의 주소가 테이프를 벗어나면 트랩을 발생시킵니다.
+	// 테이프가 4GiB이면 모든 i32 주소가 테이프 안에 있으므로 검사하지 않습니다.
+	check := func(b []byte, l byte) []byte {
+		if size > 1<<32-1 {
+			return b
+		}
+		b = append(b, wasmLocalGet, l)
+		b = wasmConst(b, int32(size))
+		return append(b, wasmI32GeU, wasmIf, wasmBlockVoid, wasmUnreachable, wasmEnd)
+	}
+	switch op.kind {
+	case irAdd:
+		b = append(b, wasmLocalGet, wasmLocalP)
+		b = wasmConst(load(b), int32(op.n))
+		b = append(b, wasmI32Add, wasmI32Store, 2, 0)
+	case irMove:
+		b = check(append(addP(b, op.n), wasmLocalSet, wasmLocalP), wasmLocalP)
+	case irOpen:
+		b = append(b, wasmBlock, wasmBlockVoid, wasmLoop, wasmBlockVoid)
+		b = append(load(b), wasmI32Eqz, wasmBrIf, 1)
+	case irClose:
+		b = append(b, wasmBr, 0, wasmEnd, wasmEnd)
+	case irOut:
+		b = append(load(b), wasmCall, wasmFuncOutput)
+	case irIn:
+		// 음수(EOF)이면 0을 저장합니다: select(t, 0, t >= 0)
+		b = append(b, wasmLocalGet, wasmLocalP, wasmCall, wasmFuncInput, wasmLocalTee, wasmLocalT)
+		b = wasmConst(b, 0)
+		b = append(b, wasmLocalGet, wasmLocalT)
+		b = wasmConst(b, 0)
+		b = append(b, wasmI32GeS, wasmSelect, wasmI32Store, 2, 0)
+	case irSet:
+		b = append(b, wasmLocalGet, wasmLocalP)
+		b = wasmConst(b, int32(op.n))
+		b = append(b, wasmI32Store, 2, 0)
+	case irMul:
+		// 현재 셀이 0이면 대상 셀에 접근하지 않습니다.
+		b = append(load(b), wasmIf, wasmBlockVoid)
+		b = check(append(addP(b, op.off), wasmLocalSet, wasmLocalT), wasmLocalT)
+		b = append(b, wasmLocalGet, wasmLocalT, wasmLocalGet, wasmLocalT, wasmI32Load, 2, 0)
+		b = wasmConst(load(b), int32(op.n))
+		b = append(b, wasmI32Mul, wasmI32Add, wasmI32Store, 2, 0, wasmEnd)
+	case irScan:
+		b = append(b, wasmBlock, wasmBlockVoid, wasmLoop, wasmBlockVoid)
+		b = append(load(b), wasmI32Eqz, wasmBrIf, 1)
+		b = check(append(addP(b, op.n), wasmLocalSet, wasmLocalP), wasmLocalP)
+		b = append(b, wasmBr, 0, wasmEnd, wasmEnd)
+	}
+	return b
+}
+
+// wasmConst 함수는 i32.const 명령어를 작성합니다. 값은 부호 있는 LEB128로 기록됩니다.
+func wasmConst(b []byte, v int32) []byte {
+	b = append(b, wasmI32Const)
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+// wasmName 함수는 길이를 앞에 붙인 이름을 작성합니다.
+func wasmName(b []byte, name string) []byte {
+	return append(binary.AppendUvarint(b, uint64(len(name))), name...)
+}
+
+// wasmVec 함수는 원소의 수를 앞에 붙인 벡터를 만듭니다.
+func wasmVec(items ...[]byte) []byte {
+	b := binary.AppendUvarint(nil, uint64(len(items)))
+	for _, it := range items {
+		b = append(b, it...)
+	}
+	return b
+}
+
+// wasmSection 함수는 섹션 ID와 길이를 앞에 붙인 섹션을 작성합니다.
+func wasmSection(b []byte, id byte, content []byte) []byte {
+	b = binary.AppendUvarint(append(b, id), uint64(len(content)))
+	return append(b, content...)
+}
@@ -0,0 +1,436 @@
+package mf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// wasmModule 구조체는 wasmCheck가 해석한 모듈입니다.
+type wasmModule struct {
+	types   [][2][]byte // 매개변수와 결과 타입
+	imports []string    // module.name
+	funcs   []uint64    // 정의된 함수의 타입 인덱스
+	pages   uint64
+	exports map[string][2]uint64 // 종류와 인덱스
+	code    []winstr
+}
+
+// winstr 구조체는 디코딩된 wasm 명령어입니다.
+type winstr struct {
+	op  byte
+	imm int64
+	end int // block, loop의 짝이 맞는 end 위치
+}
+
+// wreader 구조체는 wasm 바이너리를 읽습니다.
+type wreader struct {
+	b   []byte
+	err error
+}
+
+func (r *wreader) byte() byte {
+	if len(r.b) == 0 {
+		r.err = errors.New("unexpected end")
+		return 0
+	}
+	c := r.b[0]
+	r.b = r.b[1:]
+	return c
+}
+
+func (r *wreader) uleb() uint64 {
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.err = errors.New("bad uleb128")
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *wreader) sleb() int64 {
+	var v int64
+	var shift uint
+	for {
+		c := r.byte()
+		v |= int64(c&0x7f) << shift
+		shift += 7
+		if c&0x80 == 0 {
+			if shift < 64 && c&0x40 != 0 {
+				v |= -1 << shift
+			}
+			return v
+		}
+		if r.err != nil || shift > 35 {
+			r.err = errors.New("bad sleb128")
+			return 0
+		}
+	}
+}
+
+func (r *wreader) bytes(n uint64) []byte {
+	if uint64(len(r.b)) < n {
+		r.err = errors.New("unexpected end")
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+// wasmCheck 함수는 ToWasm이 생성한 모듈의 구조를 검사하고 해석합니다.
+// 섹션의 순서와 길이, 타입, 가져오기와 내보내기, 명령어의 스택 높이와 블록 구조를 확인합니다.
+func wasmCheck(mod []byte) (*wasmModule, error) {
+	if !bytes.HasPrefix(mod, []byte("\x00asm\x01\x00\x00\x00")) {
+		return nil, errors.New("bad header")
+	}
+	m := &wasmModule{exports: map[string][2]uint64{}}
+	r := &wreader{b: mod[8:]}
+	last := byte(0)
+	for len(r.b) > 0 && r.err == nil {
+		id := r.byte()
+		if id <= last {
+			return nil, fmt.Errorf("section %d out of order", id)
+		}
+		last = id
+		s := &wreader{b: r.bytes(r.uleb())}
+		n := s.uleb()
+		for i := uint64(0); i < n && s.err == nil; i++ {
+			switch id {
+			case wasmSecType:
+				if s.byte() != wasmFuncType {
+					return nil, errors.New("bad function type")
+				}
+				params := s.bytes(s.uleb())
+				results := s.bytes(s.uleb())
+				m.types = append(m.types, [2][]byte{params, results})
+			case wasmSecImport:
+				name := string(s.bytes(s.uleb())) + "." + string(s.bytes(s.uleb()))
+				if s.byte() != 0 || s.uleb() >= uint64(len(m.types)) {
+					return nil, errors.New("bad import")
+				}
+				m.imports = append(m.imports, name)
+			case wasmSecFunction:
+				m.funcs = append(m.funcs, s.uleb())
+			case wasmSecMemory:
+				if s.byte() != 0 {
+					return nil, errors.New("unexpected memory limits")
+				}
+				m.pages = s.uleb()
+			case wasmSecExport:
+				name := string(s.bytes(s.uleb()))
+				m.exports[name] = [2]uint64{uint64(s.byte()), s.uleb()}
+			case wasmSecCode:
+				body := &wreader{b: s.bytes(s.uleb())}
+				for j := body.uleb(); j > 0; j-- {
+					body.uleb()
+					if body.byte() != wasmI32 {
+						return nil, errors.New("bad local type")
+					}
+				}
+				code, err := wasmDecode(body)
+				if err != nil {
+					return nil, err
+				}
+				m.code = code
+			default:
+				return nil, fmt.Errorf("unexpected section %d", id)
+			}
+		}
+		if s.err == nil && len(s.b) != 0 {
+			return nil, fmt.Errorf("section %d has %d trailing bytes", id, len(s.b))
+		}
+		if s.err != nil {
+			return nil, fmt.Errorf("section %d: %v", id, s.err)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(m.funcs) != 1 || m.code == nil {
+		return nil, errors.New("expected exactly one function")
+	}
+	return m, nil
+}
+
+// wasmDecode 함수는 함수 본문을 디코딩하고 스택 높이와 블록 구조를 검사합니다.
+func wasmDecode(r *wreader) ([]winstr, error) {
+	type frame struct{ start, height int }
+	var (
+		code   []winstr
+		blocks []frame
+		height int
+		dead   bool // br 다음의 도달할 수 없는 코드
+	)
+	// 명령어별로 꺼내고 넣는 값의 수입니다.
+	effect := map[byte][2]int{
+		wasmBrIf: {1, 0}, wasmSelect: {3, 1}, wasmLocalGet: {0, 1}, wasmLocalSet: {1, 0},
+		wasmLocalTee: {1, 1}, wasmI32Load: {1, 1}, wasmI32Store: {2, 0}, wasmI32Const: {0, 1},
+		wasmI32Eqz: {1, 1}, wasmI32GeS: {2, 1}, wasmI32GeU: {2, 1}, wasmI32Add: {2, 1},
+		wasmI32Mul: {2, 1}, wasmI32Shl: {2, 1},
+	}
+	for r.err == nil {
+		in := winstr{op: r.byte()}
+		switch in.op {
+		case wasmBlock, wasmLoop, wasmIf:
+			if r.byte() != wasmBlockVoid {
+				return nil, errors.New("unexpected block type")
+			}
+			if in.op == wasmIf {
+				if height--; height < 0 && !dead {
+					return nil, errors.New("stack underflow at if")
+				}
+			}
+			blocks = append(blocks, frame{len(code), height})
+		case wasmUnreachable:
+			dead = true
+		case wasmEnd:
+			if len(blocks) == 0 {
+				if len(r.b) != 0 || height != 0 {
+					return nil, fmt.Errorf("bad function end (height %d)", height)
+				}
+				return append(code, in), nil
+			}
+			f := blocks[len(blocks)-1]
+			blocks = blocks[:len(blocks)-1]
+			if !dead && height != f.height {
+				return nil, fmt.Errorf("block at %d ends with height %d, expected %d", f.start, height, f.height)
+			}
+			height, dead = f.height, false
+			code[f.start].end = len(code)
+		case wasmBr, wasmBrIf:
+			in.imm = int64(r.uleb())
+			if int(in.imm) >= len(blocks) {
+				return nil, fmt.Errorf("branch depth %d out of range", in.imm)
+			}
+			dead = in.op == wasmBr
+		case wasmCall:
+			in.imm = int64(r.uleb())
+			switch in.imm {
+			case wasmFuncOutput:
+				height--
+			case wasmFuncInput:
+				height++
+			default:
+				return nil, fmt.Errorf("bad call %d", in.imm)
+			}
+		case wasmLocalGet, wasmLocalSet, wasmLocalTee:
+			if in.imm = int64(r.uleb()); in.imm > wasmLocalT {
+				return nil, fmt.Errorf("bad local %d", in.imm)
+			}
+		case wasmI32Load, wasmI32Store:
+			r.uleb()
+			in.imm = int64(r.uleb())
+		case wasmI32Const:
+			in.imm = r.sleb()
+		default:
+			if _, ok := effect[in.op]; !ok {
+				return nil, fmt.Errorf("unexpected opcode 0x%02x", in.op)
+			}
+		}
+		if e, ok := effect[in.op]; ok {
+			if height -= e[0]; height < 0 && !dead {
+				return nil, fmt.Errorf("stack underflow at opcode 0x%02x", in.op)
+			}
+			height += e[1]
+		}
+		code = append(code, in)
+	}
+	return nil, r.err
+}
+
+// run 메서드는 wasm 모듈의 run 함수를 실행합니다.
+func (m *wasmModule) run(input string) (string, error) {
+	mem := make([]byte, m.pages*wasmPageSize)
+	out := new(bytes.Buffer)
+	var (
+		stack  []int32
+		locals [2]int32
+		labels []int // 실행 중인 block, loop의 위치
+	)
+	pop := func() int32 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+	addr := func(base int32, off int64) (uint64, error) {
+		a := uint64(uint32(base)) + uint64(off)
+		if a+4 > uint64(len(mem)) {
+			return 0, errors.New("out of bounds memory access")
+		}
+		return a, nil
+	}
+	for pc := 0; pc < len(m.code); pc++ {
+		in := m.code[pc]
+		switch in.op {
+		case wasmBlock, wasmLoop:
+			labels = append(labels, pc)
+		case wasmIf:
+			if pop() == 0 {
+				pc = in.end
+			} else {
+				labels = append(labels, pc)
+			}
+		case wasmUnreachable:
+			return out.String(), errors.New("unreachable")
+		case wasmEnd:
+			if len(labels) > 0 {
+				labels = labels[:len(labels)-1]
+			}
+		case wasmBr, wasmBrIf:
+			if in.op == wasmBrIf && pop() == 0 {
+				break
+			}
+			target := labels[len(labels)-1-int(in.imm)]
+			if m.code[target].op == wasmLoop {
+				labels, pc = labels[:len(labels)-int(in.imm)], target
+			} else {
+				labels, pc = labels[:len(labels)-1-int(in.imm)], m.code[target].end
+			}
+		case wasmCall:
+			if in.imm == wasmFuncOutput {
+				out.WriteByte(byte(pop()))
+			} else if len(input) > 0 {
+				stack, input = append(stack, int32(input[0])), input[1:]
+			} else {
+				stack = append(stack, -1)
+			}
+		case wasmSelect:
+			c, b, a := pop(), pop(), pop()
+			if c == 0 {
+				a = b
+			}
+			stack = append(stack, a)
+		case wasmLocalGet:
+			stack = append(stack, locals[in.imm])
+		case wasmLocalSet:
+			locals[in.imm] = pop()
+		case wasmLocalTee:
+			locals[in.imm] = stack[len(stack)-1]
+		case wasmI32Load:
+			a, err := addr(pop(), in.imm)
+			if err != nil {
+				return out.String(), err
+			}
+			stack = append(stack, int32(binary.LittleEndian.Uint32(mem[a:])))
+		case wasmI32Store:
+			v := pop()
+			a, err := addr(pop(), in.imm)
+			if err != nil {
+				return out.String(), err
+			}
+			binary.LittleEndian.PutUint32(mem[a:], uint32(v))
+		case wasmI32Const:
+			stack = append(stack, int32(in.imm))
+		case wasmI32Eqz:
+			v := int32(0)
+			if pop() == 0 {
+				v = 1
+			}
+			stack = append(stack, v)
+		default:
+			b, a := pop(), pop()
+			var v int32
+			switch in.op {
+			case wasmI32GeS:
+				if a >= b {
+					v = 1
+				}
+			case wasmI32GeU:
+				if uint32(a) >= uint32(b) {
+					v = 1
+				}
+			case wasmI32Add:
+				v = a + b
+			case wasmI32Mul:
+				v = a * b
+			case wasmI32Shl:
+				v = a << (uint32(b) & 31)
+			}
+			stack = append(stack, v)
+		}
+	}
+	return out.String(), nil
+}
+
+func TestToWasm(t *testing.T) {
+	for n, test := range rtTestEntries {
+		for _, opts := range rtOptions[:3] {
+			fd, _ := FromBfCodeOpts(test.bf, opts)
+			expect, _ := runBackend(NewVM(fd), BackendInterp, test.in)
+
+			mod, err := ToWasm(fd)
+			if err != nil {
+				t.Fatalf("Test #%d failed: %v", n+1, err)
+			}
+			m, err := wasmCheck(mod)
+			if err != nil {
+				t.Fatalf("Test #%d failed: invalid module: %v", n+1, err)
+			}
+			if m.pages != (tapeSize(4096)*4+wasmPageSize-1)/wasmPageSize {
+				t.Errorf("Test #%d failed: unexpected memory size %d", n+1, m.pages)
+			}
+			if e := m.exports["run"]; e != [2]uint64{0, wasmFuncRun} {
+				t.Errorf("Test #%d failed: run is not exported", n+1)
+			}
+			if strings.Join(m.imports, ",") != "env.output,env.input" {
+				t.Errorf("Test #%d failed: unexpected imports %v", n+1, m.imports)
+			}
+			out, err := m.run(test.in)
+			if err != nil || out != expect {
+				t.Errorf("Test #%d failed (%+v): got %q (%v), VM %q", n+1, opts, out, err, expect)
+			}
+		}
+	}
+}
+
+func TestToWasmTrap(t *testing.T) {
+	fd, _ := FromBfCodeOpts("<<+", BfOptions{Mem: 16})
+	mod, _ := ToWasm(fd)
+	m, err := wasmCheck(mod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.run(""); err == nil {
+		t.Errorf("out of range access should trap")
+	}
+
+	// 선형 메모리는 페이지 단위로 잡히므로 테이프 끝은 주소 검사로 확인합니다.
+	for _, test := range []struct {
+		bf, out string
+		trap    bool
+	}{
+		{bf: ">>>>>>+", trap: true},
+		{bf: "+[>+]", trap: true},
+		{bf: "+[->>>>>>+<<<<<<]", trap: true},
+		{bf: "+.<<", out: "\x01"},
+		{bf: "[->>>>>>+<<<<<<]+.", out: "\x01"},
+	} {
+		fd, _ := FromBfCodeOpts(test.bf, BfOptions{Mem: 16, Optimize: true})
+		mod, _ := ToWasm(fd)
+		m, err := wasmCheck(mod)
+		if err != nil {
+			t.Fatalf("%s: invalid module: %v", test.bf, err)
+		}
+		if out, err := m.run(""); out != test.out || (err != nil) != test.trap {
+			t.Errorf("%s: got %q (%v), expected %q (trap %v)", test.bf, out, err, test.out, test.trap)
+		}
+	}
+	if _, err := ToWasm(NewFileData(1<<31, nil)); err == nil {
+		t.Errorf("memory larger than 4GiB should be rejected")
+	}
+}
+
+func TestWasmConst(t *testing.T) {
+	for _, v := range []int32{0, 1, 63, 64, -1, -64, -65, 1 << 20, -1 << 31, 1<<31 - 1} {
+		b := wasmConst(nil, v)
+		r := &wreader{b: b[1:]}
+		if got := r.sleb(); got != int64(v) || len(r.b) != 0 || r.err != nil {
+			t.Errorf("%d: decoded %d from %x", v, got, b)
+		}
+	}
+}
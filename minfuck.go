@@ -2,11 +2,14 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cr0sh/minfuck/mf"
@@ -19,18 +22,119 @@ const mainHelp = `MinFuck 버전 %s
 help:
     이 도움말을 출력합니다.
 
-b2m [filename] [mem]:
+b2m [--map] [--optimize] [--compress] [--encoding n] [--dialect d] [--ext e] [--debug] [--input-sep] [--pp] [--define NAME=body ...] [--meta key=value ...] [filename] [mem]:
     주어진 Brainfuck 코드를 MinFuck 코드로 변환합니다.
+    --dialect로 소스 코드의 방언을 지정할 수 있습니다. (아래 방언 목록 참고, 기본값: bf)
+    --ext로 bf, pbrain, brainfork 방언에 확장 명령어를 더할 수 있습니다. (아래 확장 목록 참고, 쉼표로 구분)
+    --debug를 지정하면 # 에서 레지스터와 현재 셀 주변의 메모리를 표준 오류로 출력합니다. (--ext debug와 같습니다)
+    --input-sep을 지정하면 첫 ! 뒤의 내용을 프로그램과 함께 기록하여, 실행할 때 표준 입력보다 먼저 읽습니다.
+    !를 명령어로 사용하는 확장(ebf1)과는 함께 쓸 수 없습니다.
+    --pp를 지정하면 소스 코드를 매크로 전처리한 뒤 변환합니다. (아래 전처리기 참고)
+    --define NAME=body로 인자 없는 매크로를 미리 정의할 수 있으며, --map의 소스맵은 원본 파일의 위치를 가리킵니다. (#include한 파일과 앞에서 정의한 매크로의 본문은 소스맵에서 제외됩니다)
     mem은 할당할 메모리 주소의 최댓값이며, 기본값은 4096입니다.
+    --map을 지정하면 Brainfuck 소스 위치를 담은 소스맵을 함께 기록합니다.
+    --optimize를 지정하면 [-], [->+<], [>] 등의 루프를 확장 명령어로 변환합니다. (v2 인코딩을 사용합니다)
+    --compress를 지정하면 코드를 DEFLATE로 압축하여 기록합니다.
+    --encoding 2를 지정하면 가변 길이 반복 횟수를 사용하는 v2 인코딩으로 기록합니다. (기본값: 1)
+    --meta로 메타데이터를 기록할 수 있습니다. (자주 쓰이는 키: name, author, cell-width, eof, tape)
+    이때 소스 파일 이름과 컴파일러 버전도 함께 기록되며, 생성 시각은 --meta build-time=now로 기록합니다.
 
-m2b [filename]:
+m2b [--dialect d] [filename]:
 	주어진 MinFuck 코드를 Brainfuck 코드로 변환합니다.
+    --dialect를 지정하면 메모리 초기화와 확장 명령어를 포함한 프로그램 전체를 그 방언의 코드로 변환합니다.
+    출력 파일의 확장자는 bf와 table 방언이면 .bf, 그 외에는 방언의 이름입니다. (예: .ook)
 
-run [filename]:
+run [--require-signature] [--trust dir] [--backend b] [--sched s] [filename]:
     주어진 MinFuck 코드를 구동합니다.
+    --backend closure를 지정하면 코드를 Go 클로저로 컴파일하여 더 빠르게 실행합니다. (기본값: interp)
+    --sched는 fork(Brainfork의 Y)로 만든 스레드를 실행하는 방식입니다.
+    roundrobin은 스레드를 명령어 하나씩 차례로 실행하여 항상 같은 결과를 내며 (기본값),
+    goroutine은 스레드마다 고루틴을 사용합니다. 두 방식 모두 명령어 하나는 원자적으로 실행됩니다.
+    소스맵이 있으면 오류 발생 시 Brainfuck 소스 위치를 함께 출력합니다.
+    --trust를 지정하면 dir 안의 .pub 파일에 있는 공개키만 서명자로 신뢰합니다.
+    --require-signature를 지정하면 신뢰하는 키로 서명되지 않은 프로그램을 거부합니다.
 
-bfr [filename]:
-    주어진 Brainfuck 코드를 구동합니다.
+bfr [--dialect d] [--ext e] [--debug] [--input-sep] [--pp] [--define NAME=body ...] [--sched s] [filename]:
+    주어진 Brainfuck 코드를 구동합니다. --debug, --input-sep, --pp, --define은 b2m과 같습니다.
+
+fmt [-w] [-d] [-minify] [-width n] [filename ...]:
+    주어진 Brainfuck 코드를 표준 형식으로 정리하여 출력합니다. 파일 이름이 없으면 표준 입력을 정리합니다.
+    루프 본문은 깊이만큼 들여쓰고, 주석의 내용은 그대로 두며, width(기본값 72)보다 긴 줄은 나눕니다.
+    -minify를 지정하면 주석과 공백, +- <> 같은 상쇄되는 명령어 쌍과 실행되지 않는 루프를 지웁니다.
+    -w를 지정하면 결과를 출력하는 대신 원래 파일에 기록하고, -d를 지정하면 원래 코드와의 차이를 출력합니다.
+
+gen-text [-mf] [-o file] [text]:
+    주어진 문자열을 출력하는 짧은 Brainfuck 코드를 생성합니다. 문자열의 \n, \x41 등 Go 이스케이프를 해석합니다.
+    곱셈 루프로 여러 셀을 문자 값 근처로 초기화하고, 문자마다 가장 가까운 셀을 골라 출력합니다.
+    -mf를 지정하면 최적화된 MinFuck 코드를 -o로 지정한 파일에 기록합니다. (Brainfuck 코드의 기본값: 표준 출력)
+
+compile [--map] [--meta key=value ...] [-o file] [filename]:
+    Polygon 스타일의 구조적 언어로 작성한 소스 코드(.pg)를 MinFuck 코드로 컴파일합니다.
+    변수 선언(var), 대입, + - * / %% 연산, 비교, if/else, while, print(문자열과 십진수), read(십진수)를 지원하며,
+    모든 값은 32비트 부호 없는 정수입니다. 문법은 mf/pg 패키지의 문서를 참고하세요.
+    --map과 --meta는 b2m과 같으며, 출력 파일 이름의 기본값은 입력 파일 이름.mf입니다.
+
+keygen [name]:
+    ed25519 키 쌍을 생성하여 name.key(개인키)와 name.pub(공개키)에 기록합니다.
+
+sign --key [keyfile] [filename]:
+    주어진 MinFuck 코드에 개인키로 서명합니다.
+
+info [filename]:
+    주어진 MinFuck 코드의 헤더, 섹션, 메타데이터와 코드 통계를 출력합니다.
+
+m2c [--cell-width n] [--eof e] [--tape t] [filename]:
+    주어진 MinFuck 코드를 C99 코드로 변환합니다.
+    --cell-width는 셀 크기(8, 16, 32), --eof는 EOF 처리 방식(0, -1, unchanged),
+    --tape는 테이프 처리 방식(fixed, wrap, grow)입니다.
+    지정하지 않으면 메타데이터의 cell-width, eof, tape 값을, 그마저 없으면 VM과 같은 32, 0, fixed를 사용합니다.
+
+b2c [--cell-width n] [--eof e] [--tape t] [filename] [mem]:
+    주어진 Brainfuck 코드를 최적화된 MinFuck 코드를 거쳐 C99 코드로 변환합니다.
+
+m2go [-pkg name] [-o file] [filename]:
+    주어진 MinFuck 코드를 func Run(in io.Reader, out io.Writer) error를 정의하는 Go 코드로 변환합니다.
+    패키지 이름의 기본값은 main이며, 이 경우 표준 입출력으로 Run을 실행하는 main 함수도 생성합니다.
+    go:generate와 함께 사용할 수 있도록 -o로 출력 파일 이름을 지정할 수 있습니다.
+
+m2wasm [-o file] [filename]:
+    주어진 MinFuck 코드를 WebAssembly 모듈(.wasm)로 변환합니다.
+    모듈은 env.output(i32)와 env.input() i32를 가져오고, run 함수와 memory를 내보냅니다.
+    input은 입력이 끝나면 음수를 반환해야 하며, 이때 셀은 0이 됩니다.
+
+m2ll [filename]:
+    주어진 MinFuck 코드를 LLVM IR 텍스트 모듈(.ll)로 변환합니다.
+    테이프는 전역 배열이며 입출력은 getchar, putchar를 사용합니다. (예: clang -O2 prog.ll -o prog)
+
+build [-o file] [filename]:
+    주어진 MinFuck 코드를 x86-64 리눅스용 정적 ELF 실행 파일로 변환합니다.
+    어셈블러나 링커가 필요하지 않으며, 출력 파일 이름의 기본값은 입력 파일 이름에서 확장자를 뺀 이름입니다.
+
+방언 (--dialect):
+    bf         Brainfuck
+    ook        Ook! (Ook. Ook? 등 두 단어가 명령어 하나)
+    blub       Blub (Ook!과 같으며 Ook 대신 Blub)
+    spoon      Spoon (0과 1로 이루어진 허프만 부호)
+    tinybf     TinyBF (= 로 +- >< 방향을 바꾸며, == 는 입출력)
+    pbrain     pbrain (Brainfuck에 ( ) 프로시저 정의와 : 호출을 더한 것, v2 인코딩을 사용합니다)
+    brainfork  Brainfork (Brainfuck에 스레드를 복제하는 Y를 더한 것, v2 인코딩을 사용합니다)
+    table:t0,t1,...,t7
+               + - > < [ ] . , 에 대응하는 8개의 토큰을 직접 지정합니다.
+
+확장 (--ext):
+    ebf1       Extended Brainfuck Type I (@ 끝, $ 저장, ! 불러오기, } { 시프트, ~ NOT, ^ XOR, & AND, | OR)
+               셀과 저장소는 32비트이며, v2 인코딩을 사용합니다.
+    debug      # 디버그 덤프 (코드 생성기와 m2b --dialect는 무시합니다)
+
+전처리기 (--pp):
+    #define NAME body        매크로를 정의합니다. 줄 끝의 \ 는 다음 줄로 이어집니다.
+    #define NAME(a, b) body  인자가 있는 매크로이며, NAME(x; y)로 사용합니다. (, 는 입력 명령어이므로 ; 로 구분)
+    #undef NAME              매크로의 정의를 지웁니다.
+    #include "file.bf"       파일을 포함합니다. 순환하는 포함은 오류입니다.
+    #ifdef NAME, #ifndef NAME, #else, #endif
+                             NAME이 정의되어 있는지에 따라 블록을 사용하거나 버립니다.
+    +*10, (>+)*3             문자나 괄호 안을 반복합니다.
+    오류는 원본 파일:줄:열 위치와 함께 출력됩니다.
 `
 
 func main() {
@@ -48,77 +152,311 @@ func main() {
 		run()
 	case "bfr":
 		bfr()
+	case "fmt":
+		format()
+	case "gen-text":
+		genText()
+	case "compile":
+		compilePG()
+	case "keygen":
+		keygen()
+	case "sign":
+		sign()
+	case "info":
+		info()
+	case "m2c":
+		m2c()
+	case "b2c":
+		b2c()
+	case "m2go":
+		m2go()
+	case "m2wasm":
+		m2wasm()
+	case "m2ll":
+		m2ll()
+	case "build":
+		build()
 	default:
 		fmt.Println("정의되지 않은 동작:", os.Args[1])
 		help()
 	}
 }
 
+// parseFlags 함수는 플래그와 위치 인자가 섞여 있어도 모두 해석하고, 위치 인자만 반환합니다.
+func parseFlags(fs *flag.FlagSet, args []string) []string {
+	var pos []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return pos
+		}
+		pos, args = append(pos, args[0]), args[1:]
+	}
+}
+
+// metaFlags 타입은 여러 번 지정할 수 있는 --meta key=value 플래그입니다.
+type metaFlags mf.Metadata
+
+func (m *metaFlags) String() string {
+	return fmt.Sprint(*m)
+}
+
+func (m *metaFlags) Set(s string) error {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return fmt.Errorf("key=value 형식이어야 합니다: %s", s)
+	}
+	(*mf.Metadata)(m).Set(kv[0], kv[1])
+	return nil
+}
+
+// fileMeta 함수는 --meta로 지정한 메타데이터 앞에 소스 파일 이름과 컴파일러 버전을 더합니다.
+// 같은 소스는 항상 같은 파일로 변환되도록, --meta가 없으면 nil을 반환하여 META 섹션을 기록하지 않으며
+// 생성 시각은 --meta build-time=now로 요청할 때만 기록합니다.
+func fileMeta(source string, meta metaFlags) mf.Metadata {
+	if len(meta) == 0 {
+		return nil
+	}
+	var m mf.Metadata
+	m.Set(mf.MetaSource, source)
+	m.Set(mf.MetaCompiler, "minfuck "+version)
+	for _, e := range meta {
+		if e.Key == mf.MetaBuildTime && e.Value == "now" {
+			e.Value = time.Now().UTC().Format(time.RFC3339)
+		}
+		m.Set(e.Key, e.Value)
+	}
+	return m
+}
+
+// defineFlags 타입은 여러 번 지정할 수 있는 --define NAME[=본문] 플래그입니다.
+type defineFlags map[string]string
+
+func (d defineFlags) String() string {
+	return fmt.Sprint(map[string]string(d))
+}
+
+func (d defineFlags) Set(s string) error {
+	kv := strings.SplitN(s, "=", 2)
+	if kv[0] == "" {
+		return fmt.Errorf("NAME 또는 NAME=본문 형식이어야 합니다: %s", s)
+	}
+	if len(kv) == 1 {
+		kv = append(kv, "")
+	}
+	d[kv[0]] = kv[1]
+	return nil
+}
+
+// preprocess 함수는 --pp가 지정되면 소스 코드를 매크로 전처리합니다. 실패하면 프로그램을 종료합니다.
+// 전처리하지 않으면 ExpansionMap은 nil입니다.
+func preprocess(name, src string, pp bool, defs defineFlags) (string, *mf.ExpansionMap) {
+	if !pp {
+		return src, nil
+	}
+	p := &mf.Preprocessor{Defines: defs}
+	out, em, err := p.Preprocess(name, src)
+	if err != nil {
+		fmt.Println("전처리 중 오류:", err)
+		os.Exit(4)
+	}
+	return out, em
+}
+
 func b2m() {
-	if len(os.Args) < 3 {
+	fs := flag.NewFlagSet("b2m", flag.ExitOnError)
+	smap := fs.Bool("map", false, "소스맵을 함께 기록합니다")
+	optimize := fs.Bool("optimize", false, "루프 관용구를 확장 명령어로 변환합니다")
+	compress := fs.Bool("compress", false, "코드를 DEFLATE로 압축하여 기록합니다")
+	enc := fs.Uint("encoding", 1, "코드 인코딩 버전 (1 또는 2)")
+	dialect := fs.String("dialect", "bf", "소스 코드의 방언")
+	ext := fs.String("ext", "", "방언에 더할 확장 (쉼표로 구분)")
+	debug := fs.Bool("debug", false, "# 를 디버그 덤프 명령어로 사용합니다")
+	sep := fs.Bool("input-sep", false, "! 뒤의 내용을 프로그램의 입력으로 사용합니다")
+	pp := fs.Bool("pp", false, "매크로 전처리기를 사용합니다")
+	defs := make(defineFlags)
+	fs.Var(defs, "define", "NAME[=본문] 형식으로 미리 정의할 매크로 (여러 번 지정 가능)")
+	var meta metaFlags
+	fs.Var(&meta, "meta", "key=value 형식의 메타데이터 (여러 번 지정 가능)")
+	args := parseFlags(fs, os.Args[2:])
+	if len(args) < 1 {
 		fmt.Println("변환할 Brainfuck 소스 파일이 필요합니다.")
 		help()
 	}
-	b, err := ioutil.ReadFile(os.Args[2])
+	b, err := ioutil.ReadFile(args[0])
 	if err != nil {
 		fmt.Println("파일 여는 중 오류:", err)
 		os.Exit(3)
 	}
-	mem := uint32(4096)
-	if len(os.Args) >= 4 {
-		n, err := strconv.ParseUint(os.Args[3], 10, 64)
-		if err != nil {
-			fmt.Println("메모리 주소 제한값이 잘못되었습니다.")
-			os.Exit(-1)
-		}
-		if n > 1<<32-1 {
-			fmt.Println("메모리 주소 제한값이 32비트를 초과합니다.")
-			os.Exit(-1)
-		}
-		mem = uint32(n)
+	mem := memArg(args)
+	d := sourceDialect(*dialect, *ext, *debug)
+	src, input := splitSource(d, string(b), *sep)
+	src, em := preprocess(args[0], src, *pp, defs)
+	if *enc > 255 || !mf.Encoding(*enc).Valid() {
+		fmt.Println("지원하지 않는 인코딩 버전입니다:", *enc)
+		os.Exit(-1)
 	}
+	opts := mf.BfOptions{Mem: mem, SourceMap: *smap, Encoding: mf.Encoding(*enc), Optimize: *optimize,
+		Meta: fileMeta(path.Base(args[0]), meta)}
+	fd, sm, err := mf.FromSource(src, d, opts)
+	if err != nil {
+		fmt.Println("소스 코드를 읽는 중 오류:", em.Translate(err))
+		os.Exit(4)
+	}
+	if sm != nil && em != nil {
+		em.Remap(sm)
+		b, _ := sm.MarshalBinary()
+		fd.SetSection(mf.SectionSourceMap, b)
+	}
+	fd.SetInput(input)
+	fd.SetCompressed(*compress)
 	ioutil.WriteFile(
-		os.Args[2][0:len(os.Args[2])-len(path.Ext(os.Args[2]))]+".mf",
-		[]byte(mf.FromBfCode(string(b), mem)),
+		args[0][0:len(args[0])-len(path.Ext(args[0]))]+".mf",
+		[]byte(fd.String()),
 		0644)
 }
 
+// memArg 함수는 두 번째 인자로 주어진 메모리 주소 제한값을 읽습니다. 기본값은 4096입니다.
+func memArg(args []string) uint32 {
+	if len(args) < 2 {
+		return 4096
+	}
+	n, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		fmt.Println("메모리 주소 제한값이 잘못되었습니다.")
+		os.Exit(-1)
+	}
+	if n > 1<<32-1 {
+		fmt.Println("메모리 주소 제한값이 32비트를 초과합니다.")
+		os.Exit(-1)
+	}
+	return uint32(n)
+}
+
+// parseDialect 함수는 --dialect 플래그의 값으로 방언을 찾습니다. 실패하면 프로그램을 종료합니다.
+func parseDialect(name string) mf.Dialect {
+	d, err := mf.ParseDialect(name)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	return d
+}
+
+// sourceDialect 함수는 --dialect, --ext, --debug 플래그의 값으로 소스 코드의 방언을 만듭니다. 실패하면 프로그램을 종료합니다.
+func sourceDialect(dialect, ext string, debug bool) mf.Dialect {
+	d := parseDialect(dialect)
+	var exts []*mf.Extension
+	if ext != "" {
+		for _, name := range strings.Split(ext, ",") {
+			e, err := mf.ParseExtension(name)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(-1)
+			}
+			exts = append(exts, e)
+		}
+	}
+	if debug {
+		exts = append(exts, mf.Debug)
+	}
+	if len(exts) == 0 {
+		return d
+	}
+	d, err := mf.Extend(d, exts...)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	return d
+}
+
+// splitSource 함수는 --input-sep이 지정되면 소스 코드를 ! 앞의 프로그램과 뒤의 입력으로 나눕니다.
+// 방언의 소스 코드에 !가 들어갈 수 있으면 프로그램을 종료합니다.
+func splitSource(d mf.Dialect, src string, sep bool) (string, []byte) {
+	if !sep {
+		return src, nil
+	}
+	if !mf.CanSplitInput(d) {
+		fmt.Printf("%s 방언은 소스 코드에 !를 사용하므로 --input-sep과 함께 쓸 수 없습니다.\n", d.Name())
+		os.Exit(-1)
+	}
+	return mf.SplitInput(src)
+}
+
+// parseScheduler 함수는 --sched 플래그의 값으로 스케줄러를 찾습니다. 실패하면 프로그램을 종료합니다.
+func parseScheduler(name string) mf.Scheduler {
+	s, err := mf.ParseScheduler(name)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+	return s
+}
+
 func m2b() {
-	if len(os.Args) < 3 {
+	fs := flag.NewFlagSet("m2b", flag.ExitOnError)
+	dialect := fs.String("dialect", "", "출력할 방언")
+	args := parseFlags(fs, os.Args[2:])
+	if len(args) < 1 {
 		fmt.Println("변환할 MinFuck 소스 파일이 필요합니다.")
 		help()
 	}
-	b, err := ioutil.ReadFile(os.Args[2])
-	if err != nil {
-		fmt.Println("파일 여는 중 오류:", err)
-		os.Exit(3)
+	d := mf.Brainfuck
+	if *dialect != "" {
+		d = parseDialect(*dialect)
 	}
-	ioutil.WriteFile(
-		os.Args[2][0:len(os.Args[2])-len(path.Ext(os.Args[2]))]+".bf",
-		[]byte(mf.ToBfCode(string(b))),
-		0644)
+	src, err := mf.ToSource(openMF(args[0]), d)
+	ext := ".bf"
+	if _, ok := d.(*mf.TableDialect); !ok && d != mf.Brainfuck {
+		ext = "." + d.Name()
+	}
+	writeOutput(args[0], ext, src, err)
 }
 
 func run() {
-	if len(os.Args) < 3 {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	requireSig := fs.Bool("require-signature", false, "서명되지 않은 프로그램을 거부합니다")
+	trust := fs.String("trust", "", "신뢰하는 공개키(.pub)가 있는 디렉터리")
+	backendName := fs.String("backend", "interp", "실행 방식 (interp, closure)")
+	sched := fs.String("sched", "roundrobin", "fork로 만든 스레드의 스케줄러 (roundrobin, goroutine)")
+	args := parseFlags(fs, os.Args[2:])
+	if len(args) < 1 {
 		fmt.Println("실행할 MinFuck 코드가 필요합니다.")
 		help()
 	}
-	f, err := os.Open(os.Args[2])
+	backend, err := mf.ParseBackend(*backendName)
 	if err != nil {
-		fmt.Println("파일 여는 중 오류:", err)
-		os.Exit(3)
+		fmt.Println(err)
+		help()
 	}
-	vm, err := mf.VMFile(f)
+	fd, f, err := mf.MapFile(args[0])
 	if err != nil {
 		fmt.Println("VM 준비 중 오류:", err)
 		os.Exit(4)
 	}
-	f.Close()
+	if *requireSig || *trust != "" {
+		checkSignature(&fd, *trust, *requireSig)
+	}
+	sm, err := fd.SourceMap()
+	if err != nil {
+		fmt.Println("소스맵을 읽는 중 오류:", err)
+		os.Exit(4)
+	}
+	vm := mf.NewVM(fd)
+	vm.Backend, vm.Scheduler = backend, parseScheduler(*sched)
 	result := make(chan error, 1)
 	vm.Run(nil, result)
-	if err := <-result; err != nil {
+	err = <-result
+	f.Close()
+	if err != nil {
 		fmt.Printf("\n코드가 비정상 종료되었습니다: %s\n", err.Error())
+		if sm != nil {
+			if r, ok := sm.Lookup(vm.PC()); ok {
+				fmt.Printf("Brainfuck 소스 위치: %s (니블 오프셋 %d)\n", r, vm.PC())
+			}
+		}
 		os.Exit(2)
 	} else {
 		fmt.Printf("\n코드가 정상적으로 종료되었습니다\n")
@@ -127,37 +465,41 @@ func run() {
 }
 
 func bfr() {
-	if len(os.Args) < 3 {
+	fs := flag.NewFlagSet("bfr", flag.ExitOnError)
+	dialect := fs.String("dialect", "bf", "소스 코드의 방언")
+	ext := fs.String("ext", "", "방언에 더할 확장 (쉼표로 구분)")
+	debug := fs.Bool("debug", false, "# 를 디버그 덤프 명령어로 사용합니다")
+	sep := fs.Bool("input-sep", false, "! 뒤의 내용을 프로그램의 입력으로 사용합니다")
+	pp := fs.Bool("pp", false, "매크로 전처리기를 사용합니다")
+	defs := make(defineFlags)
+	fs.Var(defs, "define", "NAME[=본문] 형식으로 미리 정의할 매크로 (여러 번 지정 가능)")
+	sched := fs.String("sched", "roundrobin", "fork로 만든 스레드의 스케줄러 (roundrobin, goroutine)")
+	args := parseFlags(fs, os.Args[2:])
+	if len(args) < 1 {
 		fmt.Println("실행할 Brainfuck 코드가 필요합니다.")
 		help()
 	}
-	s, err := ioutil.ReadFile(os.Args[2])
+	d := sourceDialect(*dialect, *ext, *debug)
+	s, err := ioutil.ReadFile(args[0])
 	if err != nil {
 		fmt.Println("파일 여는 중 오류:", err)
 		os.Exit(3)
 	}
-
-	b := new(bytes.Buffer)
-	var bitbuf byte
-	odd := false
-	for _, c := range []byte(s) {
-		c = mf.FromBf(string(c))
-		if c == 255 {
-			continue
-		}
-		if odd {
-			bitbuf = (bitbuf & 0xf0) | c
-			b.WriteByte(bitbuf)
-		} else {
-			bitbuf = c << 4
-		}
-		odd = !odd
+	src, input := splitSource(d, string(s), *sep)
+	src, em := preprocess(args[0], src, *pp, defs)
+	toks, err := d.Tokens(src)
+	if err != nil {
+		fmt.Println("소스 코드를 읽는 중 오류:", em.Translate(err))
+		os.Exit(4)
 	}
-	if odd {
-		b.WriteByte(bitbuf)
+
+	code, enc, err := mf.TokenCode(toks)
+	if err != nil {
+		fmt.Println("소스 코드를 읽는 중 오류:", em.Translate(err))
+		os.Exit(4)
 	}
 
-	vm := mf.MinFuckVM{Code: b.Bytes(), Mem: make([]uint32, 1<<20), Out: os.Stdout, In: os.Stdin}
+	vm := mf.MinFuckVM{Code: code, Encoding: enc, Scheduler: parseScheduler(*sched), Mem: make([]uint32, 1<<20), Out: os.Stdout, In: io.MultiReader(bytes.NewReader(input), os.Stdin)}
 	stop, result := make(chan struct{}, 1), make(chan error, 1)
 	duration, _ := time.ParseDuration("10s")
 	time.AfterFunc(duration, func() {
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/cr0sh/minfuck/mf/pg"
+)
+
+func compilePG() {
+	fs := flag.NewFlagSet("compile", flag.ExitOnError)
+	out := fs.String("o", "", "출력 파일 이름 (기본값: 입력 파일 이름.mf)")
+	smap := fs.Bool("map", false, "소스맵을 함께 기록합니다")
+	var meta metaFlags
+	fs.Var(&meta, "meta", "key=value 형식의 메타데이터 (여러 번 지정 가능)")
+	args := parseFlags(fs, os.Args[2:])
+	if len(args) < 1 {
+		fmt.Println("컴파일할 소스 파일이 필요합니다.")
+		help()
+	}
+	b, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		fmt.Println("파일 여는 중 오류:", err)
+		os.Exit(3)
+	}
+	opts := pg.Options{SourceMap: *smap, Meta: fileMeta(path.Base(args[0]), meta)}
+	fd, _, err := pg.Compile(string(b), opts)
+	if err != nil {
+		fmt.Printf("컴파일 중 오류: %s:%v\n", args[0], err)
+		os.Exit(4)
+	}
+	if *out != "" {
+		writeFile(*out, fd.String(), nil)
+	} else {
+		writeOutput(args[0], ".mf", fd.String(), nil)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/cr0sh/minfuck/mf"
+)
+
+func keygen() {
+	if len(os.Args) < 3 {
+		fmt.Println("생성할 키의 이름이 필요합니다.")
+		help()
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		fmt.Println("키 생성 중 오류:", err)
+		os.Exit(-1)
+	}
+	if err := ioutil.WriteFile(os.Args[2]+".key", []byte(hex.EncodeToString(priv.Seed())+"\n"), 0600); err != nil {
+		fmt.Println("파일 쓰는 중 오류:", err)
+		os.Exit(3)
+	}
+	if err := ioutil.WriteFile(os.Args[2]+".pub", []byte(hex.EncodeToString(pub)+"\n"), 0644); err != nil {
+		fmt.Println("파일 쓰는 중 오류:", err)
+		os.Exit(3)
+	}
+}
+
+func sign() {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	keyfile := fs.String("key", "", "서명에 사용할 개인키 파일")
+	args := parseFlags(fs, os.Args[2:])
+	if len(args) < 1 || *keyfile == "" {
+		fmt.Println("서명할 MinFuck 코드와 개인키 파일(--key)이 필요합니다.")
+		help()
+	}
+	kb, err := ioutil.ReadFile(*keyfile)
+	if err != nil {
+		fmt.Println("파일 여는 중 오류:", err)
+		os.Exit(3)
+	}
+	priv, err := mf.ParsePrivateKey(kb)
+	if err != nil {
+		fmt.Println("개인키를 읽는 중 오류:", err)
+		os.Exit(-1)
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		fmt.Println("파일 여는 중 오류:", err)
+		os.Exit(3)
+	}
+	fd, err := mf.ReadFile(f)
+	f.Close()
+	if err != nil {
+		fmt.Println("MinFuck 코드를 읽는 중 오류:", err)
+		os.Exit(4)
+	}
+	if err := fd.Sign(priv); err != nil {
+		fmt.Println("서명 중 오류:", err)
+		os.Exit(-1)
+	}
+	if err := ioutil.WriteFile(args[0], []byte(fd.String()), 0644); err != nil {
+		fmt.Println("파일 쓰는 중 오류:", err)
+		os.Exit(3)
+	}
+}
+
+// checkSignature 함수는 프로그램의 서명을 검사하고, 신뢰할 수 없으면 종료합니다.
+// require가 false이면 서명되지 않은 프로그램은 그대로 허용합니다.
+func checkSignature(fd *mf.FileData, trustDir string, require bool) {
+	if _, err := fd.Signer(); err == mf.ErrUnsigned && !require {
+		return
+	}
+	var trusted []ed25519.PublicKey
+	if trustDir != "" {
+		var err error
+		if trusted, err = mf.LoadTrustedKeys(trustDir); err != nil {
+			fmt.Println("신뢰하는 키를 읽는 중 오류:", err)
+			os.Exit(3)
+		}
+	}
+	if _, err := fd.Verify(trusted); err != nil {
+		fmt.Println("프로그램을 실행할 수 없습니다:", err)
+		os.Exit(5)
+	}
+}